@@ -7,6 +7,9 @@ import (
 
 type AccountAddedPayload struct {
 	Address flow.Address
+	// Tokens lists the names of the tokens set up for the account when it
+	// was created, besides FlowToken.
+	Tokens []string
 }
 
 type accountAddedHandler interface {
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -13,12 +14,30 @@ type AccountType string
 const AccountTypeCustodial = "custodial"
 const AccountTypeNonCustodial = "non-custodial"
 
+type AccountState string
+
+const AccountStateActive = "active"
+const AccountStateFrozen = "frozen"
+const AccountStateArchived = "archived"
+
 // Account struct represents a storable account.
 type Account struct {
-	Address   string          `json:"address" gorm:"primaryKey"`
-	Keys      []keys.Storable `json:"keys" gorm:"foreignKey:AccountAddress;references:Address;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	Type      AccountType     `json:"type" gorm:"default:custodial"`
-	CreatedAt time.Time       `json:"createdAt" `
-	UpdatedAt time.Time       `json:"updatedAt"`
-	DeletedAt gorm.DeletedAt  `json:"-" gorm:"index"`
+	Address    string          `json:"address" gorm:"primaryKey"`
+	Keys       []keys.Storable `json:"keys" gorm:"foreignKey:AccountAddress;references:Address;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Type       AccountType     `json:"type" gorm:"default:custodial"`
+	State      AccountState    `json:"state" gorm:"column:state;default:active;index"`
+	ExternalID *string         `json:"externalId,omitempty" gorm:"column:external_id;uniqueIndex"`
+	Labels     []string        `json:"labels,omitempty" gorm:"-"`
+	Attributes datatypes.JSON  `json:"attributes,omitempty" gorm:"column:attributes"`
+	// Pooled accounts are pre-created and not yet assigned to anyone.
+	Pooled    bool           `json:"-" gorm:"column:pooled;index"`
+	CreatedAt time.Time      `json:"createdAt" `
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// AccountLabel struct represents a storable label of an account.
+type AccountLabel struct {
+	AccountAddress string `gorm:"column:account_address;primaryKey"`
+	Label          string `gorm:"column:label;primaryKey;index"`
 }
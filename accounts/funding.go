@@ -0,0 +1,160 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/templates"
+	"github.com/google/uuid"
+	"github.com/onflow/cadence"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitialFundingJobType is the type of the job transferring the initial
+// funding to a new account. The job is executed by the tokens service, which
+// records the transfer.
+const InitialFundingJobType = "initial_funding"
+
+// InitialFundingJobAttributes are the attributes of an initial funding job.
+type InitialFundingJobAttributes struct {
+	Address  string  `json:"address"`
+	FundedBy string  `json:"fundedBy"`
+	Funding  Funding `json:"funding"`
+	// AccountJobID is the ID of the job that created the account, if any.
+	AccountJobID uuid.UUID `json:"accountJobId,omitempty"`
+}
+
+// Funding is an amount of a fungible token transferred to a new account.
+type Funding struct {
+	TokenName string `json:"token"`
+	Amount    string `json:"amount"`
+}
+
+// initialFunding returns the funding for a new account, falling back to the
+// configured default when none was requested. A zero amount disables
+// funding. The account must have a vault for the token, so it must be
+// FlowToken or one of the tokens set up when creating the account.
+func (s *ServiceImpl) initialFunding(requested *Funding, setups []tokenSetup) (*Funding, error) {
+	f := requested
+	if f == nil {
+		if s.cfg.InitialFundingAmount == "" {
+			return nil, nil
+		}
+		f = &Funding{TokenName: s.cfg.InitialFundingToken, Amount: s.cfg.InitialFundingAmount}
+	}
+
+	text := strings.TrimSpace(f.Amount)
+	if !strings.Contains(text, ".") {
+		text += ".0"
+	}
+
+	amount, err := cadence.NewUFix64(text)
+	if err != nil {
+		return nil, fundingError(fmt.Errorf("invalid amount %q: %w", f.Amount, err))
+	}
+
+	if amount == 0 {
+		return nil, nil
+	}
+
+	token, err := s.templates.GetTokenByName(f.TokenName)
+	if err != nil {
+		if strings.Contains(err.Error(), "record not found") {
+			return nil, fundingError(fmt.Errorf("token %q not enabled", f.TokenName))
+		}
+		return nil, err
+	}
+
+	if token.Type != templates.FT {
+		return nil, fundingError(fmt.Errorf("%s is not a fungible token", token.Name))
+	}
+
+	hasVault := token.Name == flowTokenName
+	for _, setup := range setups {
+		hasVault = hasVault || setup.tokenName == token.Name
+	}
+
+	if !hasVault {
+		return nil, fundingError(fmt.Errorf("%s vault is not set up, include it in the tokens of the account", token.Name))
+	}
+
+	return &Funding{TokenName: token.Name, Amount: amount.String()}, nil
+}
+
+// fundingAccount returns the address of the account that funds new accounts.
+func (s *ServiceImpl) fundingAccount() string {
+	if s.cfg.InitialFundingAccount != "" {
+		return flow_helpers.HexString(s.cfg.InitialFundingAccount)
+	}
+	return s.cfg.AdminAddress
+}
+
+// CheckFundingAccount returns an error if the configured funding account is
+// not the admin account or a stored custodial account, as the service could
+// not sign the funding transfers.
+func (s *ServiceImpl) CheckFundingAccount() error {
+	if s.cfg.InitialFundingAccount == "" {
+		return nil
+	}
+
+	address, err := flow_helpers.ValidateAddress(s.cfg.InitialFundingAccount, s.cfg.ChainID)
+	if err != nil {
+		return fmt.Errorf("invalid INITIAL_FUNDING_ACCOUNT: %w", err)
+	}
+
+	if address == s.cfg.AdminAddress {
+		return nil
+	}
+
+	a, err := s.store.Account(address)
+	if err != nil {
+		return fmt.Errorf("invalid INITIAL_FUNDING_ACCOUNT %s: %w", address, err)
+	}
+
+	if a.Type != AccountTypeCustodial {
+		return fmt.Errorf("invalid INITIAL_FUNDING_ACCOUNT %s: not a custodial account", address)
+	}
+
+	return nil
+}
+
+// scheduleInitialFunding schedules the job transferring the funding to the
+// new account.
+func (s *ServiceImpl) scheduleInitialFunding(address string, f Funding, accountJobID uuid.UUID) (*jobs.Job, error) {
+	attrBytes, err := json.Marshal(InitialFundingJobAttributes{
+		Address:      address,
+		FundedBy:     s.fundingAccount(),
+		Funding:      f,
+		AccountJobID: accountJobID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.wp.CreateJob(InitialFundingJobType, "", jobs.WithAttributes(attrBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error while creating initial funding job for account %s: %w", address, err)
+	}
+
+	if err := s.wp.Schedule(job); err != nil {
+		return nil, fmt.Errorf("error while scheduling initial funding job for account %s: %w", address, err)
+	}
+
+	log.
+		WithFields(log.Fields{"address": address, "jobId": job.ID, "accountJobId": accountJobID}).
+		Debug("Scheduled initial funding of new account")
+
+	return job, nil
+}
+
+func fundingError(err error) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf("invalid initial funding: %w", err),
+	}
+}
@@ -0,0 +1,109 @@
+package accounts
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/templates"
+	"github.com/onflow/flow-go-sdk"
+)
+
+type tokenTemplates struct {
+	templates.Service
+	tokens map[string]*templates.Token
+}
+
+func (s tokenTemplates) GetTokenByName(name string) (*templates.Token, error) {
+	if t, ok := s.tokens[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("record not found")
+}
+
+func TestInitialFunding(t *testing.T) {
+	svc := &ServiceImpl{
+		cfg: &configs.Config{InitialFundingToken: "FlowToken"},
+		templates: tokenTemplates{tokens: map[string]*templates.Token{
+			"FlowToken":  {Name: "FlowToken", Type: templates.FT},
+			"FUSD":       {Name: "FUSD", Type: templates.FT},
+			"ExampleNFT": {Name: "ExampleNFT", Type: templates.NFT},
+		}},
+	}
+
+	fusdSetup := []tokenSetup{{tokenName: "FUSD"}}
+
+	testCases := []struct {
+		name      string
+		defAmount string
+		requested *Funding
+		setups    []tokenSetup
+		expected  *Funding
+		expectErr bool
+	}{
+		{name: "no funding"},
+		{name: "configured default", defAmount: "0.5", expected: &Funding{TokenName: "FlowToken", Amount: "0.50000000"}},
+		{name: "requested", requested: &Funding{TokenName: "FlowToken", Amount: "1"}, expected: &Funding{TokenName: "FlowToken", Amount: "1.00000000"}},
+		{name: "zero overrides default", defAmount: "0.5", requested: &Funding{TokenName: "FlowToken", Amount: "0"}},
+		{name: "set up token", requested: &Funding{TokenName: "FUSD", Amount: "2.5"}, setups: fusdSetup, expected: &Funding{TokenName: "FUSD", Amount: "2.50000000"}},
+		{name: "token without vault", requested: &Funding{TokenName: "FUSD", Amount: "2.5"}, expectErr: true},
+		{name: "non-fungible token", requested: &Funding{TokenName: "ExampleNFT", Amount: "1"}, expectErr: true},
+		{name: "unknown token", requested: &Funding{TokenName: "Unknown", Amount: "1"}, expectErr: true},
+		{name: "invalid amount", requested: &Funding{TokenName: "FlowToken", Amount: "-1"}, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc.cfg.InitialFundingAmount = tc.defAmount
+
+			f, err := svc.initialFunding(tc.requested, tc.setups)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (f == nil) != (tc.expected == nil) || (f != nil && *f != *tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, f)
+			}
+		})
+	}
+}
+
+func TestCheckFundingAccount(t *testing.T) {
+	svc := &ServiceImpl{
+		cfg: &configs.Config{ChainID: flow.Emulator, AdminAddress: "0xf8d6e0586b0a20c7"},
+		store: &accountStore{accounts: map[string]Account{
+			"0x01cf0e2f2f715450": {Address: "0x01cf0e2f2f715450", Type: AccountTypeCustodial},
+			"0x179b6b1cb6755e31": {Address: "0x179b6b1cb6755e31", Type: AccountTypeNonCustodial},
+		}},
+	}
+
+	testCases := []struct {
+		account   string
+		expectErr bool
+	}{
+		{account: ""},
+		{account: "0xf8d6e0586b0a20c7"},
+		{account: "0x01cf0e2f2f715450"},
+		{account: "01cf0e2f2f715450"},
+		{account: "0x179b6b1cb6755e31", expectErr: true},
+		{account: "0xf3fcd2c1a78f5eee", expectErr: true},
+		{account: "0x1", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.account, func(t *testing.T) {
+			svc.cfg.InitialFundingAccount = tc.account
+
+			err := svc.CheckFundingAccount()
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error: %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/onflow/flow-go-sdk"
+	log "github.com/sirupsen/logrus"
+)
+
+// ImportedKey is a private key, or a KMS key reference, of an existing
+// account that is imported into custody.
+type ImportedKey struct {
+	// Index of the on-chain key.
+	Index int `json:"index"`
+	// Type of the key, one of: local, google_kms, aws_kms, vault_transit,
+	// pkcs11. Defaults to local.
+	Type string `json:"type,omitempty"`
+	// Value is the hex encoded private key, the KMS key resource ID, the
+	// Vault transit key as <mount>/<key name>:<version> or the PKCS#11 key
+	// pair label.
+	Value string `json:"value"`
+}
+
+// ImportAccount adds an existing account as a custodial account. Each key
+// must match a non-revoked on-chain key and the keys must have enough
+// combined weight to sign.
+func (s *ServiceImpl) ImportAccount(ctx context.Context, address string, importedKeys []ImportedKey, opts ...AccountOption) (*Account, error) {
+	log.WithFields(log.Fields{"address": address}).Trace("Import account")
+
+	o := parseAccountOptions(opts)
+
+	if err := o.Metadata.validate(); err != nil {
+		return nil, err
+	}
+
+	flowAddress := flow.HexToAddress(address)
+	if !flowAddress.IsValid(s.cfg.ChainID) {
+		return nil, importError(fmt.Errorf(`not a valid address for %s: "%s"`, s.cfg.ChainID, address))
+	}
+
+	if len(importedKeys) == 0 {
+		return nil, importError(fmt.Errorf("at least one key is required"))
+	}
+
+	a := &Account{
+		Address: flow_helpers.FormatAddress(flowAddress),
+		Type:    AccountTypeCustodial,
+		State:   AccountStateActive,
+	}
+
+	if _, err := s.store.Account(a.Address); err == nil {
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusConflict,
+			Err:        fmt.Errorf("account already exists: %s", a.Address),
+		}
+	} else if !strings.Contains(err.Error(), "record not found") {
+		return nil, err
+	}
+
+	o.Metadata.applyTo(a)
+
+	if err := s.checkExternalID(a.ExternalID, ""); err != nil {
+		return nil, err
+	}
+
+	flowAccount, err := s.fc.GetAccount(ctx, flowAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[int]bool{}
+	weights := map[string]int{}
+	for _, k := range importedKeys {
+		if seen[k.Index] {
+			return nil, importError(fmt.Errorf("duplicate key index %d", k.Index))
+		}
+		seen[k.Index] = true
+
+		storable, err := s.importKey(ctx, flowAccount, k)
+		if err != nil {
+			return nil, err
+		}
+
+		a.Keys = append(a.Keys, storable)
+		weights[storable.PublicKey] = flowAccount.Keys[k.Index].Weight
+	}
+
+	// Clones of a key can not sign together, so count each public key once
+	weight := 0
+	for _, w := range weights {
+		weight += w
+	}
+
+	if weight < flow.AccountKeyWeightThreshold {
+		return nil, importError(fmt.Errorf("combined key weight %d is below the threshold %d", weight, flow.AccountKeyWeightThreshold))
+	}
+
+	if err := s.store.InsertAccount(a); err != nil {
+		return nil, err
+	}
+
+	AccountAdded.Trigger(AccountAddedPayload{Address: flow.HexToAddress(a.Address)})
+
+	log.WithFields(log.Fields{"address": a.Address, "keys": len(a.Keys)}).Debug("Account imported")
+
+	return a, nil
+}
+
+// importKey verifies that the key matches the on-chain key at its index and
+// converts it to storable form.
+func (s *ServiceImpl) importKey(ctx context.Context, flowAccount *flow.Account, k ImportedKey) (keys.Storable, error) {
+	if k.Index < 0 || k.Index >= len(flowAccount.Keys) {
+		return keys.Storable{}, importError(fmt.Errorf("key index %d not found on-chain", k.Index))
+	}
+
+	accountKey := flowAccount.Keys[k.Index]
+
+	if accountKey.Revoked {
+		return keys.Storable{}, importError(fmt.Errorf("key %d is revoked", k.Index))
+	}
+
+	private := keys.Private{
+		Index:    k.Index,
+		Type:     k.Type,
+		Value:    strings.TrimSpace(k.Value),
+		SignAlgo: accountKey.SigAlgo,
+		HashAlgo: accountKey.HashAlgo,
+	}
+
+	if private.Type == "" {
+		private.Type = keys.AccountKeyTypeLocal
+	}
+
+	if private.Type == keys.AccountKeyTypeLocal {
+		private.Value = strings.TrimPrefix(private.Value, "0x")
+	}
+
+	signer, err := s.km.Signer(ctx, private)
+	if err != nil {
+		return keys.Storable{}, importError(fmt.Errorf("invalid key %d: %w", k.Index, err))
+	}
+
+	if !signer.PublicKey().Equals(accountKey.PublicKey) {
+		return keys.Storable{}, importError(fmt.Errorf("key %d does not match the on-chain public key", k.Index))
+	}
+
+	// Convert the key to storable form (encrypt it)
+	storable, err := s.km.Save(private)
+	if err != nil {
+		return keys.Storable{}, err
+	}
+	storable.PublicKey = accountKey.PublicKey.String()
+
+	return storable, nil
+}
+
+func importError(err error) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf("cannot import account: %w", err),
+	}
+}
@@ -19,8 +19,28 @@ func (s *ServiceImpl) executeAccountCreateJob(ctx context.Context, j *jobs.Job)
 
 	j.ShouldSendNotification = true
 
-	a, txID, err := s.createAccount(ctx)
+	var o accountOptions
+	if len(j.Attributes) > 0 {
+		if err := json.Unmarshal(j.Attributes, &o); err != nil {
+			return err
+		}
+	}
+
+	o.jobID = j.ID
+
+	// A conflicting external ID won't be resolved by retrying
+	if err := s.checkExternalID(o.Metadata.ExternalID, ""); err != nil {
+		return jobs.PermanentFailure(err)
+	}
+
+	a, txID, err := s.createAccount(ctx, o)
 	if err != nil {
+		if a != nil {
+			// The account was created and stored, retrying would create another
+			j.TransactionID = txID
+			j.Result = a.Address
+			return jobs.PermanentFailure(err)
+		}
 		return err
 	}
 
@@ -33,8 +53,9 @@ func (s *ServiceImpl) executeAccountCreateJob(ctx context.Context, j *jobs.Job)
 const SyncAccountKeyCountJobType = "sync_account_key_count"
 
 type syncAccountKeyCountJobAttributes struct {
-	Address flow.Address `json:"address"`
-	NumKeys int          `json:"numkeys"`
+	Address           flow.Address `json:"address"`
+	NumKeys           int          `json:"numkeys"`
+	RevokeUnknownKeys bool         `json:"revokeUnknownKeys"`
 }
 
 func (s *ServiceImpl) executeSyncAccountKeyCountJob(ctx context.Context, j *jobs.Job) error {
@@ -53,14 +74,19 @@ func (s *ServiceImpl) executeSyncAccountKeyCountJob(ctx context.Context, j *jobs
 
 	entry.WithFields(log.Fields{"attrs": j.Attributes}).Trace("Unmarshaled attributes")
 
-	numKeys, txID, err := s.syncAccountKeyCount(ctx, attrs.Address, attrs.NumKeys)
-	entry.WithFields(log.Fields{"numKeys": numKeys, "txId": txID, "err": err}).Trace("s.syncAccountKeyCount complete")
+	report, txID, err := s.syncAccountKeyCount(ctx, attrs.Address, attrs.NumKeys, attrs.RevokeUnknownKeys)
+	entry.WithFields(log.Fields{"report": report, "txId": txID, "err": err}).Trace("s.syncAccountKeyCount complete")
 	if err != nil {
 		return err
 	}
 
+	// The drift report is stored separately, see KeyDriftReport
+	if err := s.store.InsertKeyDriftReport(j.ID, report); err != nil {
+		return err
+	}
+
 	j.TransactionID = txID
-	j.Result = fmt.Sprintf("%s:%d", attrs.Address, numKeys)
+	j.Result = fmt.Sprintf("%s:%d", report.Address, report.NumKeys)
 
 	return nil
 }
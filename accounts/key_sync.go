@@ -0,0 +1,343 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/flow-hydraulics/flow-wallet-api/templates/template_strings"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
+	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
+	flow_crypto "github.com/onflow/flow-go-sdk/crypto"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+)
+
+// KeyDriftReport describes how the on-chain keys of an account differed from
+// the stored keys and what was done to reconcile them.
+type KeyDriftReport struct {
+	Address string `json:"address"`
+	// Number of stored keys after the sync
+	NumKeys int `json:"numKeys"`
+	// Indices of non-revoked on-chain keys matching a stored key before the sync
+	ValidKeys []int `json:"validKeys"`
+	// Indices of stored keys that were revoked or missing on-chain
+	StaleKeys []int `json:"staleKeys"`
+	// Number of cloned keys added on-chain
+	AddedKeys int `json:"addedKeys"`
+	// Indices of surplus clones revoked on-chain
+	RevokedKeys []int `json:"revokedKeys"`
+	// Non-revoked on-chain keys not stored in the database
+	UnknownKeys []UnknownAccountKey `json:"unknownKeys"`
+}
+
+// StoredKeyDriftReport is the database model of the drift report of a key
+// count sync job.
+type StoredKeyDriftReport struct {
+	JobID     uuid.UUID      `gorm:"column:job_id;primaryKey;type:uuid"`
+	Address   string         `gorm:"column:address;index"`
+	Report    datatypes.JSON `gorm:"column:report"`
+	CreatedAt time.Time      `gorm:"column:created_at"`
+	UpdatedAt time.Time      `gorm:"column:updated_at"`
+}
+
+func (StoredKeyDriftReport) TableName() string {
+	return "account_key_drift_reports"
+}
+
+// UnknownAccountKey is an on-chain key the service has no private key for.
+type UnknownAccountKey struct {
+	Index     int    `json:"index"`
+	PublicKey string `json:"publicKey"`
+	SignAlgo  string `json:"signAlgo"`
+	HashAlgo  string `json:"hashAlgo"`
+	Weight    int    `json:"weight"`
+	// Whether the key was revoked by the sync
+	Revoked bool `json:"revoked"`
+}
+
+// keySyncPlan is the on-chain key changes needed to reconcile an account.
+type keySyncPlan struct {
+	report *KeyDriftReport
+	clones []keyClones
+	revoke []int
+}
+
+// keyClones is a stored key to add count clones of on-chain.
+type keyClones struct {
+	key    keys.Storable
+	weight int
+	count  int
+}
+
+// planKeySync compares the on-chain keys with the stored service keys. Each
+// distinct stored key is synced to numKeys clones, surplus clones with the
+// highest indices are revoked. Unknown keys are revoked only if revokeUnknown
+// is set. Recovery keys are left as they are.
+func planKeySync(flowAccount *flow.Account, stored, recovery []keys.Storable, numKeys int, revokeUnknown bool) keySyncPlan {
+	storedByPbk := storedKeysByPublicKey(stored)
+	recoveryByPbk := storedKeysByPublicKey(recovery)
+
+	plan := keySyncPlan{
+		report: &KeyDriftReport{
+			ValidKeys:   []int{},
+			StaleKeys:   []int{},
+			RevokedKeys: []int{},
+			UnknownKeys: []UnknownAccountKey{},
+		},
+		clones: []keyClones{},
+		revoke: []int{},
+	}
+
+	var valid []*flow.AccountKey
+	for _, key := range flowAccount.Keys {
+		if key.Revoked {
+			continue
+		}
+
+		if _, ok := recoveryByPbk[publicKeyHex(key.PublicKey.String())]; ok {
+			continue
+		}
+
+		if _, ok := storedByPbk[publicKeyHex(key.PublicKey.String())]; ok {
+			valid = append(valid, key)
+			continue
+		}
+
+		plan.report.UnknownKeys = append(plan.report.UnknownKeys, UnknownAccountKey{
+			Index:     key.Index,
+			PublicKey: key.PublicKey.String(),
+			SignAlgo:  key.SigAlgo.String(),
+			HashAlgo:  key.HashAlgo.String(),
+			Weight:    key.Weight,
+		})
+	}
+
+	sort.SliceStable(valid, func(i, j int) bool {
+		return valid[i].Index < valid[j].Index
+	})
+
+	validIndices := map[int]bool{}
+	for _, key := range valid {
+		validIndices[key.Index] = true
+		plan.report.ValidKeys = append(plan.report.ValidKeys, key.Index)
+	}
+
+	for _, k := range stored {
+		if !validIndices[k.Index] {
+			plan.report.StaleKeys = append(plan.report.StaleKeys, k.Index)
+		}
+	}
+
+	// Group the valid clones by public key, stored keys without a valid
+	// clone can not be synced
+	pbks := []string{}
+	clonesByPbk := map[string][]*flow.AccountKey{}
+	for _, key := range valid {
+		pbk := publicKeyHex(key.PublicKey.String())
+		if _, ok := clonesByPbk[pbk]; !ok {
+			pbks = append(pbks, pbk)
+		}
+		clonesByPbk[pbk] = append(clonesByPbk[pbk], key)
+	}
+
+	for _, pbk := range pbks {
+		clones := clonesByPbk[pbk]
+
+		if len(clones) < numKeys {
+			plan.clones = append(plan.clones, keyClones{
+				key:    storedByPbk[pbk],
+				weight: clones[0].Weight,
+				count:  numKeys - len(clones),
+			})
+			plan.report.AddedKeys += numKeys - len(clones)
+			continue
+		}
+
+		// Keep the clones with the lowest indices
+		for _, key := range clones[numKeys:] {
+			plan.revoke = append(plan.revoke, key.Index)
+			plan.report.RevokedKeys = append(plan.report.RevokedKeys, key.Index)
+		}
+	}
+
+	if revokeUnknown {
+		for i := range plan.report.UnknownKeys {
+			plan.report.UnknownKeys[i].Revoked = true
+			plan.revoke = append(plan.revoke, plan.report.UnknownKeys[i].Index)
+		}
+	}
+
+	return plan
+}
+
+// syncAccountKeyCount syncs the number of account keys with the given numKeys,
+// revoking surplus clones and optionally unknown keys. The stored keys are
+// updated to match the on-chain keys. Returns a drift report, transaction ID
+// and error.
+func (s *ServiceImpl) syncAccountKeyCount(ctx context.Context, address flow.Address, numKeys int, revokeUnknown bool) (*KeyDriftReport, string, error) {
+	entry := log.WithFields(log.Fields{"address": address, "numKeys": numKeys, "function": "ServiceImpl.syncAccountKeyCount"})
+
+	if numKeys < 1 {
+		return nil, "", fmt.Errorf("invalid number of keys specified: %d, min. 1 expected", numKeys)
+	}
+
+	// Check on-chain keys
+	flowAccount, err := s.fc.GetAccount(ctx, address)
+	if err != nil {
+		entry.WithFields(log.Fields{"err": err}).Error("failed to get Flow account")
+		return nil, "", err
+	}
+
+	// Get stored account
+	dbAccount, err := s.store.Account(flow_helpers.FormatAddress(address))
+	if err != nil {
+		entry.WithFields(log.Fields{"err": err}).Error("failed to get account from database")
+		return nil, "", err
+	}
+
+	service, recovery := serviceKeys(dbAccount.Keys), recoveryKeys(dbAccount.Keys)
+
+	if len(service) == 0 {
+		return nil, "", fmt.Errorf("no stored keys for account %s", dbAccount.Address)
+	}
+
+	plan := planKeySync(flowAccount, service, recovery, numKeys, revokeUnknown)
+	plan.report.Address = dbAccount.Address
+
+	if len(plan.report.StaleKeys) > 0 || len(plan.report.UnknownKeys) > 0 {
+		entry.WithFields(log.Fields{"staleKeys": plan.report.StaleKeys, "unknownKeys": len(plan.report.UnknownKeys)}).Warn("on-chain vs. database key mismatch")
+	}
+
+	// Drop stale keys before sending the transaction so they are not used for signing
+	stored, err := s.storeValidKeys(dbAccount.Address, flowAccount, service, recovery)
+	if err != nil {
+		entry.WithFields(log.Fields{"err": err}).Error("failed to update keys in database")
+		return nil, "", err
+	}
+
+	if len(stored) == 0 {
+		return nil, "", fmt.Errorf("no valid keys for account %s", dbAccount.Address)
+	}
+
+	var txID string
+
+	if len(plan.clones) > 0 || len(plan.revoke) > 0 {
+		entry.WithFields(log.Fields{"addedKeys": plan.report.AddedKeys, "revoke": plan.revoke}).Debug("going to add and revoke keys")
+
+		added := make([]addedAccountKey, len(plan.clones))
+		for i, c := range plan.clones {
+			added[i] = addedAccountKey{
+				publicKey: c.key.PublicKey,
+				signAlgo:  flow_crypto.StringToSignatureAlgorithm(c.key.SignAlgo),
+				hashAlgo:  flow_crypto.StringToHashAlgorithm(c.key.HashAlgo),
+				weight:    c.weight,
+				count:     c.count,
+			}
+		}
+
+		args, err := addAndRevokeKeysArgs(added, plan.revoke)
+		if err != nil {
+			return nil, "", err
+		}
+
+		// NOTE: sync, so will wait for transaction to be sent & sealed
+		_, tx, err := s.txs.Create(ctx, true, dbAccount.Address, template_strings.RotateAccountKeyTransaction, args, transactions.General)
+		if tx != nil {
+			txID = tx.TransactionId
+		}
+		if err != nil {
+			entry.WithFields(log.Fields{"err": err}).Error("failed to create transaction")
+			return nil, txID, err
+		}
+
+		flowAccount, err = s.fc.GetAccount(ctx, address)
+		if err != nil {
+			return nil, txID, err
+		}
+
+		// Store the added clones and drop the revoked keys
+		stored, err = s.storeValidKeys(dbAccount.Address, flowAccount, stored, recovery)
+		if err != nil {
+			entry.WithFields(log.Fields{"err": err}).Error("failed to update keys in database")
+			return nil, txID, err
+		}
+	}
+
+	plan.report.NumKeys = len(stored)
+
+	return plan.report, txID, nil
+}
+
+// storeValidKeys replaces the stored service keys of an account with the
+// non-revoked on-chain keys the service has a private key for, if they
+// differ. Recovery keys are kept. Returns the service keys stored after the
+// update.
+func (s *ServiceImpl) storeValidKeys(address string, flowAccount *flow.Account, stored, recovery []keys.Storable) ([]keys.Storable, error) {
+	storedByPbk := storedKeysByPublicKey(stored)
+
+	valid := []keys.Storable{}
+	for _, key := range flowAccount.Keys {
+		if key.Revoked {
+			continue
+		}
+
+		if k, ok := storedByPbk[publicKeyHex(key.PublicKey.String())]; ok {
+			k.ID = 0 // Reset ID to create a new key to DB
+			k.Index = key.Index
+			valid = append(valid, k)
+		}
+	}
+
+	if sameKeyIndices(stored, valid) {
+		return stored, nil
+	}
+
+	replaced := append([]keys.Storable{}, valid...)
+	for _, k := range recovery {
+		k.ID = 0 // Reset ID to create a new key to DB
+		replaced = append(replaced, k)
+	}
+
+	if err := s.store.ReplaceAccountKeys(address, replaced); err != nil {
+		return nil, err
+	}
+
+	return valid, nil
+}
+
+func storedKeysByPublicKey(stored []keys.Storable) map[string]keys.Storable {
+	byPbk := make(map[string]keys.Storable, len(stored))
+	for _, k := range stored {
+		byPbk[publicKeyHex(k.PublicKey)] = k
+	}
+	return byPbk
+}
+
+func sameKeyIndices(a, b []keys.Storable) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	indices := make(map[int]bool, len(a))
+	for _, k := range a {
+		indices[k.Index] = true
+	}
+
+	for _, k := range b {
+		if !indices[k.Index] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func publicKeyHex(pbk string) string {
+	return strings.TrimPrefix(strings.ToLower(pbk), "0x")
+}
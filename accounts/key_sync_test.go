@@ -0,0 +1,112 @@
+package accounts
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+func testPublicKey(t *testing.T, seedByte byte) crypto.PublicKey {
+	seed := make([]byte, crypto.MinSeedLength)
+	seed[0] = seedByte
+	pk, err := crypto.GeneratePrivateKey(crypto.ECDSA_P256, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pk.PublicKey()
+}
+
+func TestPlanKeySync(t *testing.T) {
+	storedPbk := testPublicKey(t, 1)
+	foreignPbk := testPublicKey(t, 2)
+
+	account := &flow.Account{Keys: []*flow.AccountKey{
+		{Index: 0, PublicKey: storedPbk, Weight: 1000, Revoked: true},
+		{Index: 1, PublicKey: storedPbk, Weight: 1000},
+		{Index: 2, PublicKey: storedPbk, Weight: 1000},
+		{Index: 3, PublicKey: foreignPbk, Weight: 500},
+		{Index: 4, PublicKey: storedPbk, Weight: 1000},
+	}}
+
+	stored := []keys.Storable{
+		{Index: 0, PublicKey: storedPbk.String()},
+		{Index: 1, PublicKey: storedPbk.String()},
+		{Index: 2, PublicKey: storedPbk.String()},
+	}
+
+	t.Run("shrink", func(t *testing.T) {
+		plan := planKeySync(account, stored, nil, 2, false)
+
+		if !reflect.DeepEqual(plan.revoke, []int{4}) || len(plan.clones) != 0 {
+			t.Errorf("expected the surplus clone with the highest index to be revoked, got %v %+v", plan.revoke, plan.clones)
+		}
+
+		if !reflect.DeepEqual(plan.report.ValidKeys, []int{1, 2, 4}) {
+			t.Errorf("expected valid keys 1, 2 and 4, got %v", plan.report.ValidKeys)
+		}
+
+		if !reflect.DeepEqual(plan.report.StaleKeys, []int{0}) {
+			t.Errorf("expected the revoked stored key to be stale, got %v", plan.report.StaleKeys)
+		}
+
+		if len(plan.report.UnknownKeys) != 1 || plan.report.UnknownKeys[0].Index != 3 || plan.report.UnknownKeys[0].Revoked {
+			t.Errorf("expected the foreign key to be reported but not revoked, got %+v", plan.report.UnknownKeys)
+		}
+	})
+
+	t.Run("grow and revoke unknown", func(t *testing.T) {
+		plan := planKeySync(account, stored, nil, 5, true)
+
+		if !reflect.DeepEqual(plan.revoke, []int{3}) || len(plan.clones) != 1 || plan.clones[0].count != 2 {
+			t.Errorf("expected 2 clones and the foreign key revoked, got %v %+v", plan.revoke, plan.clones)
+		}
+
+		if !plan.report.UnknownKeys[0].Revoked || plan.report.AddedKeys != 2 {
+			t.Errorf("expected the report to reflect the changes, got %+v", plan.report)
+		}
+
+		if plan.clones[0].weight != 1000 || plan.clones[0].key.PublicKey != storedPbk.String() {
+			t.Errorf("expected to clone the valid stored key, got %+v", plan.clones[0])
+		}
+	})
+}
+
+func TestPlanKeySyncMultipleKeys(t *testing.T) {
+	pbk1 := testPublicKey(t, 1)
+	pbk2 := testPublicKey(t, 2)
+	pbk3 := testPublicKey(t, 3)
+
+	account := &flow.Account{Keys: []*flow.AccountKey{
+		{Index: 0, PublicKey: pbk1, Weight: 500},
+		{Index: 1, PublicKey: pbk2, Weight: 300},
+		{Index: 2, PublicKey: pbk2, Weight: 300},
+		{Index: 3, PublicKey: pbk2, Weight: 300},
+		{Index: 4, PublicKey: pbk3, Weight: 200, Revoked: true},
+	}}
+
+	stored := []keys.Storable{
+		{Index: 0, PublicKey: pbk1.String()},
+		{Index: 1, PublicKey: pbk2.String()},
+		{Index: 2, PublicKey: pbk2.String()},
+		{Index: 3, PublicKey: pbk2.String()},
+		{Index: 4, PublicKey: pbk3.String()},
+	}
+
+	plan := planKeySync(account, stored, nil, 2, false)
+
+	// Each key is synced with its own weight
+	if len(plan.clones) != 1 || plan.clones[0].key.PublicKey != pbk1.String() || plan.clones[0].weight != 500 || plan.clones[0].count != 1 {
+		t.Errorf("expected a clone of the first key, got %+v", plan.clones)
+	}
+
+	if !reflect.DeepEqual(plan.revoke, []int{3}) {
+		t.Errorf("expected the surplus clone of the second key to be revoked, got %v", plan.revoke)
+	}
+
+	if !reflect.DeepEqual(plan.report.StaleKeys, []int{4}) || plan.report.AddedKeys != 1 {
+		t.Errorf("expected the report to reflect the changes, got %+v", plan.report)
+	}
+}
@@ -0,0 +1,99 @@
+package accounts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"gorm.io/datatypes"
+)
+
+// Metadata is free-form metadata of an account: an external reference such
+// as a user ID, labels for searching and arbitrary JSON attributes.
+// Nil fields are left unchanged when updating an account. Attributes are kept
+// as raw JSON, so that null attributes can be told apart from missing ones.
+type Metadata struct {
+	ExternalID *string         `json:"externalId,omitempty"`
+	Labels     *[]string       `json:"labels,omitempty"`
+	Attributes json.RawMessage `json:"attributes,omitempty"`
+}
+
+// AccountFilter restricts the accounts returned when listing. Zero values are
+// ignored. Accounts must have all of the given labels.
+type AccountFilter struct {
+	ExternalID string
+	Labels     []string
+	// State of the accounts to list, archived accounts are only listed when
+	// requested explicitly.
+	State AccountState
+}
+
+func (m Metadata) validate() error {
+	if m.Labels != nil {
+		for _, l := range *m.Labels {
+			if strings.TrimSpace(l) == "" {
+				return metadataError(fmt.Errorf("empty label"))
+			}
+			if strings.Contains(l, ",") {
+				return metadataError(fmt.Errorf("label %q contains a comma", l))
+			}
+		}
+	}
+
+	if m.Attributes != nil {
+		a := bytes.TrimSpace(m.Attributes)
+		if len(a) > 0 && !bytes.Equal(a, []byte("null")) && a[0] != '{' {
+			return metadataError(fmt.Errorf("attributes must be a JSON object"))
+		}
+	}
+
+	return nil
+}
+
+// applyTo sets the non-nil metadata fields on an account. An empty external
+// ID or null attributes clear the stored values.
+func (m Metadata) applyTo(a *Account) {
+	if m.ExternalID != nil {
+		if id := strings.TrimSpace(*m.ExternalID); id != "" {
+			a.ExternalID = &id
+		} else {
+			a.ExternalID = nil
+		}
+	}
+
+	if m.Labels != nil {
+		a.Labels = normalizeLabels(*m.Labels)
+	}
+
+	if m.Attributes != nil {
+		a.Attributes = nil
+		if attrs := bytes.TrimSpace(m.Attributes); len(attrs) > 0 && !bytes.Equal(attrs, []byte("null")) {
+			a.Attributes = datatypes.JSON(attrs)
+		}
+	}
+}
+
+// normalizeLabels trims labels and removes duplicates, keeping the order.
+func normalizeLabels(labels []string) []string {
+	seen := make(map[string]bool, len(labels))
+	result := make([]string, 0, len(labels))
+	for _, l := range labels {
+		l = strings.TrimSpace(l)
+		if l == "" || seen[l] {
+			continue
+		}
+		seen[l] = true
+		result = append(result, l)
+	}
+	return result
+}
+
+func metadataError(err error) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf("invalid metadata: %w", err),
+	}
+}
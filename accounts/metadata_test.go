@@ -0,0 +1,99 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"gorm.io/datatypes"
+)
+
+// accountStore stores accounts in memory, external IDs are unique.
+type accountStore struct {
+	Store
+	accounts map[string]Account
+}
+
+func (s *accountStore) Account(address string) (Account, error) {
+	if a, ok := s.accounts[address]; ok {
+		return a, nil
+	}
+	return Account{}, fmt.Errorf("record not found")
+}
+
+func (s *accountStore) AccountByExternalID(externalID string) (Account, error) {
+	for _, a := range s.accounts {
+		if a.ExternalID != nil && *a.ExternalID == externalID {
+			return a, nil
+		}
+	}
+	return Account{}, fmt.Errorf("record not found")
+}
+
+func (s *accountStore) InsertAccount(a *Account) error {
+	if a.ExternalID != nil {
+		if _, err := s.AccountByExternalID(*a.ExternalID); err == nil {
+			return fmt.Errorf("UNIQUE constraint failed: accounts.external_id")
+		}
+	}
+	s.accounts[a.Address] = *a
+	return nil
+}
+
+func TestInsertCreatedAccount(t *testing.T) {
+	externalID := "user-1"
+	store := &accountStore{accounts: map[string]Account{
+		"0x01": {Address: "0x01", ExternalID: &externalID},
+	}}
+	svc := &ServiceImpl{store: store}
+
+	stored, err := svc.insertCreatedAccount(&Account{Address: "0x02"})
+	if err != nil || !stored {
+		t.Fatalf("expected the account to be stored, got: %v", err)
+	}
+
+	// The external ID was taken while the account was being created
+	id := externalID
+	account := &Account{Address: "0x03", ExternalID: &id}
+
+	stored, err = svc.insertCreatedAccount(account)
+	if err == nil {
+		t.Fatal("expected a conflict error")
+	}
+
+	if !stored {
+		t.Fatal("expected the account to be stored without the external id")
+	}
+
+	if a, ok := store.accounts["0x03"]; !ok || a.ExternalID != nil {
+		t.Fatalf("expected 0x03 to be stored without an external id, got %+v", a)
+	}
+}
+
+func TestMetadataApplyToAttributes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{name: "missing", body: `{}`, expected: `{"tier":"gold"}`},
+		{name: "null", body: `{"attributes":null}`, expected: ""},
+		{name: "object", body: `{"attributes":{"tier":"silver"}}`, expected: `{"tier":"silver"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var m Metadata
+			if err := json.Unmarshal([]byte(tc.body), &m); err != nil {
+				t.Fatal(err)
+			}
+
+			a := &Account{Attributes: datatypes.JSON(`{"tier":"gold"}`)}
+			m.applyTo(a)
+
+			if string(a.Attributes) != tc.expected {
+				t.Fatalf("expected attributes %q, got %q", tc.expected, a.Attributes)
+			}
+		})
+	}
+}
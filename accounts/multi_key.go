@@ -0,0 +1,104 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// accountKeyPair is a generated key of a new account.
+type accountKeyPair struct {
+	public  *flow.AccountKey
+	private *keys.Private
+}
+
+// validateKeySpecs checks that the keys of a new account use allowed key
+// backends and algorithms and that their combined weight reaches the signing
+// threshold.
+func (s *ServiceImpl) validateKeySpecs(specs []keys.KeySpec) error {
+	total := 0
+
+	for i, spec := range specs {
+		if spec.Weight < 0 || spec.Weight > flow.AccountKeyWeightThreshold {
+			return keySpecError(fmt.Errorf("key %d: weight must be between 1 and %d, got %d", i, flow.AccountKeyWeightThreshold, spec.Weight))
+		}
+
+		spec = keys.ResolveKeySpec(s.cfg, spec)
+
+		if err := keys.CheckAllowed(s.cfg, spec); err != nil {
+			return keySpecError(fmt.Errorf("key %d: %w", i, err))
+		}
+
+		total += spec.Weight
+	}
+
+	if total < flow.AccountKeyWeightThreshold {
+		return keySpecError(fmt.Errorf("combined key weight %d is below the threshold %d", total, flow.AccountKeyWeightThreshold))
+	}
+
+	return nil
+}
+
+// generateAccountKeys generates a key pair for each spec, or the default key
+// if there are none. Each key is cloned based on the configured key count,
+// the clones get sequential indices.
+func (s *ServiceImpl) generateAccountKeys(ctx context.Context, specs []keys.KeySpec) ([]accountKeyPair, error) {
+	if len(specs) == 0 {
+		specs = []keys.KeySpec{{}}
+	}
+
+	pairs := []accountKeyPair{}
+
+	for _, spec := range specs {
+		accountKey, private, err := s.km.GenerateFromSpec(ctx, spec, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create copies based on the configured key count, changing just the index
+		for i := 0; i < int(s.cfg.DefaultAccountKeyCount); i++ {
+			clonedAccountKey := *accountKey
+			clonedAccountKey.Index = len(pairs)
+
+			clonedPrivate := *private
+			clonedPrivate.Index = len(pairs)
+
+			pairs = append(pairs, accountKeyPair{public: &clonedAccountKey, private: &clonedPrivate})
+		}
+	}
+
+	return pairs, nil
+}
+
+// distinctKeys returns the first stored key of each public key, ordered by
+// index.
+func distinctKeys(stored []keys.Storable) []keys.Storable {
+	sorted := append([]keys.Storable{}, stored...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Index < sorted[j].Index
+	})
+
+	seen := map[string]bool{}
+	distinct := []keys.Storable{}
+	for _, k := range sorted {
+		pbk := publicKeyHex(k.PublicKey)
+		if !seen[pbk] {
+			seen[pbk] = true
+			distinct = append(distinct, k)
+		}
+	}
+
+	return distinct
+}
+
+func keySpecError(err error) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf("invalid account keys: %w", err),
+	}
+}
@@ -0,0 +1,65 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	_ "github.com/flow-hydraulics/flow-wallet-api/keys/basic"
+)
+
+func TestValidateKeySpecs(t *testing.T) {
+	s := &ServiceImpl{cfg: &configs.Config{
+		DefaultKeyType:   keys.AccountKeyTypeLocal,
+		DefaultKeyWeight: 1000,
+		DefaultSignAlgo:  "ECDSA_P256",
+		DefaultHashAlgo:  "SHA3_256",
+		AllowedKeySpecs: []string{
+			"local:ECDSA_P256:SHA3_256",
+			"local:ECDSA_secp256k1:SHA2_256",
+			"aws_kms:ECDSA_P256:SHA3_256",
+			"google_kms:ECDSA_P256:SHA2_256",
+		},
+	}}
+
+	valid := [][]keys.KeySpec{
+		{{}},
+		{{Type: keys.AccountKeyTypeLocal, SignAlgo: "ECDSA_secp256k1", HashAlgo: "SHA2_256"}},
+		{{Type: keys.AccountKeyTypeLocal, Weight: 500}, {Type: keys.AccountKeyTypeGoogleKMS, Weight: 500, HashAlgo: "SHA2_256"}},
+		{{Type: keys.AccountKeyTypeLocal, Weight: 400}, {Type: keys.AccountKeyTypeAWSKMS, Weight: 400}, {Type: keys.AccountKeyTypeGoogleKMS, Weight: 400, HashAlgo: "SHA2_256"}},
+		{{Weight: 500}, {}},
+	}
+
+	for _, specs := range valid {
+		if err := s.validateKeySpecs(specs); err != nil {
+			t.Errorf("expected %+v to be valid, got %s", specs, err)
+		}
+	}
+
+	invalid := [][]keys.KeySpec{
+		{{Type: keys.AccountKeyTypeLocal, Weight: 500}},
+		{{Type: "unknown", Weight: 1000}},
+		{{Weight: 1001}},
+		{{Weight: -1}, {}},
+		// Not an allowed combination
+		{{Type: keys.AccountKeyTypeAWSKMS, SignAlgo: "ECDSA_secp256k1"}},
+		// Not supported by Google KMS
+		{{Type: keys.AccountKeyTypeGoogleKMS}},
+	}
+
+	for _, specs := range invalid {
+		if err := s.validateKeySpecs(specs); err == nil {
+			t.Errorf("expected %+v to be invalid", specs)
+		}
+	}
+}
+
+func TestDistinctKeys(t *testing.T) {
+	pbk1 := testPublicKey(t, 1).String()
+	pbk2 := testPublicKey(t, 2).String()
+
+	distinct := distinctKeys([]keys.Storable{{Index: 2, PublicKey: pbk2}, {Index: 1, PublicKey: pbk1}, {Index: 0, PublicKey: pbk1}})
+	if len(distinct) != 2 || distinct[0].Index != 0 || distinct[1].Index != 2 {
+		t.Errorf("expected the first key of each public key ordered by index, got %+v", distinct)
+	}
+}
@@ -1,6 +1,10 @@
 package accounts
 
-import "go.uber.org/ratelimit"
+import (
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/google/uuid"
+	"go.uber.org/ratelimit"
+)
 
 type ServiceOption func(*ServiceImpl)
 
@@ -9,3 +13,73 @@ func WithTxRatelimiter(limiter ratelimit.Limiter) ServiceOption {
 		svc.txRateLimiter = limiter
 	}
 }
+
+// AccountOption configures an account being created or added.
+type AccountOption func(*accountOptions)
+
+// accountOptions are stored as job attributes when creating accounts
+// asynchronously.
+type accountOptions struct {
+	Metadata Metadata `json:"metadata"`
+	Tokens   []string `json:"tokens,omitempty"`
+	// InitialFunding is resolved when the account creation is requested.
+	InitialFunding *Funding `json:"initialFunding,omitempty"`
+	// Keys of a multi-key account, the default key is used if empty.
+	Keys []keys.KeySpec `json:"keys,omitempty"`
+	// Externally held keys added to the account for exiting custody.
+	RecoveryKeys []RecoveryKey `json:"recoveryKeys,omitempty"`
+
+	// pooled accounts are created for the account pool
+	pooled bool
+	// jobID is the ID of the account creation job, if any
+	jobID uuid.UUID
+}
+
+// WithMetadata sets the metadata of the account.
+func WithMetadata(m Metadata) AccountOption {
+	return func(o *accountOptions) {
+		o.Metadata = m
+	}
+}
+
+// WithTokens sets up vaults for the given enabled tokens when creating the
+// account.
+func WithTokens(tokenNames ...string) AccountOption {
+	return func(o *accountOptions) {
+		o.Tokens = append(o.Tokens, tokenNames...)
+	}
+}
+
+// WithInitialFunding transfers an amount of a fungible token to the account
+// after creating it, instead of the configured default. A zero amount
+// disables funding.
+func WithInitialFunding(tokenName, amount string) AccountOption {
+	return func(o *accountOptions) {
+		o.InitialFunding = &Funding{TokenName: tokenName, Amount: amount}
+	}
+}
+
+// WithKeys creates the account with a key for each spec instead of the
+// default key. The combined weight of the keys must reach the signing
+// threshold.
+func WithKeys(specs ...keys.KeySpec) AccountOption {
+	return func(o *accountOptions) {
+		o.Keys = append(o.Keys, specs...)
+	}
+}
+
+// WithRecoveryKeys adds externally held public keys to the account when
+// creating it. The service never signs with them.
+func WithRecoveryKeys(recovery ...RecoveryKey) AccountOption {
+	return func(o *accountOptions) {
+		o.RecoveryKeys = append(o.RecoveryKeys, recovery...)
+	}
+}
+
+func parseAccountOptions(opts []AccountOption) accountOptions {
+	var o accountOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
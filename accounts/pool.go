@@ -0,0 +1,199 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	AccountPoolRefillJobType  = "account_pool_refill"
+	AccountPoolReconcilerName = "account_pool_refill"
+	AccountPoolDepthGaugeName = "accountPoolDepth"
+)
+
+type accountPoolRefillJobAttributes struct {
+	// Count is the number of accounts missing from the pool when the job was
+	// created.
+	Count int `json:"count"`
+}
+
+func (s *ServiceImpl) accountPoolEnabled() bool {
+	return s.cfg.AccountPoolSize > 0
+}
+
+// takePooledAccount assigns a pre-created account from the pool with the
+// given metadata. It returns nil if the pool is disabled or empty.
+func (s *ServiceImpl) takePooledAccount(o accountOptions) (*Account, error) {
+	if !s.accountPoolEnabled() {
+		return nil, nil
+	}
+
+	account := &Account{}
+	o.Metadata.applyTo(account)
+
+	if err := s.store.AssignPooledAccount(account); err != nil {
+		if strings.Contains(err.Error(), "record not found") {
+			log.Warn("Account pool is empty, creating account on demand")
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Refill in the background, failing to do so does not affect this account
+	if err := s.refillAccountPool(); err != nil {
+		log.
+			WithFields(log.Fields{"error": err}).
+			Warn("Error while scheduling account pool refill")
+	}
+
+	return account, nil
+}
+
+// refillAccountPool schedules a refill job if the pool, counting the accounts
+// of pending refill jobs, holds low water mark accounts or fewer. Pending jobs
+// are read from the database, so that service instances do not schedule
+// refills for the same missing accounts.
+func (s *ServiceImpl) refillAccountPool() error {
+	if !s.accountPoolEnabled() {
+		return nil
+	}
+
+	depth, err := s.store.PooledAccountCount()
+	if err != nil {
+		return err
+	}
+
+	pending, err := s.store.PendingPoolRefillJobs()
+	if err != nil {
+		return err
+	}
+
+	depth += int64(poolRefillCount(pending))
+
+	lowWaterMark := int64(s.cfg.AccountPoolLowWaterMark)
+	if size := int64(s.cfg.AccountPoolSize); lowWaterMark >= size {
+		lowWaterMark = size - 1
+	}
+
+	if depth > lowWaterMark {
+		return nil
+	}
+
+	attrBytes, err := json.Marshal(accountPoolRefillJobAttributes{Count: int(s.cfg.AccountPoolSize) - int(depth)})
+	if err != nil {
+		return err
+	}
+
+	job, err := s.wp.CreateJob(AccountPoolRefillJobType, "", jobs.WithAttributes(attrBytes))
+	if err != nil {
+		return err
+	}
+
+	return s.wp.Schedule(job)
+}
+
+// poolRefillCount returns the number of accounts the refill jobs were
+// scheduled to create.
+func poolRefillCount(jj []jobs.Job) int {
+	count := 0
+	for _, j := range jj {
+		var attrs accountPoolRefillJobAttributes
+		if err := json.Unmarshal(j.Attributes, &attrs); err != nil {
+			continue
+		}
+		count += attrs.Count
+	}
+	return count
+}
+
+// reconcileAccountPool is run periodically by the worker pool, it fills the
+// pool on startup and refills it if a refill job failed.
+func (s *ServiceImpl) reconcileAccountPool(ctx context.Context) error {
+	return s.refillAccountPool()
+}
+
+// executeAccountPoolRefillJob creates the accounts missing from the pool. The
+// accounts of older pending refill jobs are counted as already created, so
+// that concurrently scheduled jobs do not overfill the pool.
+func (s *ServiceImpl) executeAccountPoolRefillJob(ctx context.Context, j *jobs.Job) error {
+	if j.Type != AccountPoolRefillJobType {
+		return jobs.ErrInvalidJobType
+	}
+
+	depth, err := s.store.PooledAccountCount()
+	if err != nil {
+		return err
+	}
+
+	pending, err := s.store.PendingPoolRefillJobs()
+	if err != nil {
+		return err
+	}
+
+	var older []jobs.Job
+	for _, p := range pending {
+		if p.ID == j.ID {
+			break
+		}
+		older = append(older, p)
+	}
+
+	missing := int(s.cfg.AccountPoolSize) - int(depth) - poolRefillCount(older)
+	if missing <= 0 {
+		j.Result = "0"
+		return nil
+	}
+
+	concurrency := int(s.cfg.AccountPoolRefillConcurrency)
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+		errs    []string
+		sem     = make(chan struct{}, concurrency)
+	)
+
+	for i := 0; i < missing; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func() {
+			defer func() { <-sem }()
+			defer wg.Done()
+
+			_, _, err := s.createAccount(ctx, accountOptions{pooled: true})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err.Error())
+				return
+			}
+			created++
+		}()
+	}
+
+	wg.Wait()
+
+	j.Result = fmt.Sprintf("%d", created)
+
+	log.
+		WithFields(log.Fields{"created": created, "failed": len(errs)}).
+		Debug("Account pool refilled")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to create %d pool accounts: %s", len(errs), strings.Join(errs, "; "))
+	}
+
+	return nil
+}
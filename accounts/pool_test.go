@@ -0,0 +1,20 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+)
+
+func TestPoolRefillCount(t *testing.T) {
+	jj := []jobs.Job{
+		{Attributes: []byte(`{"count":3}`)},
+		{Attributes: []byte(`{"count":2}`)},
+		// Scheduled before the count was recorded
+		{},
+	}
+
+	if n := poolRefillCount(jj); n != 5 {
+		t.Fatalf("expected 5 accounts, got %d", n)
+	}
+}
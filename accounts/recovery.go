@@ -0,0 +1,468 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/flow-hydraulics/flow-wallet-api/templates/template_strings"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	flow_crypto "github.com/onflow/flow-go-sdk/crypto"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	AddRecoveryKeyJobType = "add_recovery_key"
+	ExportAccountJobType  = "export_account"
+)
+
+// RecoveryKey is a public key held outside the service. Recovery keys are
+// added to custodial accounts so that their owners can take over the account
+// once it's exported.
+type RecoveryKey struct {
+	// Hex encoded public key.
+	PublicKey string `json:"publicKey"`
+	// Signature algorithm, defaults to the configured default.
+	SignAlgo string `json:"signAlgo,omitempty"`
+	// Hash algorithm, defaults to the configured default.
+	HashAlgo string `json:"hashAlgo,omitempty"`
+	// Weight of the key, between 1 and 1000.
+	Weight int `json:"weight"`
+}
+
+type addRecoveryKeyJobAttributes struct {
+	Address flow.Address `json:"address"`
+	Key     RecoveryKey  `json:"key"`
+}
+
+type exportAccountJobAttributes struct {
+	Address flow.Address `json:"address"`
+}
+
+// recoveryAccountKey validates the recovery key and converts it to an account
+// key, using the configured default algorithms.
+func (s *ServiceImpl) recoveryAccountKey(k RecoveryKey) (*flow.AccountKey, error) {
+	signAlgo := k.SignAlgo
+	if signAlgo == "" {
+		signAlgo = s.cfg.DefaultSignAlgo
+	}
+
+	hashAlgo := k.HashAlgo
+	if hashAlgo == "" {
+		hashAlgo = keys.DefaultHashAlgo(s.cfg, keys.AccountKeyTypeRecovery)
+	}
+
+	sigAlgo := flow_crypto.StringToSignatureAlgorithm(signAlgo)
+	if _, err := cadenceSignatureAlgorithm(sigAlgo); err != nil {
+		return nil, recoveryKeyError(err)
+	}
+
+	hAlgo := flow_crypto.StringToHashAlgorithm(hashAlgo)
+	if hAlgo != flow_crypto.SHA2_256 && hAlgo != flow_crypto.SHA3_256 {
+		return nil, recoveryKeyError(fmt.Errorf("unsupported hash algorithm: %s", hashAlgo))
+	}
+
+	if k.Weight < 1 || k.Weight > flow.AccountKeyWeightThreshold {
+		return nil, recoveryKeyError(fmt.Errorf("weight must be between 1 and %d, got %d", flow.AccountKeyWeightThreshold, k.Weight))
+	}
+
+	pbk, err := flow_crypto.DecodePublicKeyHex(sigAlgo, strings.TrimPrefix(strings.TrimSpace(k.PublicKey), "0x"))
+	if err != nil {
+		return nil, recoveryKeyError(fmt.Errorf("invalid public key: %w", err))
+	}
+
+	return flow.NewAccountKey().
+		SetPublicKey(pbk).
+		SetHashAlgo(hAlgo).
+		SetWeight(k.Weight), nil
+}
+
+// validateRecoveryKeys converts the recovery keys of a new account to account
+// keys.
+func (s *ServiceImpl) validateRecoveryKeys(recovery []RecoveryKey) ([]*flow.AccountKey, error) {
+	accountKeys := make([]*flow.AccountKey, len(recovery))
+	for i, k := range recovery {
+		key, err := s.recoveryAccountKey(k)
+		if err != nil {
+			return nil, err
+		}
+		accountKeys[i] = key
+	}
+	return accountKeys, nil
+}
+
+// recoveryStorable returns the storable form of a recovery key, it has no
+// private key value.
+func recoveryStorable(address string, key *flow.AccountKey) keys.Storable {
+	return keys.Storable{
+		AccountAddress: address,
+		Index:          key.Index,
+		Type:           keys.AccountKeyTypeRecovery,
+		Value:          []byte{},
+		PublicKey:      key.PublicKey.String(),
+		SignAlgo:       key.SigAlgo.String(),
+		HashAlgo:       key.HashAlgo.String(),
+	}
+}
+
+// serviceKeys returns the stored keys the service can sign with.
+func serviceKeys(stored []keys.Storable) []keys.Storable {
+	kk := []keys.Storable{}
+	for _, k := range stored {
+		if k.Type != keys.AccountKeyTypeRecovery {
+			kk = append(kk, k)
+		}
+	}
+	return kk
+}
+
+// recoveryKeys returns the stored recovery keys.
+func recoveryKeys(stored []keys.Storable) []keys.Storable {
+	kk := []keys.Storable{}
+	for _, k := range stored {
+		if k.Type == keys.AccountKeyTypeRecovery {
+			kk = append(kk, k)
+		}
+	}
+	return kk
+}
+
+// recoveryWeight returns the combined weight of the non-revoked on-chain
+// recovery keys, each public key counted once.
+func recoveryWeight(flowAccount *flow.Account, stored []keys.Storable) int {
+	recovery := storedKeysByPublicKey(recoveryKeys(stored))
+
+	counted := map[string]bool{}
+	weight := 0
+	for _, key := range flowAccount.Keys {
+		pbk := publicKeyHex(key.PublicKey.String())
+		if _, ok := recovery[pbk]; !ok || key.Revoked || counted[pbk] {
+			continue
+		}
+		counted[pbk] = true
+		weight += key.Weight
+	}
+	return weight
+}
+
+// custodialAccount returns the custodial account with the address, or a
+// request error.
+func (s *ServiceImpl) custodialAccount(address flow.Address) (Account, error) {
+	// Validate address, they might be legit addresses but for the wrong chain
+	if !address.IsValid(s.cfg.ChainID) {
+		return Account{}, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf(`not a valid address for %s: "%s"`, s.cfg.ChainID, address),
+		}
+	}
+
+	if flow_helpers.FormatAddress(address) == s.cfg.AdminAddress {
+		return Account{}, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("not allowed for the admin account"),
+		}
+	}
+
+	account, err := s.store.Account(flow_helpers.FormatAddress(address))
+	if err != nil {
+		if strings.Contains(err.Error(), "record not found") {
+			return Account{}, &errors.RequestError{StatusCode: http.StatusNotFound, Err: fmt.Errorf("account not found")}
+		}
+		return Account{}, err
+	}
+
+	if account.Type != AccountTypeCustodial {
+		return Account{}, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("account %s is not custodial", account.Address),
+		}
+	}
+
+	if err := account.CheckActive(); err != nil {
+		return Account{}, err
+	}
+
+	return account, nil
+}
+
+// AddRecoveryKey adds an externally held public key to a custodial account
+// as a recovery key.
+func (s *ServiceImpl) AddRecoveryKey(ctx context.Context, address flow.Address, key RecoveryKey) (*jobs.Job, error) {
+	log.WithFields(log.Fields{"address": address}).Trace("Add recovery key")
+
+	if _, err := s.custodialAccount(address); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.recoveryAccountKey(key); err != nil {
+		return nil, err
+	}
+
+	attrBytes, err := json.Marshal(addRecoveryKeyJobAttributes{Address: address, Key: key})
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.wp.CreateJob(AddRecoveryKeyJobType, "", jobs.WithAttributes(attrBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.wp.Schedule(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (s *ServiceImpl) executeAddRecoveryKeyJob(ctx context.Context, j *jobs.Job) error {
+	if j.Type != AddRecoveryKeyJobType {
+		return jobs.ErrInvalidJobType
+	}
+
+	j.ShouldSendNotification = true
+
+	var attrs addRecoveryKeyJobAttributes
+	if err := json.Unmarshal(j.Attributes, &attrs); err != nil {
+		return err
+	}
+
+	index, txID, err := s.addRecoveryKey(ctx, attrs)
+	if err != nil {
+		return err
+	}
+
+	j.TransactionID = txID
+	j.Result = fmt.Sprintf("%s:%d", attrs.Address, index)
+
+	return nil
+}
+
+// addRecoveryKey adds the recovery key on-chain and stores it. Returns the
+// index of the key and the transaction ID.
+func (s *ServiceImpl) addRecoveryKey(ctx context.Context, attrs addRecoveryKeyJobAttributes) (int, string, error) {
+	entry := log.WithFields(log.Fields{"address": attrs.Address, "function": "ServiceImpl.addRecoveryKey"})
+
+	dbAccount, err := s.store.Account(flow_helpers.FormatAddress(attrs.Address))
+	if err != nil {
+		return 0, "", err
+	}
+
+	if dbAccount.Type != AccountTypeCustodial {
+		return 0, "", jobs.PermanentFailure(fmt.Errorf("account %s is not custodial", dbAccount.Address))
+	}
+
+	key, err := s.recoveryAccountKey(attrs.Key)
+	if err != nil {
+		return 0, "", jobs.PermanentFailure(err)
+	}
+
+	flowAccount, err := s.fc.GetAccount(ctx, attrs.Address)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var txID string
+
+	// A retried job may have already sent the transaction
+	if len(validKeyIndices(flowAccount, key.PublicKey)) == 0 {
+		args, err := addAndRevokeKeysArgs([]addedAccountKey{{
+			publicKey: key.PublicKey.String(),
+			signAlgo:  key.SigAlgo,
+			hashAlgo:  key.HashAlgo,
+			weight:    key.Weight,
+			count:     1,
+		}}, nil)
+		if err != nil {
+			return 0, "", jobs.PermanentFailure(err)
+		}
+
+		// NOTE: sync, so will wait for transaction to be sent & sealed
+		_, tx, err := s.txs.Create(ctx, true, dbAccount.Address, template_strings.RotateAccountKeyTransaction, args, transactions.General)
+		if tx != nil {
+			txID = tx.TransactionId
+		}
+		if err != nil {
+			entry.WithFields(log.Fields{"err": err}).Error("failed to create transaction")
+			return 0, txID, err
+		}
+
+		flowAccount, err = s.fc.GetAccount(ctx, attrs.Address)
+		if err != nil {
+			return 0, txID, err
+		}
+	}
+
+	indices := validKeyIndices(flowAccount, key.PublicKey)
+	if len(indices) == 0 {
+		return 0, txID, fmt.Errorf("recovery key not found on-chain")
+	}
+
+	key.Index = indices[len(indices)-1]
+
+	for _, k := range dbAccount.Keys {
+		if k.Index == key.Index {
+			// Already stored by a previous try
+			return key.Index, txID, nil
+		}
+	}
+
+	if err := s.store.InsertAccountKey(recoveryStorable(dbAccount.Address, key)); err != nil {
+		entry.WithFields(log.Fields{"err": err}).Error("failed to store recovery key")
+		return 0, txID, err
+	}
+
+	entry.WithFields(log.Fields{"index": key.Index}).Debug("recovery key added")
+
+	return key.Index, txID, nil
+}
+
+// ExportAccount hands over the control of a custodial account to its
+// recovery keys by revoking the service keys. The account is kept as a
+// non-custodial account so that its deposits are still tracked.
+func (s *ServiceImpl) ExportAccount(ctx context.Context, address flow.Address) (*jobs.Job, error) {
+	log.WithFields(log.Fields{"address": address}).Trace("Export account")
+
+	account, err := s.custodialAccount(address)
+	if err != nil {
+		return nil, err
+	}
+
+	flowAccount, err := s.fc.GetAccount(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fail early, checked again right before revoking the service keys
+	if err := checkRecoveryWeight(flowAccount, account.Keys); err != nil {
+		return nil, &errors.RequestError{StatusCode: http.StatusBadRequest, Err: err}
+	}
+
+	attrBytes, err := json.Marshal(exportAccountJobAttributes{Address: address})
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.wp.CreateJob(ExportAccountJobType, "", jobs.WithAttributes(attrBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.wp.Schedule(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (s *ServiceImpl) executeExportAccountJob(ctx context.Context, j *jobs.Job) error {
+	if j.Type != ExportAccountJobType {
+		return jobs.ErrInvalidJobType
+	}
+
+	j.ShouldSendNotification = true
+
+	var attrs exportAccountJobAttributes
+	if err := json.Unmarshal(j.Attributes, &attrs); err != nil {
+		return err
+	}
+
+	txID, err := s.exportAccount(ctx, attrs.Address)
+	if err != nil {
+		return err
+	}
+
+	j.TransactionID = txID
+	j.Result = flow_helpers.FormatAddress(attrs.Address)
+
+	return nil
+}
+
+// exportAccount revokes the service keys on-chain, drops them from the
+// database and turns the account non-custodial. Returns the transaction ID.
+func (s *ServiceImpl) exportAccount(ctx context.Context, address flow.Address) (string, error) {
+	entry := log.WithFields(log.Fields{"address": address, "function": "ServiceImpl.exportAccount"})
+
+	dbAccount, err := s.store.Account(flow_helpers.FormatAddress(address))
+	if err != nil {
+		return "", err
+	}
+
+	if dbAccount.Type != AccountTypeCustodial {
+		// Exported by a previous try
+		return "", nil
+	}
+
+	flowAccount, err := s.fc.GetAccount(ctx, address)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkRecoveryWeight(flowAccount, dbAccount.Keys); err != nil {
+		return "", jobs.PermanentFailure(err)
+	}
+
+	var txID string
+
+	if revoke := revokedKeyIndices(flowAccount, serviceKeys(dbAccount.Keys)); len(revoke) > 0 {
+		revokeValues := make([]cadence.Value, len(revoke))
+		for i, index := range revoke {
+			revokeValues[i] = cadence.NewInt(index)
+		}
+
+		entry.WithFields(log.Fields{"revoke": revoke}).Debug("going to revoke service keys")
+
+		// NOTE: sync, so will wait for transaction to be sent & sealed
+		_, tx, err := s.txs.Create(ctx, true, dbAccount.Address, template_strings.RevokeAccountKeysTransaction, []transactions.Argument{cadence.NewArray(revokeValues)}, transactions.General)
+		if tx != nil {
+			txID = tx.TransactionId
+		}
+		if err != nil {
+			entry.WithFields(log.Fields{"err": err}).Error("failed to create transaction")
+			return txID, err
+		}
+	}
+
+	// Keep the recovery keys for reference
+	recovery := recoveryKeys(dbAccount.Keys)
+	for i := range recovery {
+		recovery[i].ID = 0 // Reset ID to create a new key to DB
+	}
+
+	if err := s.store.ReplaceAccountKeys(dbAccount.Address, recovery); err != nil {
+		entry.WithFields(log.Fields{"err": err}).Error("failed to replace keys in database")
+		return txID, err
+	}
+
+	dbAccount.Type = AccountTypeNonCustodial
+
+	if err := s.store.UpdateAccountType(&dbAccount); err != nil {
+		return txID, err
+	}
+
+	entry.Info("Account exported")
+
+	return txID, nil
+}
+
+func checkRecoveryWeight(flowAccount *flow.Account, stored []keys.Storable) error {
+	if weight := recoveryWeight(flowAccount, stored); weight < flow.AccountKeyWeightThreshold {
+		return fmt.Errorf("recovery keys must have a combined weight of at least %d to export the account, got %d", flow.AccountKeyWeightThreshold, weight)
+	}
+	return nil
+}
+
+func recoveryKeyError(err error) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf("invalid recovery key: %w", err),
+	}
+}
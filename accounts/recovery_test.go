@@ -0,0 +1,76 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+func TestRecoveryAccountKey(t *testing.T) {
+	s := &ServiceImpl{cfg: &configs.Config{DefaultSignAlgo: "ECDSA_P256", DefaultHashAlgo: "SHA3_256"}}
+	pbk := testPublicKey(t, 1).String()
+
+	key, err := s.recoveryAccountKey(RecoveryKey{PublicKey: pbk, Weight: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.SigAlgo != crypto.ECDSA_P256 || key.HashAlgo != crypto.SHA3_256 || key.Weight != 500 {
+		t.Errorf("expected the default algorithms and weight 500, got %s %s %d", key.SigAlgo, key.HashAlgo, key.Weight)
+	}
+
+	invalid := []RecoveryKey{
+		{PublicKey: pbk},
+		{PublicKey: pbk, Weight: 1001},
+		{PublicKey: "0x1234", Weight: 1000},
+		{PublicKey: pbk, Weight: 1000, HashAlgo: "SHA2_384"},
+		{PublicKey: pbk, Weight: 1000, SignAlgo: "BLS_BLS12_381"},
+	}
+
+	for _, k := range invalid {
+		if _, err := s.recoveryAccountKey(k); err == nil {
+			t.Errorf("expected %+v to be invalid", k)
+		}
+	}
+}
+
+func TestRecoveryWeight(t *testing.T) {
+	servicePbk := testPublicKey(t, 1)
+	recoveryPbk := testPublicKey(t, 2)
+	otherRecoveryPbk := testPublicKey(t, 3)
+
+	account := &flow.Account{Keys: []*flow.AccountKey{
+		{Index: 0, PublicKey: servicePbk, Weight: 1000},
+		{Index: 1, PublicKey: recoveryPbk, Weight: 500},
+		{Index: 2, PublicKey: recoveryPbk, Weight: 500},
+		{Index: 3, PublicKey: otherRecoveryPbk, Weight: 500, Revoked: true},
+	}}
+
+	stored := []keys.Storable{
+		{Index: 0, Type: keys.AccountKeyTypeLocal, PublicKey: servicePbk.String()},
+		{Index: 1, Type: keys.AccountKeyTypeRecovery, PublicKey: recoveryPbk.String()},
+		{Index: 3, Type: keys.AccountKeyTypeRecovery, PublicKey: otherRecoveryPbk.String()},
+	}
+
+	if len(serviceKeys(stored)) != 1 || len(recoveryKeys(stored)) != 2 {
+		t.Fatalf("expected 1 service key and 2 recovery keys")
+	}
+
+	// Clones and revoked keys are not counted
+	if w := recoveryWeight(account, stored); w != 500 {
+		t.Errorf("expected recovery weight 500, got %d", w)
+	}
+
+	if err := checkRecoveryWeight(account, stored); err == nil {
+		t.Error("expected not enough recovery weight")
+	}
+
+	// Recovery keys are neither cloned, revoked nor reported as unknown
+	plan := planKeySync(account, serviceKeys(stored), recoveryKeys(stored), 1, true)
+	if len(plan.revoke) != 0 || len(plan.report.UnknownKeys) != 0 || len(plan.clones) != 0 {
+		t.Errorf("expected recovery keys to be left alone, got %+v", plan)
+	}
+}
@@ -0,0 +1,398 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/flow-hydraulics/flow-wallet-api/templates/template_strings"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/sema"
+	"github.com/onflow/flow-go-sdk"
+	flow_crypto "github.com/onflow/flow-go-sdk/crypto"
+	log "github.com/sirupsen/logrus"
+)
+
+const RotateAccountKeysJobType = "rotate_account_keys"
+
+// rotateAccountKeysJobAttributes holds the new keys of a rotation. The keys
+// are generated before the job is scheduled so that retries use the same
+// keys.
+type rotateAccountKeysJobAttributes struct {
+	Address flow.Address        `json:"address"`
+	NumKeys int                 `json:"numkeys"`
+	Keys    []rotatedAccountKey `json:"keys"`
+}
+
+// rotatedAccountKey is a new key replacing the clones of a stored key, Value
+// is encrypted like in the database.
+type rotatedAccountKey struct {
+	Weight    int    `json:"weight"`
+	KeyType   string `json:"keyType"`
+	KeyValue  []byte `json:"keyValue"`
+	PublicKey string `json:"publicKey"`
+	SignAlgo  string `json:"signAlgo"`
+	HashAlgo  string `json:"hashAlgo"`
+}
+
+// RotateAccountKeys replaces each distinct key of a custodial account with a
+// freshly generated key of the same weight. Unset fields of spec are taken
+// from the replaced key, so an empty spec keeps the key types and algorithms.
+func (s *ServiceImpl) RotateAccountKeys(ctx context.Context, address flow.Address, spec keys.KeySpec) (*jobs.Job, error) {
+	account, err := s.custodialAccount(address)
+	if err != nil {
+		return nil, err
+	}
+
+	flowAccount, err := s.fc.GetAccount(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := rotationKeySpecs(flowAccount, serviceKeys(account.Keys), spec)
+
+	if err := s.validateKeySpecs(specs); err != nil {
+		return nil, err
+	}
+
+	// Prepare job attributes required for executing the job
+	attrs := rotateAccountKeysJobAttributes{
+		Address: address,
+		NumKeys: int(s.cfg.DefaultAccountKeyCount),
+		Keys:    make([]rotatedAccountKey, len(specs)),
+	}
+
+	for i, spec := range specs {
+		// Generate the new key pair
+		accountKey, newPrivateKey, err := s.km.GenerateFromSpec(ctx, spec, s.cfg.DefaultKeyIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		// Convert the key to storable form (encrypt it)
+		encryptedAccountKey, err := s.km.Save(*newPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		attrs.Keys[i] = rotatedAccountKey{
+			Weight:    accountKey.Weight,
+			KeyType:   encryptedAccountKey.Type,
+			KeyValue:  encryptedAccountKey.Value,
+			PublicKey: accountKey.PublicKey.String(),
+			SignAlgo:  encryptedAccountKey.SignAlgo,
+			HashAlgo:  encryptedAccountKey.HashAlgo,
+		}
+	}
+
+	attrBytes, err := json.Marshal(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create & schedule the "rotate account keys" job
+	job, err := s.wp.CreateJob(RotateAccountKeysJobType, "", jobs.WithAttributes(attrBytes))
+	if err != nil {
+		return nil, err
+	}
+	err = s.wp.Schedule(job)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// rotationKeySpecs returns the spec of a new key for each distinct stored
+// key. The new key keeps the on-chain weight and, unless overridden, the
+// type and algorithms of the stored key.
+func rotationKeySpecs(flowAccount *flow.Account, stored []keys.Storable, override keys.KeySpec) []keys.KeySpec {
+	distinct := distinctKeys(stored)
+	if len(distinct) == 0 {
+		return []keys.KeySpec{override}
+	}
+
+	weights := map[string]int{}
+	for _, key := range flowAccount.Keys {
+		pbk := publicKeyHex(key.PublicKey.String())
+		if _, ok := weights[pbk]; !ok || !key.Revoked {
+			weights[pbk] = key.Weight
+		}
+	}
+
+	specs := make([]keys.KeySpec, len(distinct))
+	for i, k := range distinct {
+		spec := override
+		if spec.Type == "" {
+			spec.Type = k.Type
+		}
+		if spec.Weight == 0 {
+			spec.Weight = weights[publicKeyHex(k.PublicKey)]
+		}
+		if spec.SignAlgo == "" {
+			spec.SignAlgo = k.SignAlgo
+		}
+		if spec.HashAlgo == "" {
+			spec.HashAlgo = k.HashAlgo
+		}
+		specs[i] = spec
+	}
+
+	return specs
+}
+
+func (s *ServiceImpl) executeRotateAccountKeysJob(ctx context.Context, j *jobs.Job) error {
+	if j.Type != RotateAccountKeysJobType {
+		return jobs.ErrInvalidJobType
+	}
+
+	j.ShouldSendNotification = true
+
+	var attrs rotateAccountKeysJobAttributes
+	if err := json.Unmarshal(j.Attributes, &attrs); err != nil {
+		return err
+	}
+
+	numKeys, txID, err := s.rotateAccountKeys(ctx, attrs)
+	if err != nil {
+		return err
+	}
+
+	j.TransactionID = txID
+	j.Result = fmt.Sprintf("%s:%d", attrs.Address, numKeys)
+
+	return nil
+}
+
+// rotateAccountKeys adds the new keys on-chain, revokes the stored service
+// keys in the same transaction and replaces them in the database. Returns the
+// number of new keys and the transaction ID.
+func (s *ServiceImpl) rotateAccountKeys(ctx context.Context, attrs rotateAccountKeysJobAttributes) (int, string, error) {
+	entry := log.WithFields(log.Fields{"address": attrs.Address, "function": "ServiceImpl.rotateAccountKeys"})
+
+	if attrs.NumKeys < 1 {
+		return 0, "", jobs.PermanentFailure(fmt.Errorf("invalid number of keys specified: %d, min. 1 expected", attrs.NumKeys))
+	}
+
+	if len(attrs.Keys) == 0 {
+		return 0, "", jobs.PermanentFailure(fmt.Errorf("no new keys specified"))
+	}
+
+	dbAccount, err := s.store.Account(flow_helpers.FormatAddress(attrs.Address))
+	if err != nil {
+		return 0, "", err
+	}
+
+	if dbAccount.Type != AccountTypeCustodial || dbAccount.Address == s.cfg.AdminAddress {
+		return 0, "", jobs.PermanentFailure(fmt.Errorf("only custodial account keys can be rotated"))
+	}
+
+	added := make([]addedAccountKey, len(attrs.Keys))
+	newPbks := make([]flow_crypto.PublicKey, len(attrs.Keys))
+	for i, k := range attrs.Keys {
+		added[i] = addedAccountKey{
+			publicKey: k.PublicKey,
+			signAlgo:  flow_crypto.StringToSignatureAlgorithm(k.SignAlgo),
+			hashAlgo:  flow_crypto.StringToHashAlgorithm(k.HashAlgo),
+			weight:    k.Weight,
+			count:     attrs.NumKeys,
+		}
+
+		newPbks[i], err = flow_crypto.DecodePublicKeyHex(added[i].signAlgo, strings.TrimPrefix(k.PublicKey, "0x"))
+		if err != nil {
+			return 0, "", jobs.PermanentFailure(err)
+		}
+	}
+
+	flowAccount, err := s.fc.GetAccount(ctx, attrs.Address)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var txID string
+
+	// A retried job may have already sent the transaction, the keys are added
+	// and revoked in a single transaction so it's enough to look for a new key
+	if len(validKeyIndices(flowAccount, newPbks[0])) == 0 {
+		args, err := addAndRevokeKeysArgs(added, revokedKeyIndices(flowAccount, serviceKeys(dbAccount.Keys)))
+		if err != nil {
+			return 0, "", jobs.PermanentFailure(err)
+		}
+
+		entry.WithFields(log.Fields{"numKeys": attrs.NumKeys, "distinctKeys": len(added)}).Debug("going to rotate keys")
+
+		// NOTE: sync, so will wait for transaction to be sent & sealed
+		_, tx, err := s.txs.Create(ctx, true, dbAccount.Address, template_strings.RotateAccountKeyTransaction, args, transactions.General)
+		if tx != nil {
+			txID = tx.TransactionId
+		}
+		if err != nil {
+			entry.WithFields(log.Fields{"err": err}).Error("failed to create transaction")
+			return 0, txID, err
+		}
+
+		flowAccount, err = s.fc.GetAccount(ctx, attrs.Address)
+		if err != nil {
+			return 0, txID, err
+		}
+	}
+
+	newKeys := []keys.Storable{}
+	for i, k := range attrs.Keys {
+		indices := validKeyIndices(flowAccount, newPbks[i])
+		if len(indices) == 0 {
+			return 0, txID, fmt.Errorf("new key not found on-chain")
+		}
+
+		for _, index := range indices {
+			newKeys = append(newKeys, keys.Storable{
+				AccountAddress: dbAccount.Address,
+				Index:          index,
+				Type:           k.KeyType,
+				Value:          k.KeyValue,
+				PublicKey:      k.PublicKey,
+				SignAlgo:       k.SignAlgo,
+				HashAlgo:       k.HashAlgo,
+			})
+		}
+	}
+
+	numKeys := len(newKeys)
+
+	// Recovery keys are not rotated
+	for _, k := range recoveryKeys(dbAccount.Keys) {
+		k.ID = 0 // Reset ID to create a new key to DB
+		newKeys = append(newKeys, k)
+	}
+
+	if err := s.store.ReplaceAccountKeys(dbAccount.Address, newKeys); err != nil {
+		entry.WithFields(log.Fields{"err": err}).Error("failed to replace keys in database")
+		return 0, txID, err
+	}
+
+	entry.WithFields(log.Fields{"numKeys": numKeys}).Debug("keys rotated")
+
+	return numKeys, txID, nil
+}
+
+// validKeyIndices returns the indices of the non-revoked keys of the account
+// matching the public key.
+func validKeyIndices(account *flow.Account, pbk flow_crypto.PublicKey) []int {
+	indices := []int{}
+	for _, key := range account.Keys {
+		if !key.Revoked && key.PublicKey.Equals(pbk) {
+			indices = append(indices, key.Index)
+		}
+	}
+	return indices
+}
+
+// revokedKeyIndices returns the indices of the non-revoked on-chain keys that
+// are stored for the account.
+func revokedKeyIndices(account *flow.Account, stored []keys.Storable) []int {
+	storedIndices := make(map[int]bool, len(stored))
+	for _, k := range stored {
+		storedIndices[k.Index] = true
+	}
+
+	indices := []int{}
+	for _, key := range account.Keys {
+		if !key.Revoked && storedIndices[key.Index] {
+			indices = append(indices, key.Index)
+		}
+	}
+	return indices
+}
+
+// addedAccountKey is a key added count times by RotateAccountKeyTransaction.
+type addedAccountKey struct {
+	publicKey string
+	signAlgo  flow_crypto.SignatureAlgorithm
+	hashAlgo  flow_crypto.HashAlgorithm
+	weight    int
+	count     int
+}
+
+// addAndRevokeKeysArgs returns the arguments of RotateAccountKeyTransaction.
+func addAndRevokeKeysArgs(added []addedAccountKey, revoke []int) ([]transactions.Argument, error) {
+	pbkValues := make([]cadence.Value, len(added))
+	signAlgoValues := make([]cadence.Value, len(added))
+	hashAlgoValues := make([]cadence.Value, len(added))
+	weightValues := make([]cadence.Value, len(added))
+	countValues := make([]cadence.Value, len(added))
+
+	for i, k := range added {
+		signAlgo, err := cadenceSignatureAlgorithm(k.signAlgo)
+		if err != nil {
+			return nil, err
+		}
+
+		hashAlgo, err := cadenceHashAlgorithm(k.hashAlgo)
+		if err != nil {
+			return nil, err
+		}
+
+		pbk, err := cadence.NewString(strings.TrimPrefix(k.publicKey, "0x"))
+		if err != nil {
+			return nil, err
+		}
+
+		weight, err := cadence.NewUFix64(fmt.Sprintf("%d.0", k.weight))
+		if err != nil {
+			return nil, err
+		}
+
+		pbkValues[i] = pbk
+		signAlgoValues[i] = cadence.NewUInt8(signAlgo)
+		hashAlgoValues[i] = cadence.NewUInt8(hashAlgo)
+		weightValues[i] = weight
+		countValues[i] = cadence.NewInt(k.count)
+	}
+
+	revokeValues := make([]cadence.Value, len(revoke))
+	for i, index := range revoke {
+		revokeValues[i] = cadence.NewInt(index)
+	}
+
+	return []transactions.Argument{
+		cadence.NewArray(pbkValues),
+		cadence.NewArray(signAlgoValues),
+		cadence.NewArray(hashAlgoValues),
+		cadence.NewArray(weightValues),
+		cadence.NewArray(countValues),
+		cadence.NewArray(revokeValues),
+	}, nil
+}
+
+// cadenceSignatureAlgorithm returns the raw value of the Cadence
+// SignatureAlgorithm enum case for the signature algorithm.
+func cadenceSignatureAlgorithm(algo flow_crypto.SignatureAlgorithm) (uint8, error) {
+	switch algo {
+	case flow_crypto.ECDSA_P256:
+		return sema.SignatureAlgorithmECDSA_P256.RawValue(), nil
+	case flow_crypto.ECDSA_secp256k1:
+		return sema.SignatureAlgorithmECDSA_secp256k1.RawValue(), nil
+	}
+	return 0, fmt.Errorf("unsupported signature algorithm: %s", algo)
+}
+
+// cadenceHashAlgorithm returns the raw value of the Cadence HashAlgorithm
+// enum case for the hash algorithm.
+func cadenceHashAlgorithm(algo flow_crypto.HashAlgorithm) (uint8, error) {
+	switch algo {
+	case flow_crypto.SHA2_256:
+		return sema.HashAlgorithmSHA2_256.RawValue(), nil
+	case flow_crypto.SHA2_384:
+		return sema.HashAlgorithmSHA2_384.RawValue(), nil
+	case flow_crypto.SHA3_256:
+		return sema.HashAlgorithmSHA3_256.RawValue(), nil
+	case flow_crypto.SHA3_384:
+		return sema.HashAlgorithmSHA3_384.RawValue(), nil
+	}
+	return 0, fmt.Errorf("unsupported hash algorithm: %s", algo)
+}
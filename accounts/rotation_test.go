@@ -0,0 +1,83 @@
+package accounts
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+func TestRotationKeyIndices(t *testing.T) {
+	oldPbk := testPublicKey(t, 0)
+	newPbk := testPublicKey(t, 1)
+
+	account := &flow.Account{Keys: []*flow.AccountKey{
+		{Index: 0, PublicKey: oldPbk, Revoked: true},
+		{Index: 1, PublicKey: oldPbk},
+		{Index: 2, PublicKey: oldPbk},
+		{Index: 3, PublicKey: newPbk},
+		{Index: 4, PublicKey: newPbk, Revoked: true},
+	}}
+
+	stored := []keys.Storable{{Index: 0}, {Index: 1}}
+
+	if got := revokedKeyIndices(account, stored); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("expected only the stored non-revoked keys to be revoked, got %v", got)
+	}
+
+	if got := validKeyIndices(account, newPbk); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("expected only the non-revoked new key, got %v", got)
+	}
+}
+
+func TestRotationKeySpecs(t *testing.T) {
+	pbk1 := testPublicKey(t, 1)
+	pbk2 := testPublicKey(t, 2)
+
+	account := &flow.Account{Keys: []*flow.AccountKey{
+		{Index: 0, PublicKey: pbk1, Weight: 500, Revoked: true},
+		{Index: 1, PublicKey: pbk1, Weight: 600},
+		{Index: 2, PublicKey: pbk2, Weight: 400},
+	}}
+
+	stored := []keys.Storable{
+		{Index: 1, Type: "local", PublicKey: pbk1.String(), SignAlgo: "ECDSA_P256", HashAlgo: "SHA3_256"},
+		{Index: 2, Type: "aws_kms", PublicKey: pbk2.String(), SignAlgo: "ECDSA_P256", HashAlgo: "SHA2_256"},
+	}
+
+	// Each key keeps its type, algorithms and non-revoked on-chain weight
+	specs := rotationKeySpecs(account, stored, keys.KeySpec{})
+	expected := []keys.KeySpec{
+		{Type: "local", Weight: 600, SignAlgo: "ECDSA_P256", HashAlgo: "SHA3_256"},
+		{Type: "aws_kms", Weight: 400, SignAlgo: "ECDSA_P256", HashAlgo: "SHA2_256"},
+	}
+	if !reflect.DeepEqual(specs, expected) {
+		t.Errorf("expected %+v, got %+v", expected, specs)
+	}
+
+	// Set fields override those of every key
+	specs = rotationKeySpecs(account, stored, keys.KeySpec{SignAlgo: "ECDSA_secp256k1"})
+	if specs[0].SignAlgo != "ECDSA_secp256k1" || specs[1].SignAlgo != "ECDSA_secp256k1" || specs[1].Type != "aws_kms" {
+		t.Errorf("expected the signature algorithm to be overridden, got %+v", specs)
+	}
+}
+
+func TestCadenceAlgorithms(t *testing.T) {
+	if v, err := cadenceSignatureAlgorithm(crypto.ECDSA_secp256k1); err != nil || v != 2 {
+		t.Errorf("expected ECDSA_secp256k1 to be 2, got %d %v", v, err)
+	}
+
+	if v, err := cadenceHashAlgorithm(crypto.SHA3_256); err != nil || v != 3 {
+		t.Errorf("expected SHA3_256 to be 3, got %d %v", v, err)
+	}
+
+	if _, err := cadenceSignatureAlgorithm(crypto.UnknownSignatureAlgorithm); err == nil {
+		t.Error("expected an error for an unsupported signature algorithm")
+	}
+
+	if _, err := cadenceHashAlgorithm(crypto.UnknownHashAlgorithm); err == nil {
+		t.Error("expected an error for an unsupported hash algorithm")
+	}
+}
@@ -4,35 +4,41 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
-	"sort"
 	"strings"
 
 	"github.com/flow-hydraulics/flow-wallet-api/configs"
 	"github.com/flow-hydraulics/flow-wallet-api/datastore"
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
 	"github.com/flow-hydraulics/flow-wallet-api/jobs"
 	"github.com/flow-hydraulics/flow-wallet-api/keys"
-	"github.com/flow-hydraulics/flow-wallet-api/templates/template_strings"
+	"github.com/flow-hydraulics/flow-wallet-api/templates"
 	"github.com/flow-hydraulics/flow-wallet-api/transactions"
-	"github.com/onflow/cadence"
+	"github.com/google/uuid"
 	"github.com/onflow/flow-go-sdk"
-	flow_crypto "github.com/onflow/flow-go-sdk/crypto"
 	flow_templates "github.com/onflow/flow-go-sdk/templates"
 	log "github.com/sirupsen/logrus"
 	"go.uber.org/ratelimit"
 )
 
-const maxGasLimit = 9999
-
 type Service interface {
-	List(limit, offset int) (result []Account, err error)
-	Create(ctx context.Context, sync bool) (*jobs.Job, *Account, error)
-	AddNonCustodialAccount(address string) (*Account, error)
+	List(limit, offset int, filter AccountFilter) (result []Account, err error)
+	Create(ctx context.Context, sync bool, opts ...AccountOption) (*jobs.Job, *Account, error)
+	AddNonCustodialAccount(address string, opts ...AccountOption) (*Account, error)
+	ImportAccount(ctx context.Context, address string, importedKeys []ImportedKey, opts ...AccountOption) (*Account, error)
 	DeleteNonCustodialAccount(address string) error
-	SyncAccountKeyCount(ctx context.Context, address flow.Address) (*jobs.Job, error)
+	SyncAccountKeyCount(ctx context.Context, address flow.Address, revokeUnknownKeys bool) (*jobs.Job, error)
+	KeyDriftReport(jobID string) (*KeyDriftReport, error)
+	RotateAccountKeys(ctx context.Context, address flow.Address, spec keys.KeySpec) (*jobs.Job, error)
+	AddRecoveryKey(ctx context.Context, address flow.Address, key RecoveryKey) (*jobs.Job, error)
+	ExportAccount(ctx context.Context, address flow.Address) (*jobs.Job, error)
 	Details(address string) (Account, error)
+	UpdateMetadata(address string, metadata Metadata) (Account, error)
+	SetState(address string, state AccountState) (Account, error)
 	InitAdminAccount(ctx context.Context) error
+	CheckFundingAccount() error
 }
 
 // ServiceImpl defines the API for account management.
@@ -43,6 +49,7 @@ type ServiceImpl struct {
 	fc            flow_helpers.FlowClient
 	wp            jobs.WorkerPool
 	txs           transactions.Service
+	templates     templates.Service
 	txRateLimiter ratelimit.Limiter
 }
 
@@ -54,12 +61,13 @@ func NewService(
 	fc flow_helpers.FlowClient,
 	wp jobs.WorkerPool,
 	txs transactions.Service,
+	tes templates.Service,
 	opts ...ServiceOption,
 ) Service {
 	var defaultTxRatelimiter = ratelimit.NewUnlimited()
 
 	// TODO(latenssi): safeguard against nil config?
-	svc := &ServiceImpl{cfg, store, km, fc, wp, txs, defaultTxRatelimiter}
+	svc := &ServiceImpl{cfg, store, km, fc, wp, txs, tes, defaultTxRatelimiter}
 
 	for _, opt := range opts {
 		opt(svc)
@@ -72,25 +80,83 @@ func NewService(
 	// Register asynchronous job executors
 	wp.RegisterExecutor(AccountCreateJobType, svc.executeAccountCreateJob)
 	wp.RegisterExecutor(SyncAccountKeyCountJobType, svc.executeSyncAccountKeyCountJob)
+	wp.RegisterExecutor(RotateAccountKeysJobType, svc.executeRotateAccountKeysJob)
+	wp.RegisterExecutor(AccountPoolRefillJobType, svc.executeAccountPoolRefillJob)
+	wp.RegisterExecutor(AddRecoveryKeyJobType, svc.executeAddRecoveryKeyJob)
+	wp.RegisterExecutor(ExportAccountJobType, svc.executeExportAccountJob)
+
+	if svc.accountPoolEnabled() {
+		wp.RegisterReconciler(AccountPoolReconcilerName, svc.reconcileAccountPool)
+		wp.RegisterGauge(AccountPoolDepthGaugeName, store.PooledAccountCount)
+	}
 
 	return svc
 }
 
-// List returns all accounts in the datastore.
-func (s *ServiceImpl) List(limit, offset int) (result []Account, err error) {
+// List returns all accounts in the datastore matching the filter.
+func (s *ServiceImpl) List(limit, offset int, filter AccountFilter) (result []Account, err error) {
 	o := datastore.ParseListOptions(limit, offset)
-	return s.store.Accounts(o)
+	return s.store.Accounts(o, filter)
 }
 
 // Create calls account.New to generate a new account.
 // It receives a new account with a corresponding private key or resource ID
 // and stores both in datastore.
 // It returns a job, the new account and a possible error.
-func (s *ServiceImpl) Create(ctx context.Context, sync bool) (*jobs.Job, *Account, error) {
+// An account with the same external ID must not exist.
+// Vaults for the given tokens are set up in the account creation transaction.
+// Initial funding is transferred to the account in a job scheduled once the
+// account has been set up.
+func (s *ServiceImpl) Create(ctx context.Context, sync bool, opts ...AccountOption) (*jobs.Job, *Account, error) {
 	log.WithFields(log.Fields{"sync": sync}).Trace("Create account")
 
+	o := parseAccountOptions(opts)
+
+	if err := o.Metadata.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	// Fail early, checked again right before creating the account
+	if err := s.checkExternalID(o.Metadata.ExternalID, ""); err != nil {
+		return nil, nil, err
+	}
+
+	if len(o.Keys) > 0 {
+		if err := s.validateKeySpecs(o.Keys); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if _, err := s.validateRecoveryKeys(o.RecoveryKeys); err != nil {
+		return nil, nil, err
+	}
+
+	if len(o.Tokens) > 0 && s.cfg.ScriptPathCreateAccount != "" {
+		return nil, nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("token setup is not supported with a custom account creation script"),
+		}
+	}
+
+	setups, err := s.tokenSetups(o.Tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Resolve the funding now, so that the configured default at the time of
+	// the request is used
+	o.InitialFunding, err = s.initialFunding(o.InitialFunding, setups)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if !sync {
-		job, err := s.wp.CreateJob(AccountCreateJobType, "")
+		attrBytes, err := json.Marshal(o)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		job, err := s.wp.CreateJob(AccountCreateJobType, "", jobs.WithAttributes(attrBytes))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -103,7 +169,7 @@ func (s *ServiceImpl) Create(ctx context.Context, sync bool) (*jobs.Job, *Accoun
 		return job, nil, err
 	}
 
-	account, _, err := s.createAccount(ctx)
+	account, _, err := s.createAccount(ctx, o)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -111,12 +177,25 @@ func (s *ServiceImpl) Create(ctx context.Context, sync bool) (*jobs.Job, *Accoun
 	return nil, account, nil
 }
 
-func (s *ServiceImpl) AddNonCustodialAccount(address string) (*Account, error) {
+func (s *ServiceImpl) AddNonCustodialAccount(address string, opts ...AccountOption) (*Account, error) {
 	log.WithFields(log.Fields{"address": address}).Trace("Add non-custodial account")
 
+	o := parseAccountOptions(opts)
+
+	if err := o.Metadata.validate(); err != nil {
+		return nil, err
+	}
+
 	a := &Account{
 		Address: flow_helpers.HexString(address),
 		Type:    AccountTypeNonCustodial,
+		State:   AccountStateActive,
+	}
+
+	o.Metadata.applyTo(a)
+
+	if err := s.checkExternalID(a.ExternalID, ""); err != nil {
+		return nil, err
 	}
 
 	err := s.store.InsertAccount(a)
@@ -170,15 +249,82 @@ func (s *ServiceImpl) Details(address string) (Account, error) {
 	return account, nil
 }
 
-// SyncKeyCount syncs number of keys for given account
-func (s *ServiceImpl) SyncAccountKeyCount(ctx context.Context, address flow.Address) (*jobs.Job, error) {
+// UpdateMetadata updates the external ID, labels and attributes of an
+// account. Nil metadata fields are left unchanged.
+func (s *ServiceImpl) UpdateMetadata(address string, metadata Metadata) (Account, error) {
+	log.WithFields(log.Fields{"address": address}).Trace("Update account metadata")
+
+	address, err := flow_helpers.ValidateAddress(address, s.cfg.ChainID)
+	if err != nil {
+		return Account{}, err
+	}
+
+	if err := metadata.validate(); err != nil {
+		return Account{}, err
+	}
+
+	account, err := s.store.Account(address)
+	if err != nil {
+		return Account{}, err
+	}
+
+	metadata.applyTo(&account)
+
+	if err := s.checkExternalID(account.ExternalID, account.Address); err != nil {
+		return Account{}, err
+	}
+
+	if err := s.store.UpdateAccountMetadata(&account); err != nil {
+		return Account{}, err
+	}
+
+	// Strip the private keys
+	for i := range account.Keys {
+		account.Keys[i].Value = make([]byte, 0)
+	}
+
+	return account, nil
+}
+
+// checkExternalID returns a conflict error if the external ID is used by an
+// account other than the one with the given address.
+func (s *ServiceImpl) checkExternalID(externalID *string, address string) error {
+	if externalID == nil || strings.TrimSpace(*externalID) == "" {
+		return nil
+	}
+
+	a, err := s.store.AccountByExternalID(strings.TrimSpace(*externalID))
+	if err != nil {
+		if strings.Contains(err.Error(), "record not found") {
+			return nil
+		}
+		return err
+	}
+
+	if a.Address == address {
+		return nil
+	}
+
+	return &errors.RequestError{
+		StatusCode: http.StatusConflict,
+		Err:        fmt.Errorf("account with external id %q already exists: %s", *a.ExternalID, a.Address),
+	}
+}
+
+// SyncKeyCount syncs number of keys for given account, optionally revoking
+// on-chain keys that are not stored in the database.
+func (s *ServiceImpl) SyncAccountKeyCount(ctx context.Context, address flow.Address, revokeUnknownKeys bool) (*jobs.Job, error) {
 	// Validate address, they might be legit addresses but for the wrong chain
 	if !address.IsValid(s.cfg.ChainID) {
 		return nil, fmt.Errorf(`not a valid address for %s: "%s"`, s.cfg.ChainID, address)
 	}
 
 	// Prepare job attributes required for executing the job
-	attrs := syncAccountKeyCountJobAttributes{Address: address, NumKeys: int(s.cfg.DefaultAccountKeyCount)}
+	attrs := syncAccountKeyCountJobAttributes{
+		Address:           address,
+		NumKeys:           int(s.cfg.DefaultAccountKeyCount),
+		RevokeUnknownKeys: revokeUnknownKeys,
+	}
 	attrBytes, err := json.Marshal(attrs)
 	if err != nil {
 		return nil, err
@@ -197,131 +343,63 @@ func (s *ServiceImpl) SyncAccountKeyCount(ctx context.Context, address flow.Addr
 	return job, nil
 }
 
-// syncAccountKeyCount syncs the number of account keys with the given numKeys and
-// returns the number of keys, transaction ID and error.
-func (s *ServiceImpl) syncAccountKeyCount(ctx context.Context, address flow.Address, numKeys int) (int, string, error) {
-	entry := log.WithFields(log.Fields{"address": address, "numKeys": numKeys, "function": "ServiceImpl.syncAccountKeyCount"})
-
-	if numKeys < 1 {
-		return 0, "", fmt.Errorf("invalid number of keys specified: %d, min. 1 expected", numKeys)
-	}
-
-	// Check on-chain keys
-	flowAccount, err := s.fc.GetAccount(ctx, address)
+// KeyDriftReport returns the drift report of a completed key count sync job.
+func (s *ServiceImpl) KeyDriftReport(jobID string) (*KeyDriftReport, error) {
+	id, err := uuid.Parse(jobID)
 	if err != nil {
-		entry.WithFields(log.Fields{"err": err}).Error("failed to get Flow account")
-		return 0, "", err
-	}
-
-	// Get stored account
-	dbAccount, err := s.store.Account(flow_helpers.FormatAddress(address))
-	if err != nil {
-		entry.WithFields(log.Fields{"err": err}).Error("failed to get account from database")
-		return 0, "", err
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid job id"),
+		}
 	}
 
-	// Pick a source key that will be used to create the new keys & decode public key
-	sourceKey := dbAccount.Keys[0] // NOTE: Only valid (not revoked) keys should be stored in the database
-	sourceKeyPbkString := strings.TrimPrefix(sourceKey.PublicKey, "0x")
-	sourcePbk, err := flow_crypto.DecodePublicKeyHex(flow_crypto.StringToSignatureAlgorithm(sourceKey.SignAlgo), sourceKeyPbkString)
+	report, err := s.store.KeyDriftReport(id)
 	if err != nil {
-		entry.WithFields(log.Fields{"err": err, "sourceKeyPbkString": sourceKeyPbkString}).Error("failed to decode public key for source key")
-		return 0, "", err
-	}
-	entry.WithFields(log.Fields{"sourceKeyId": sourceKey.ID, "sourcePbk": sourcePbk}).Trace("source key selected")
-
-	// Count valid keys, as some keys might be revoked, assuming dbAccount.Keys are clones (all have same public key)
-	var validKeys []*flow.AccountKey
-	for i := range flowAccount.Keys {
-		key := flowAccount.Keys[i]
-		if !key.Revoked && key.PublicKey.Equals(sourcePbk) {
-			validKeys = append(validKeys, key)
+		if strings.Contains(err.Error(), "record not found") {
+			return nil, &errors.RequestError{StatusCode: http.StatusNotFound, Err: fmt.Errorf("key drift report not found")}
 		}
+		return nil, err
 	}
 
-	if len(validKeys) != len(dbAccount.Keys) {
-		entry.WithFields(log.Fields{"onChain": len(validKeys), "database": len(dbAccount.Keys)}).Warn("on-chain vs. database key count mismatch")
-	}
-
-	entry.WithFields(log.Fields{"validKeys": validKeys}).Trace("filtered valid keys")
-
-	// Add keys by cloning the source key
-	if len(validKeys) < numKeys {
-
-		cloneCount := numKeys - len(validKeys)
-		code := template_strings.AddAccountKeysTransaction
-		pbks := []cadence.Value{}
-
-		entry.WithFields(log.Fields{"validKeys": len(validKeys), "numKeys": numKeys, "cloneCount": cloneCount}).Debug("going to add keys")
-
-		// Sort keys by index
-		sort.SliceStable(dbAccount.Keys, func(i, j int) bool {
-			return dbAccount.Keys[i].Index < dbAccount.Keys[j].Index
-		})
+	return &report, nil
+}
 
-		// Push publickeys to args and prepare db update
-		for i := 0; i < cloneCount; i++ {
-			pbk, err := cadence.NewString(sourceKey.PublicKey[2:]) // TODO: use a helper function to trim "0x" prefix
-			if err != nil {
-				return 0, "", err
-			}
-			pbks = append(pbks, pbk)
-
-			// Create cloned account key & update index
-			cloned := keys.Storable{
-				ID:             0, // Reset ID to create a new key to DB
-				AccountAddress: sourceKey.AccountAddress,
-				Index:          dbAccount.Keys[len(dbAccount.Keys)-1].Index + 1,
-				Type:           sourceKey.Type,
-				Value:          sourceKey.Value,
-				PublicKey:      sourceKey.PublicKey,
-				SignAlgo:       sourceKey.SignAlgo,
-				HashAlgo:       sourceKey.HashAlgo,
-			}
+// createAccount creates a new account on the flow blockchain. It generates a
+// fresh key pair and constructs a flow transaction to create the account with
+// generated key. Admin account is used to pay for the transaction.
+//
+// Returns created account and the flow transaction ID of the account creation.
+func (s *ServiceImpl) createAccount(ctx context.Context, o accountOptions) (*Account, string, error) {
+	account := &Account{Type: AccountTypeCustodial, State: AccountStateActive, Pooled: o.pooled}
 
-			dbAccount.Keys = append(dbAccount.Keys, cloned)
-		}
+	o.Metadata.applyTo(account)
 
-		// Prepare transaction arguments
-		x := cadence.NewArray(pbks)
-		args := []transactions.Argument{x}
+	// The external ID may have been taken while the job was queued
+	if err := s.checkExternalID(account.ExternalID, ""); err != nil {
+		return nil, "", err
+	}
 
-		entry.WithFields(log.Fields{"args": args}).Debug("args prepared")
+	setups, err := s.tokenSetups(o.Tokens)
+	if err != nil {
+		return nil, "", err
+	}
 
-		// NOTE: sync, so will wait for transaction to be sent & sealed
-		_, tx, err := s.txs.Create(ctx, true, dbAccount.Address, code, args, transactions.General)
+	// Pooled accounts have no token vaults besides FlowToken and a single key
+	if !o.pooled && len(setups) == 0 && len(o.Keys) == 0 && len(o.RecoveryKeys) == 0 {
+		pooled, err := s.takePooledAccount(o)
 		if err != nil {
-			entry.WithFields(log.Fields{"err": err}).Error("failed to create transaction")
-			return 0, tx.TransactionId, err
+			return nil, "", err
 		}
 
-		// Update account in database
-		// TODO: if update fails, should sync keys from chain later
-		err = s.store.SaveAccount(&dbAccount)
-		if err != nil {
-			entry.WithFields(log.Fields{"err": err}).Error("failed to update account in database")
-			return 0, tx.TransactionId, err
+		if pooled != nil {
+			log.WithFields(log.Fields{"address": pooled.Address}).Debug("Account assigned from pool")
+			if err := s.accountAdded(pooled, nil, o); err != nil {
+				return pooled, "", err
+			}
+			return pooled, "", nil
 		}
-
-		return len(dbAccount.Keys), tx.TransactionId, err
-	} else if len(validKeys) > numKeys {
-		entry.Debug("too many valid keys", len(validKeys), " vs. ", numKeys)
-	} else {
-		entry.Debug("correct number of keys")
-		return numKeys, "", nil
 	}
 
-	return 0, "", nil
-}
-
-// createAccount creates a new account on the flow blockchain. It generates a
-// fresh key pair and constructs a flow transaction to create the account with
-// generated key. Admin account is used to pay for the transaction.
-//
-// Returns created account and the flow transaction ID of the account creation.
-func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, error) {
-	account := &Account{Type: AccountTypeCustodial}
-
 	// Important to ratelimit all the way up here so the keys and reference blocks
 	// are "fresh" when the transaction is actually sent
 	s.txRateLimiter.Take()
@@ -342,21 +420,25 @@ func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, erro
 		return nil, "", err
 	}
 
-	// Generate a new key pair
-	accountKey, newPrivateKey, err := s.km.GenerateDefault(ctx)
+	// Generate new key pairs
+	keyPairs, err := s.generateAccountKeys(ctx, o.Keys)
 	if err != nil {
 		return nil, "", err
 	}
 
-	// Public keys for creating the account
-	publicKeys := []*flow.AccountKey{}
-
-	// Create copies based on the configured key count, changing just the index
-	for i := 0; i < int(s.cfg.DefaultAccountKeyCount); i++ {
-		clonedAccountKey := *accountKey
-		clonedAccountKey.Index = i
+	recovery, err := s.validateRecoveryKeys(o.RecoveryKeys)
+	if err != nil {
+		return nil, "", err
+	}
 
-		publicKeys = append(publicKeys, &clonedAccountKey)
+	// Public keys for creating the account, recovery keys after the service keys
+	publicKeys := []*flow.AccountKey{}
+	for _, pair := range keyPairs {
+		publicKeys = append(publicKeys, pair.public)
+	}
+	for _, key := range recovery {
+		key.Index = len(publicKeys)
+		publicKeys = append(publicKeys, key)
 	}
 
 	flowTx, err := flow_templates.CreateAccount(
@@ -372,7 +454,7 @@ func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, erro
 		SetReferenceBlockID(*referenceBlockID).
 		SetProposalKey(proposer.Address, proposer.Key.Index, proposer.Key.SequenceNumber).
 		SetPayer(payer.Address).
-		SetGasLimit(maxGasLimit)
+		SetGasLimit(transactions.DefaultGasLimit(s.cfg.TransactionMaxGasLimit))
 
 	// Check if we want to use a custom account create script
 	if s.cfg.ScriptPathCreateAccount != "" {
@@ -384,6 +466,11 @@ func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, erro
 		flowTx.SetScript(bytes)
 	}
 
+	// Set up the token vaults in the same transaction
+	if len(setups) > 0 {
+		flowTx.SetScript([]byte(createAccountWithTokensCode(setups)))
+	}
+
 	// Proposer signs the payload (unless proposer == payer).
 	if !proposer.Equals(payer) {
 		if err := flowTx.SignPayload(proposer.Address, proposer.Key.Index, proposer.Signer); err != nil {
@@ -398,6 +485,9 @@ func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, erro
 
 	// Send and wait for the transaction to be sealed
 	result, err := flow_helpers.SendAndWait(ctx, s.fc, *flowTx, s.cfg.TransactionTimeout)
+	if result != nil {
+		s.recordTransaction(ctx, flowTx, result)
+	}
 	if err != nil {
 		return nil, "", err
 	}
@@ -419,32 +509,102 @@ func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, erro
 
 	account.Address = flow_helpers.FormatAddress(newAddress)
 
-	// Convert the key to storable form (encrypt it)
-	encryptedAccountKey, err := s.km.Save(*newPrivateKey)
-	if err != nil {
-		return nil, "", err
-	}
-	encryptedAccountKey.PublicKey = accountKey.PublicKey.String()
-
 	// Store account and key(s)
-	// Looping through accountKeys to get the correct Index values
 	storableKeys := []keys.Storable{}
-	for _, pbk := range publicKeys {
-		clonedEncryptedAccountKey := encryptedAccountKey
-		clonedEncryptedAccountKey.Index = pbk.Index
-		storableKeys = append(storableKeys, clonedEncryptedAccountKey)
+	for _, pair := range keyPairs {
+		// Convert the key to storable form (encrypt it)
+		encryptedAccountKey, err := s.km.Save(*pair.private)
+		if err != nil {
+			return nil, "", err
+		}
+		encryptedAccountKey.PublicKey = pair.public.PublicKey.String()
+		storableKeys = append(storableKeys, encryptedAccountKey)
+	}
+	for _, key := range recovery {
+		storableKeys = append(storableKeys, recoveryStorable(account.Address, key))
 	}
 
 	account.Keys = storableKeys
-	if err := s.store.InsertAccount(account); err != nil {
+	if stored, err := s.insertCreatedAccount(account); err != nil {
+		if stored {
+			// Stored without the external ID, see insertCreatedAccount
+			return account, flowTx.ID().String(), err
+		}
 		return nil, "", err
 	}
 
+	log.WithFields(log.Fields{"address": account.Address, "pooled": o.pooled}).Debug("Account created")
+
+	// Pooled accounts are added when they are assigned
+	if !o.pooled {
+		if err := s.accountAdded(account, setups, o); err != nil {
+			return account, flowTx.ID().String(), err
+		}
+	}
+
+	return account, flowTx.ID().String(), nil
+}
+
+// insertCreatedAccount stores an account created on-chain. If the external ID
+// was taken while the account was being created, the account is stored
+// without it so that its keys are not lost, and a conflict error is returned
+// along with stored set to true.
+func (s *ServiceImpl) insertCreatedAccount(account *Account) (stored bool, err error) {
+	err = s.store.InsertAccount(account)
+	if err == nil || account.ExternalID == nil {
+		return err == nil, err
+	}
+
+	if conflict := s.checkExternalID(account.ExternalID, ""); conflict == nil {
+		return false, err
+	}
+
+	externalID := *account.ExternalID
+	account.ExternalID = nil
+
+	if err := s.store.InsertAccount(account); err != nil {
+		return false, err
+	}
+
+	log.
+		WithFields(log.Fields{"address": account.Address, "externalId": externalID}).
+		Warn("External ID was taken while creating the account, stored the account without it")
+
+	return true, &errors.RequestError{
+		StatusCode: http.StatusConflict,
+		Err:        fmt.Errorf("account with external id %q was created concurrently, account %s was created without the external id", externalID, account.Address),
+	}
+}
+
+// recordTransaction stores a transaction sent directly by the accounts service
+// so that the fees paid for it are included in fee summaries. Errors are only
+// logged, as the transaction has already been sent.
+func (s *ServiceImpl) recordTransaction(ctx context.Context, flowTx *flow.Transaction, result *flow.TransactionResult) {
+	if err := s.txs.RecordResult(ctx, flowTx, transactions.General, result); err != nil {
+		log.
+			WithFields(log.Fields{"transactionId": flowTx.ID().Hex(), "error": err}).
+			Warn("Error while recording transaction")
+	}
+}
+
+// accountAdded triggers the AccountAdded event for a new custodial account
+// and schedules its initial funding.
+func (s *ServiceImpl) accountAdded(account *Account, setups []tokenSetup, o accountOptions) error {
+	tokenNames := make([]string, len(setups))
+	for i, setup := range setups {
+		tokenNames[i] = setup.tokenName
+	}
+
 	AccountAdded.Trigger(AccountAddedPayload{
 		Address: flow.HexToAddress(account.Address),
+		Tokens:  tokenNames,
 	})
 
-	log.WithFields(log.Fields{"address": account.Address}).Debug("Account created")
+	if o.InitialFunding != nil {
+		if _, err := s.scheduleInitialFunding(account.Address, *o.InitialFunding, o.jobID); err != nil {
+			return err
+		}
+	}
 
-	return account, flowTx.ID().String(), nil
+	return nil
 }
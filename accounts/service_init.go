@@ -8,6 +8,7 @@ import (
 	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
 	"github.com/flow-hydraulics/flow-wallet-api/keys"
 	"github.com/flow-hydraulics/flow-wallet-api/templates/template_strings"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
 	"github.com/onflow/cadence"
 	"github.com/onflow/flow-go-sdk"
 	log "github.com/sirupsen/logrus"
@@ -87,7 +88,7 @@ func (s *ServiceImpl) addAdminProposalKeys(ctx context.Context, count uint16) er
 		SetReferenceBlockID(*referenceBlockID).
 		SetProposalKey(payer.Address, payer.Key.Index, payer.Key.SequenceNumber).
 		SetPayer(payer.Address).
-		SetGasLimit(maxGasLimit).
+		SetGasLimit(transactions.DefaultGasLimit(s.cfg.TransactionMaxGasLimit)).
 		SetScript([]byte(code))
 
 	if err := flowTx.AddArgument(cadence.NewInt(s.cfg.AdminKeyIndex)); err != nil {
@@ -105,7 +106,11 @@ func (s *ServiceImpl) addAdminProposalKeys(ctx context.Context, count uint16) er
 	}
 
 	// Send and wait for the transaction to be sealed
-	if _, err := flow_helpers.SendAndWait(ctx, s.fc, *flowTx, s.cfg.TransactionTimeout); err != nil {
+	result, err := flow_helpers.SendAndWait(ctx, s.fc, *flowTx, s.cfg.TransactionTimeout)
+	if result != nil {
+		s.recordTransaction(ctx, flowTx, result)
+	}
+	if err != nil {
 		return err
 	}
 
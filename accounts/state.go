@@ -0,0 +1,97 @@
+package accounts
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
+	log "github.com/sirupsen/logrus"
+)
+
+// CheckActive returns an error if the account is frozen or archived. Only
+// active accounts may sign transactions.
+func (a Account) CheckActive() error {
+	if a.State == "" || a.State == AccountStateActive {
+		return nil
+	}
+
+	return &errors.RequestError{
+		StatusCode: http.StatusForbidden,
+		Err:        fmt.Errorf("account %s is %s", a.Address, a.State),
+	}
+}
+
+// SetState changes the state of a custodial account. Frozen accounts can not
+// sign transactions but deposits to them are still tracked. Archived accounts
+// are also left out of listings. Keys are kept in both states.
+func (s *ServiceImpl) SetState(address string, state AccountState) (Account, error) {
+	log.WithFields(log.Fields{"address": address, "state": state}).Trace("Set account state")
+
+	address, err := flow_helpers.ValidateAddress(address, s.cfg.ChainID)
+	if err != nil {
+		return Account{}, err
+	}
+
+	switch state {
+	case AccountStateActive, AccountStateFrozen, AccountStateArchived:
+	default:
+		return Account{}, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid account state: %q", state),
+		}
+	}
+
+	if address == s.cfg.AdminAddress {
+		return Account{}, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("the state of the admin account can not be changed"),
+		}
+	}
+
+	account, err := s.store.Account(address)
+	if err != nil {
+		return Account{}, err
+	}
+
+	if account.Type != AccountTypeCustodial {
+		return Account{}, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("only custodial accounts have a state"),
+		}
+	}
+
+	account.State = state
+
+	if err := s.store.UpdateAccountState(&account); err != nil {
+		return Account{}, err
+	}
+
+	// Strip the private keys
+	for i := range account.Keys {
+		account.Keys[i].Value = make([]byte, 0)
+	}
+
+	log.WithFields(log.Fields{"address": address, "state": state}).Info("Account state changed")
+
+	return account, nil
+}
+
+// SignerCheck returns a check for the transaction service that rejects
+// transactions signed by custodial accounts that are not active.
+func SignerCheck(store Store) transactions.SignerCheck {
+	return func(address string) error {
+		account, err := store.Account(address)
+		if err != nil {
+			// Unknown accounts have no keys to sign with in the first place
+			if strings.Contains(err.Error(), "record not found") {
+				return nil
+			}
+			return err
+		}
+
+		return account.CheckActive()
+	}
+}
@@ -2,22 +2,62 @@ package accounts
 
 import (
 	"github.com/flow-hydraulics/flow-wallet-api/datastore"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/google/uuid"
 )
 
 // Store manages data regarding accounts.
 type Store interface {
-	// List all accounts.
-	Accounts(datastore.ListOptions) ([]Account, error)
+	// List all accounts matching the filter, pooled accounts excluded.
+	// Archived accounts are excluded unless filtered by state.
+	Accounts(datastore.ListOptions, AccountFilter) ([]Account, error)
 
 	// Get account details.
 	Account(address string) (Account, error)
 
+	// Get account details by external ID.
+	AccountByExternalID(externalID string) (Account, error)
+
 	// Insert a new account.
 	InsertAccount(a *Account) error
 
 	// Update an existing account.
 	SaveAccount(a *Account) error
 
+	// Replace all keys of an account in a single transaction.
+	ReplaceAccountKeys(address string, kk []keys.Storable) error
+
+	// Insert a new key of an existing account.
+	InsertAccountKey(k keys.Storable) error
+
+	// Update the external ID, labels and attributes of an existing account.
+	UpdateAccountMetadata(a *Account) error
+
+	// Update the state of an existing account.
+	UpdateAccountState(a *Account) error
+
+	// Update the type of an existing account.
+	UpdateAccountType(a *Account) error
+
+	// Count the pre-created accounts in the account pool.
+	PooledAccountCount() (int64, error)
+
+	// List the account pool refill jobs that have not completed or failed,
+	// oldest first.
+	PendingPoolRefillJobs() ([]jobs.Job, error)
+
+	// Assign the oldest pooled account by setting the metadata of a, and
+	// load the assigned account into a. Returns a "record not found" error
+	// if the pool is empty.
+	AssignPooledAccount(a *Account) error
+
+	// Insert or replace the drift report of a key count sync job.
+	InsertKeyDriftReport(jobID uuid.UUID, r *KeyDriftReport) error
+
+	// Get the drift report of a key count sync job.
+	KeyDriftReport(jobID uuid.UUID) (KeyDriftReport, error)
+
 	// Permanently delete an account, despite of `DeletedAt` field.
 	HardDeleteAccount(a *Account) error
 }
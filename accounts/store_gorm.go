@@ -1,10 +1,18 @@
 package accounts
 
 import (
+	"encoding/json"
+
 	"github.com/flow-hydraulics/flow-wallet-api/datastore"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+const maxPoolAssignAttempts = 10
+
 type GormStore struct {
 	db *gorm.DB
 }
@@ -13,28 +21,247 @@ func NewGormStore(db *gorm.DB) Store {
 	return &GormStore{db}
 }
 
-func (s *GormStore) Accounts(o datastore.ListOptions) (aa []Account, err error) {
-	err = s.db.
+func (s *GormStore) Accounts(o datastore.ListOptions, f AccountFilter) (aa []Account, err error) {
+	q := s.db.Where("pooled = ?", false)
+
+	if f.State != "" {
+		q = q.Where("state = ?", f.State)
+	} else {
+		q = q.Where("state <> ?", AccountStateArchived)
+	}
+
+	if f.ExternalID != "" {
+		q = q.Where("external_id = ?", f.ExternalID)
+	}
+
+	if labels := normalizeLabels(f.Labels); len(labels) > 0 {
+		q = q.Where(
+			"address IN (?)",
+			s.db.Model(&AccountLabel{}).
+				Select("account_address").
+				Where("label IN ?", labels).
+				Group("account_address").
+				Having("COUNT(DISTINCT label) = ?", len(labels)),
+		)
+	}
+
+	err = q.
 		Order("created_at desc").
 		Limit(o.Limit).
 		Offset(o.Offset).
 		Find(&aa).Error
+	if err != nil {
+		return
+	}
+
+	err = loadLabels(s.db, aa)
 	return
 }
 
 func (s *GormStore) Account(address string) (a Account, err error) {
-	err = s.db.Preload("Keys").First(&a, "address = ?", address).Error
-	return
+	if err = s.db.Preload("Keys").First(&a, "address = ?", address).Error; err != nil {
+		return
+	}
+	aa := []Account{a}
+	err = loadLabels(s.db, aa)
+	return aa[0], err
+}
+
+func (s *GormStore) AccountByExternalID(externalID string) (a Account, err error) {
+	if err = s.db.First(&a, "external_id = ?", externalID).Error; err != nil {
+		return
+	}
+	aa := []Account{a}
+	err = loadLabels(s.db, aa)
+	return aa[0], err
 }
 
 func (s *GormStore) InsertAccount(a *Account) error {
-	return s.db.Create(a).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(a).Error; err != nil {
+			return err
+		}
+		return insertLabels(tx, a)
+	})
 }
 
 func (s *GormStore) SaveAccount(a *Account) error {
 	return s.db.Save(&a).Error
 }
 
+func (s *GormStore) ReplaceAccountKeys(address string, kk []keys.Storable) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("account_address = ?", address).Delete(&keys.Storable{}).Error; err != nil {
+			return err
+		}
+		if len(kk) == 0 {
+			return nil
+		}
+		for i := range kk {
+			kk[i].AccountAddress = address
+		}
+		return tx.Create(&kk).Error
+	})
+}
+
+func (s *GormStore) InsertAccountKey(k keys.Storable) error {
+	return s.db.Create(&k).Error
+}
+
+func (s *GormStore) UpdateAccountMetadata(a *Account) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(a).
+			Select("external_id", "attributes", "updated_at").
+			Updates(a).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("account_address = ?", a.Address).Delete(&AccountLabel{}).Error; err != nil {
+			return err
+		}
+
+		return insertLabels(tx, a)
+	})
+}
+
+func (s *GormStore) UpdateAccountState(a *Account) error {
+	return s.db.Model(a).Select("state", "updated_at").Updates(a).Error
+}
+
+func (s *GormStore) UpdateAccountType(a *Account) error {
+	return s.db.Model(a).Select("type", "updated_at").Updates(a).Error
+}
+
+func (s *GormStore) PooledAccountCount() (n int64, err error) {
+	err = s.db.Model(&Account{}).Where("pooled = ?", true).Count(&n).Error
+	return
+}
+
+func (s *GormStore) PendingPoolRefillJobs() (jj []jobs.Job, err error) {
+	err = s.db.
+		Where("type = ? AND state NOT IN ?", AccountPoolRefillJobType, []jobs.State{jobs.Complete, jobs.Failed}).
+		Order("created_at asc").
+		Find(&jj).Error
+	return
+}
+
+func (s *GormStore) AssignPooledAccount(a *Account) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// Concurrent assignments may pick the same account, only one of them
+		// will update it
+		for attempt := 0; attempt < maxPoolAssignAttempts; attempt++ {
+			var pooled Account
+			if err := tx.Where("pooled = ?", true).Order("created_at asc").First(&pooled).Error; err != nil {
+				return err
+			}
+
+			res := tx.Model(&Account{}).
+				Where("address = ? AND pooled = ?", pooled.Address, true).
+				Updates(map[string]interface{}{
+					"pooled":      false,
+					"external_id": a.ExternalID,
+					"attributes":  a.Attributes,
+				})
+			if res.Error != nil {
+				return res.Error
+			}
+
+			if res.RowsAffected == 0 {
+				continue
+			}
+
+			a.Address = pooled.Address
+			if err := insertLabels(tx, a); err != nil {
+				return err
+			}
+
+			labels := a.Labels
+			if err := tx.Preload("Keys").First(a, "address = ?", pooled.Address).Error; err != nil {
+				return err
+			}
+			a.Labels = labels
+
+			return nil
+		}
+
+		return gorm.ErrRecordNotFound
+	})
+}
+
+func (s *GormStore) InsertKeyDriftReport(jobID uuid.UUID, r *KeyDriftReport) error {
+	report, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	stored := StoredKeyDriftReport{JobID: jobID, Address: r.Address, Report: report}
+
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "job_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"report", "updated_at"}),
+	}).Create(&stored).Error
+}
+
+func (s *GormStore) KeyDriftReport(jobID uuid.UUID) (r KeyDriftReport, err error) {
+	var stored StoredKeyDriftReport
+	if err = s.db.First(&stored, "job_id = ?", jobID).Error; err != nil {
+		return
+	}
+	err = json.Unmarshal(stored.Report, &r)
+	return
+}
+
 func (s *GormStore) HardDeleteAccount(a *Account) error {
-	return s.db.Unscoped().Delete(a).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("account_address = ?", a.Address).Delete(&AccountLabel{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(a).Error
+	})
+}
+
+func insertLabels(tx *gorm.DB, a *Account) error {
+	if len(a.Labels) == 0 {
+		return nil
+	}
+
+	ll := make([]AccountLabel, len(a.Labels))
+	for i, l := range a.Labels {
+		ll[i] = AccountLabel{AccountAddress: a.Address, Label: l}
+	}
+
+	return tx.Create(&ll).Error
+}
+
+// loadLabels populates the labels of the given accounts.
+func loadLabels(db *gorm.DB, aa []Account) error {
+	if len(aa) == 0 {
+		return nil
+	}
+
+	addresses := make([]string, len(aa))
+	for i := range aa {
+		addresses[i] = aa[i].Address
+	}
+
+	var ll []AccountLabel
+	err := db.
+		Where("account_address IN ?", addresses).
+		Order("label asc").
+		Find(&ll).Error
+	if err != nil {
+		return err
+	}
+
+	labels := make(map[string][]string, len(aa))
+	for _, l := range ll {
+		labels[l.AccountAddress] = append(labels[l.AccountAddress], l.Label)
+	}
+
+	for i := range aa {
+		aa[i].Labels = labels[aa[i].Address]
+	}
+
+	return nil
 }
@@ -20,6 +20,7 @@ func AddContract(
 	km keys.Manager,
 	accountAddress string,
 	contract flow_templates.Contract,
+	gasLimit uint64,
 	transactionTimeout time.Duration) error {
 
 	// Get admin account authorizer
@@ -44,7 +45,7 @@ func AddContract(
 		SetReferenceBlockID(*referenceBlockID).
 		SetProposalKey(proposer.Address, proposer.Key.Index, proposer.Key.SequenceNumber).
 		SetPayer(payer.Address).
-		SetGasLimit(maxGasLimit).
+		SetGasLimit(gasLimit).
 		SetScript([]byte(template_strings.AddAccountContractWithAdmin)).
 		AddAuthorizer(payer.Address)
 
@@ -0,0 +1,159 @@
+package accounts
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/templates"
+	"github.com/onflow/cadence/runtime/parser2"
+)
+
+// New accounts always have a FlowToken vault.
+const flowTokenName = "FlowToken"
+
+// tokenSetup is the setup transaction of a token split into its imports and
+// the parameter and block of its prepare function.
+type tokenSetup struct {
+	tokenName string
+	imports   []string
+	signer    string
+	block     string
+}
+
+// parseTokenSetup splits the setup code of a token so that it can be run as
+// a part of the account creation transaction. Only transactions that consist
+// of a single prepare function with one parameter can be composed.
+func parseTokenSetup(token *templates.Token) (tokenSetup, error) {
+	setup := tokenSetup{tokenName: token.Name}
+
+	program, err := parser2.ParseProgram(token.Setup, nil)
+	if err != nil {
+		return setup, tokenSetupError(token.Name, err)
+	}
+
+	for _, d := range program.ImportDeclarations() {
+		setup.imports = append(setup.imports, token.Setup[d.StartPos.Offset:d.EndPos.Offset+1])
+	}
+
+	txs := program.TransactionDeclarations()
+	if len(txs) != 1 || len(program.Declarations()) != len(txs)+len(setup.imports) {
+		return setup, tokenSetupError(token.Name, fmt.Errorf("expected only imports and a transaction"))
+	}
+
+	tx := txs[0]
+
+	if tx.ParameterList != nil && len(tx.ParameterList.Parameters) > 0 {
+		return setup, tokenSetupError(token.Name, fmt.Errorf("transaction parameters are not supported"))
+	}
+
+	if len(tx.Fields) > 0 || tx.PreConditions != nil || tx.PostConditions != nil || tx.Execute != nil {
+		return setup, tokenSetupError(token.Name, fmt.Errorf("only a prepare function is supported"))
+	}
+
+	if tx.Prepare == nil {
+		return setup, tokenSetupError(token.Name, fmt.Errorf("missing prepare function"))
+	}
+
+	prepare := tx.Prepare.FunctionDeclaration
+
+	if prepare.ParameterList == nil || len(prepare.ParameterList.Parameters) != 1 {
+		return setup, tokenSetupError(token.Name, fmt.Errorf("prepare function must have exactly one parameter"))
+	}
+
+	if prepare.FunctionBlock == nil || prepare.FunctionBlock.Block == nil ||
+		prepare.FunctionBlock.PreConditions != nil || prepare.FunctionBlock.PostConditions != nil {
+		return setup, tokenSetupError(token.Name, fmt.Errorf("prepare function conditions are not supported"))
+	}
+
+	block := prepare.FunctionBlock.Block
+
+	setup.signer = prepare.ParameterList.Parameters[0].Identifier.Identifier
+	setup.block = token.Setup[block.StartPos.Offset : block.EndPos.Offset+1]
+
+	return setup, nil
+}
+
+// createAccountWithTokensCode returns a transaction that creates an account
+// like the default account creation template and runs the token setups for
+// the new account. Each setup is declared as a nested function, so that
+// declarations and early returns in one do not affect the others.
+func createAccountWithTokensCode(setups []tokenSetup) string {
+	var b strings.Builder
+
+	imported := map[string]bool{"import Crypto": true}
+	b.WriteString("import Crypto\n")
+	for _, s := range setups {
+		for _, i := range s.imports {
+			if !imported[i] {
+				imported[i] = true
+				b.WriteString(i + "\n")
+			}
+		}
+	}
+
+	b.WriteString(`
+transaction(publicKeys: [Crypto.KeyListEntry], contracts: {String: String}) {
+	prepare(signer: AuthAccount) {
+		let account = AuthAccount(payer: signer)
+
+		// add all the keys to the account
+		for key in publicKeys {
+			account.keys.add(publicKey: key.publicKey, hashAlgorithm: key.hashAlgorithm, weight: key.weight)
+		}
+
+		// add contracts if provided
+		for contract in contracts.keys {
+			account.contracts.add(name: contract, code: contracts[contract]!.decodeHex())
+		}
+`)
+
+	for i, s := range setups {
+		fmt.Fprintf(&b, "\n\t\t// set up %s\n", s.tokenName)
+		fmt.Fprintf(&b, "\t\tfun setupToken%d(_ %s: AuthAccount) %s\n", i, s.signer, s.block)
+		fmt.Fprintf(&b, "\t\tsetupToken%d(account)\n", i)
+	}
+
+	b.WriteString("\t}\n}\n")
+
+	return b.String()
+}
+
+// tokenSetups resolves the names of enabled tokens and parses their setup
+// code. FlowToken and duplicates are skipped.
+func (s *ServiceImpl) tokenSetups(tokenNames []string) ([]tokenSetup, error) {
+	setups := []tokenSetup{}
+	seen := map[string]bool{}
+
+	for _, name := range tokenNames {
+		token, err := s.templates.GetTokenByName(name)
+		if err != nil {
+			if strings.Contains(err.Error(), "record not found") {
+				return nil, tokenSetupError(name, fmt.Errorf("token not enabled"))
+			}
+			return nil, err
+		}
+
+		if token.Name == flowTokenName || seen[token.Name] {
+			continue
+		}
+		seen[token.Name] = true
+
+		setup, err := parseTokenSetup(token)
+		if err != nil {
+			return nil, err
+		}
+
+		setups = append(setups, setup)
+	}
+
+	return setups, nil
+}
+
+func tokenSetupError(tokenName string, err error) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf("cannot set up %s: %w", tokenName, err),
+	}
+}
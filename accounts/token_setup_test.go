@@ -0,0 +1,74 @@
+package accounts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/templates"
+	"github.com/onflow/cadence/runtime/parser2"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestCreateAccountWithTokensCode(t *testing.T) {
+	fusd := &templates.Token{Name: "FUSD", Address: "0xf8d6e0586b0a20c7", NameLowerCase: "fusd"}
+	fusd.Setup = templates.FungibleSetupCode(flow.Emulator, fusd)
+
+	other := &templates.Token{Name: "Other", Address: "0x01cf0e2f2f715450", NameLowerCase: "other"}
+	other.Setup = templates.FungibleSetupCode(flow.Emulator, other)
+
+	var setups []tokenSetup
+	for _, token := range []*templates.Token{fusd, other} {
+		setup, err := parseTokenSetup(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		setups = append(setups, setup)
+	}
+
+	if setups[0].signer != "signer" {
+		t.Fatalf("expected signer parameter, got %q", setups[0].signer)
+	}
+
+	if !strings.HasPrefix(setups[0].block, "{") || !strings.HasSuffix(setups[0].block, "}") {
+		t.Fatalf("expected the prepare block, got %q", setups[0].block)
+	}
+
+	code := createAccountWithTokensCode(setups)
+
+	program, err := parser2.ParseProgram(code, nil)
+	if err != nil {
+		t.Fatalf("expected composed code to parse, got %s\n%s", err, code)
+	}
+
+	// Crypto, FungibleToken, FUSD and Other
+	if n := len(program.ImportDeclarations()); n != 4 {
+		t.Fatalf("expected shared imports to be declared once, got %d imports\n%s", n, code)
+	}
+
+	for _, s := range []string{"setupToken0(account)", "setupToken1(account)", "/storage/fusdVault", "/storage/otherVault"} {
+		if !strings.Contains(code, s) {
+			t.Fatalf("expected composed code to contain %q\n%s", s, code)
+		}
+	}
+}
+
+func TestParseTokenSetupUnsupported(t *testing.T) {
+	testCases := []struct {
+		name string
+		code string
+	}{
+		{name: "invalid code", code: "transaction {"},
+		{name: "parameters", code: "transaction(amount: UFix64) { prepare(signer: AuthAccount) {} }"},
+		{name: "execute", code: "transaction { prepare(signer: AuthAccount) {} execute {} }"},
+		{name: "multiple signers", code: "transaction { prepare(a: AuthAccount, b: AuthAccount) {} }"},
+		{name: "other declarations", code: "pub fun f() {}\ntransaction { prepare(signer: AuthAccount) {} }"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := parseTokenSetup(&templates.Token{Name: "Test", Setup: tc.code}); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -42,20 +42,31 @@ type Config struct {
 	// KMS key types:
 	// - aws_kms
 	// - google_kms
+	// - vault_transit
+	// - pkcs11
+	// Other key types can be added with keys.RegisterBackend.
 	DefaultKeyType  string `env:"DEFAULT_KEY_TYPE" envDefault:"local"`
 	DefaultKeyIndex int    `env:"DEFAULT_KEY_INDEX" envDefault:"0"`
 	// If the default of "-1" is used for "DefaultKeyWeight"
 	// the service will use flow.AccountKeyWeightThreshold from the Flow SDK.
 	DefaultKeyWeight int    `env:"DEFAULT_KEY_WEIGHT" envDefault:"-1"`
 	DefaultSignAlgo  string `env:"DEFAULT_SIGN_ALGO" envDefault:"ECDSA_P256"`
-	DefaultHashAlgo  string `env:"DEFAULT_HASH_ALGO" envDefault:"SHA3_256"`
+	// If "DefaultHashAlgo" is not set the hash algorithm depends on the key
+	// type, SHA2_256 for google_kms and SHA3_256 for the others.
+	DefaultHashAlgo string `env:"DEFAULT_HASH_ALGO"`
+	// Key backend and algorithm combinations that account creation requests
+	// may choose, in "type:signAlgo:hashAlgo" form, e.g.
+	// "local:ECDSA_P256:SHA3_256,google_kms:ECDSA_P256:SHA2_256".
+	// Only the default combination is allowed if empty.
+	AllowedKeySpecs []string `env:"ALLOWED_KEY_SPECS" envSeparator:","`
 	// This symmetrical key is used to encrypt private keys
 	// that are stored in the database. Values per type:
 	// - local: 32 bytes long encryption key
 	// - aws_kms: key ARN, e.g. arn:aws:kms:us-west-1:123456789000:key/00000000-1111-2222-3333-444444444444
 	// - google_kms: key resource name (without version info), e.g. projects/my-project/locations/europe-north1/keyRings/my-keyring/cryptoKeys/my-encryption-key
+	// - vault_transit: transit key as <mount>/<key name>, e.g. transit/my-encryption-key
 	EncryptionKey string `env:"ENCRYPTION_KEY,notEmpty"`
-	// Encryption key type, one of: local, aws_kms, google_kms
+	// Encryption key type, one of: local, aws_kms, google_kms, vault_transit
 	EncryptionKeyType string `env:"ENCRYPTION_KEY_TYPE,notEmpty" envDefault:"local"`
 	// DefaultAccountKeyCount specifies how many times the account key will be duplicated upon account creation, does not affect existing accounts
 	DefaultAccountKeyCount uint `env:"DEFAULT_ACCOUNT_KEY_COUNT" envDefault:"1"`
@@ -79,6 +90,26 @@ type Config struct {
 	EnabledTokens           []string `env:"ENABLED_TOKENS" envSeparator:","`
 	ScriptPathCreateAccount string   `env:"SCRIPT_PATH_CREATE_ACCOUNT" envDefault:""`
 
+	// -- Initial funding of new accounts --
+
+	// Amount of InitialFundingToken transferred to new custodial accounts
+	// when the create request does not specify any, no funding if empty.
+	InitialFundingAmount string `env:"INITIAL_FUNDING_AMOUNT" envDefault:""`
+	InitialFundingToken  string `env:"INITIAL_FUNDING_TOKEN" envDefault:"FlowToken"`
+	// Custodial account that funds new accounts, defaults to the admin account.
+	InitialFundingAccount string `env:"INITIAL_FUNDING_ACCOUNT" envDefault:""`
+
+	// -- Account pool --
+
+	// Number of unassigned custodial accounts kept pre-created, so that
+	// accounts can be handed out without waiting for a transaction.
+	// The pool is disabled when set to 0.
+	AccountPoolSize uint `env:"ACCOUNT_POOL_SIZE" envDefault:"0"`
+	// The pool is refilled when it holds this many accounts or fewer.
+	AccountPoolLowWaterMark uint `env:"ACCOUNT_POOL_LOW_WATER_MARK" envDefault:"5"`
+	// Maximum number of pool accounts created concurrently.
+	AccountPoolRefillConcurrency uint `env:"ACCOUNT_POOL_REFILL_CONCURRENCY" envDefault:"1"`
+
 	// -- Workerpool --
 
 	// Defines the maximum number of active jobs that can be queued before
@@ -101,6 +132,27 @@ type Config struct {
 	GoogleKMSLocationID string `env:"GOOGLE_KMS_LOCATION_ID"`
 	GoogleKMSKeyRingID  string `env:"GOOGLE_KMS_KEYRING_ID"`
 
+	// -- HashiCorp Vault --
+
+	// Transit mount where vault_transit account keys are created. The Vault
+	// client is configured with the standard VAULT_ADDR, VAULT_TOKEN and
+	// VAULT_NAMESPACE environment variables.
+	VaultTransitMount string `env:"VAULT_TRANSIT_MOUNT" envDefault:"transit"`
+
+	// -- PKCS#11 --
+
+	// Path of the PKCS#11 module (shared library) of the HSM used for pkcs11
+	// keys, e.g. /usr/lib/softhsm/libsofthsm2.so
+	PKCS11ModulePath string `env:"PKCS11_MODULE_PATH"`
+	// ID of the slot holding the token. Ignored if PKCS11TokenLabel is set.
+	PKCS11Slot uint `env:"PKCS11_SLOT" envDefault:"0"`
+	// Label of the token, selects the slot by the token label instead of ID.
+	PKCS11TokenLabel string `env:"PKCS11_TOKEN_LABEL"`
+	// User PIN of the token. Alternatively PKCS11PINFile can point to a file
+	// containing the PIN, e.g. a Docker secret.
+	PKCS11PIN     string `env:"PKCS11_PIN"`
+	PKCS11PINFile string `env:"PKCS11_PIN_FILE"`
+
 	// -- Misc --
 
 	// Duration for which to wait for a transaction seal, if 0 wait indefinitely. Default: 0.
@@ -131,6 +183,12 @@ type Config struct {
 	// Max transactions per second, rate at which the service can submit transactions to Flow
 	TransactionMaxSendRate int `env:"MAX_TPS" envDefault:"10"`
 
+	// Maximum gas limit a transaction may request
+	TransactionMaxGasLimit uint64 `env:"MAX_GAS_LIMIT" envDefault:"9999"`
+
+	// Maximum number of transactions in a single batch request
+	TransactionMaxBatchSize int `env:"MAX_BATCH_SIZE" envDefault:"1000"`
+
 	// maxJobErrorCount is the maximum number of times a Job can be tried to
 	// execute before considering it completely failed.
 	MaxJobErrorCount int `env:"MAX_JOB_ERROR_COUNT" envDefault:"10"`
@@ -148,6 +206,9 @@ type Config struct {
 	// restart (such as NO_AVAILABLE_WORKERS or ERROR).
 	ReSchedulableGracePeriod time.Duration `env:"RESCHEDULABLE_GRACE_PERIOD" envDefault:"60s"`
 
+	// Run reconcilers (such as polling the status of unsealed transactions) every 30s.
+	ReconcileInterval time.Duration `env:"RECONCILE_INTERVAL" envDefault:"30s"`
+
 	// Sleep duration in case of service isHalted
 	PauseDuration time.Duration `env:"PAUSE_DURATION" envDefault:"60s"`
 
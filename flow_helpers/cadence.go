@@ -0,0 +1,87 @@
+package flow_helpers
+
+import (
+	"strconv"
+
+	"github.com/onflow/cadence"
+)
+
+// CadenceValueToJSON converts a Cadence value to a plain value that can be
+// marshalled to JSON. Fixed point numbers are converted to strings to avoid
+// losing precision, addresses to hex strings and composite values (structs,
+// resources, events etc.) to objects keyed by field name.
+func CadenceValueToJSON(value cadence.Value) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case cadence.UFix64, cadence.Fix64:
+		return v.String()
+	case cadence.Address:
+		return FormatAddress([8]byte(v))
+	case cadence.Optional:
+		return CadenceValueToJSON(v.Value)
+	case cadence.Array:
+		values := make([]interface{}, len(v.Values))
+		for i, e := range v.Values {
+			values[i] = CadenceValueToJSON(e)
+		}
+		return values
+	case cadence.Dictionary:
+		m := make(map[string]interface{}, len(v.Pairs))
+		for _, p := range v.Pairs {
+			key := CadenceValueToJSON(p.Key)
+			if s, ok := key.(string); ok {
+				m[s] = CadenceValueToJSON(p.Value)
+			} else {
+				m[p.Key.String()] = CadenceValueToJSON(p.Value)
+			}
+		}
+		return m
+	case cadence.Struct:
+		if v.StructType != nil {
+			return CompositeFieldsToJSON(v.StructType.Fields, v.Fields)
+		}
+		return CompositeFieldsToJSON(nil, v.Fields)
+	case cadence.Resource:
+		if v.ResourceType != nil {
+			return CompositeFieldsToJSON(v.ResourceType.Fields, v.Fields)
+		}
+		return CompositeFieldsToJSON(nil, v.Fields)
+	case cadence.Event:
+		if v.EventType != nil {
+			return CompositeFieldsToJSON(v.EventType.Fields, v.Fields)
+		}
+		return CompositeFieldsToJSON(nil, v.Fields)
+	case cadence.Contract:
+		if v.ContractType != nil {
+			return CompositeFieldsToJSON(v.ContractType.Fields, v.Fields)
+		}
+		return CompositeFieldsToJSON(nil, v.Fields)
+	case cadence.Enum:
+		if v.EnumType != nil {
+			return CompositeFieldsToJSON(v.EnumType.Fields, v.Fields)
+		}
+		return CompositeFieldsToJSON(nil, v.Fields)
+	case cadence.Path, cadence.TypeValue, cadence.Capability:
+		return v.String()
+	}
+
+	return value.ToGoValue()
+}
+
+// CompositeFieldsToJSON converts the fields of a composite value to plain
+// values keyed by field name. Fields without a known name are keyed by their
+// position.
+func CompositeFieldsToJSON(types []cadence.Field, fields []cadence.Value) map[string]interface{} {
+	m := make(map[string]interface{}, len(fields))
+
+	for i, f := range fields {
+		name := strconv.Itoa(i)
+		if i < len(types) && types[i].Identifier != "" {
+			name = types[i].Identifier
+		}
+		m[name] = CadenceValueToJSON(f)
+	}
+
+	return m
+}
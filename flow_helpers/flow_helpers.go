@@ -17,17 +17,24 @@ import (
 
 type FlowClient interface {
 	ExecuteScriptAtLatestBlock(ctx context.Context, script []byte, arguments []cadence.Value) (cadence.Value, error)
+	ExecuteScriptAtBlockHeight(ctx context.Context, height uint64, script []byte, arguments []cadence.Value) (cadence.Value, error)
+	ExecuteScriptAtBlockID(ctx context.Context, blockID flow.Identifier, script []byte, arguments []cadence.Value) (cadence.Value, error)
 	GetAccount(ctx context.Context, address flow.Address) (*flow.Account, error)
 	GetAccountAtLatestBlock(ctx context.Context, address flow.Address) (*flow.Account, error)
 	GetTransaction(ctx context.Context, txID flow.Identifier) (*flow.Transaction, error)
 	GetTransactionResult(ctx context.Context, txID flow.Identifier) (*flow.TransactionResult, error)
 	GetLatestBlockHeader(ctx context.Context, isSealed bool) (*flow.BlockHeader, error)
+	GetBlockHeaderByID(ctx context.Context, blockID flow.Identifier) (*flow.BlockHeader, error)
 	GetEventsForHeightRange(ctx context.Context, eventType string, startHeight uint64, endHeight uint64) ([]flow.BlockEvents, error)
 	SendTransaction(ctx context.Context, tx flow.Transaction) error
 }
 
 const hexPrefix = "0x"
 
+// TransactionExpiry is the number of blocks after which the network considers
+// a transaction expired, counted from its reference block.
+const TransactionExpiry = 600
+
 // LatestBlockId retuns the flow.Identifier for the latest block in the chain.
 func LatestBlockId(ctx context.Context, flowClient FlowClient) (*flow.Identifier, error) {
 	block, err := flowClient.GetLatestBlockHeader(ctx, false)
@@ -116,17 +123,22 @@ func ValidateAddress(address string, chainId flow.ChainID) (string, error) {
 	return FormatAddress(flowAddress), nil
 }
 
+func ValidateBlockId(id string) error {
+	return validateId(id, "block")
+}
+
 func ValidateTransactionId(id string) error {
-	invalidErr := &errors.RequestError{
-		StatusCode: http.StatusBadRequest,
-		Err:        fmt.Errorf(`not a valid transaction id: "%s"`, id),
-	}
+	return validateId(id, "transaction")
+}
+
+// validateId checks that id is a hex encoded 32 byte Flow identifier.
+func validateId(id, kind string) error {
 	b, err := hex.DecodeString(id)
-	if err != nil {
-		return invalidErr
-	}
-	if id != flow.BytesToID(b).Hex() {
-		return invalidErr
+	if err != nil || id != flow.BytesToID(b).Hex() {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf(`not a valid %s id: "%s"`, kind, id),
+		}
 	}
 	return nil
 }
@@ -2,12 +2,14 @@ package flow_helpers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers/internal"
+	"github.com/onflow/cadence"
 	"github.com/onflow/flow-go-sdk"
 )
 
@@ -56,3 +58,32 @@ func TestWaitForSeal(t *testing.T) {
 		}
 	})
 }
+
+func TestCadenceValueToJSON(t *testing.T) {
+	value := cadence.NewStruct([]cadence.Value{
+		cadence.NewArray([]cadence.Value{cadence.NewUInt64(1), cadence.NewUInt64(2)}),
+		cadence.NewDictionary([]cadence.KeyValuePair{
+			{Key: cadence.String("a"), Value: cadence.Fix64(-100000000)},
+		}),
+		cadence.NewOptional(nil),
+		cadence.String("hello"),
+	}).WithType(&cadence.StructType{
+		QualifiedIdentifier: "Foo.Bar",
+		Fields: []cadence.Field{
+			{Identifier: "ids", Type: cadence.VariableSizedArrayType{ElementType: cadence.UInt64Type{}}},
+			{Identifier: "amounts", Type: cadence.DictionaryType{KeyType: cadence.StringType{}, ElementType: cadence.Fix64Type{}}},
+			{Identifier: "maybe", Type: cadence.OptionalType{Type: cadence.StringType{}}},
+		},
+	})
+
+	b, err := json.Marshal(CadenceValueToJSON(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fields without a known type are keyed by their position
+	expected := `{"3":"hello","amounts":{"a":"-1.00000000"},"ids":[1,2],"maybe":null}`
+	if string(b) != expected {
+		t.Fatalf("expected %s, got %s", expected, b)
+	}
+}
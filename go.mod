@@ -17,6 +17,7 @@ require (
 	github.com/gorilla/mux v1.8.0
 	github.com/jpillora/backoff v1.0.0
 	github.com/lib/pq v1.10.4
+	github.com/miekg/pkcs11 v1.1.1
 	github.com/onflow/cadence v0.24.0
 	github.com/onflow/flow-go-sdk v0.26.0
 	github.com/sirupsen/logrus v1.8.1
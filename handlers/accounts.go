@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/flow-hydraulics/flow-wallet-api/accounts"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
 	"github.com/onflow/flow-go-sdk"
 )
 
@@ -16,7 +17,51 @@ type Accounts struct {
 
 // SyncKeyCountRequest represents a JSON payload for a HTTP request
 type SyncKeyCountRequest struct {
+	Address           flow.Address `json:"address"`
+	RevokeUnknownKeys bool         `json:"revokeUnknownKeys"`
+}
+
+// RotateAccountKeysRequest represents a JSON payload for a HTTP request
+type RotateAccountKeysRequest struct {
 	Address flow.Address `json:"address"`
+	// Key overrides the type, weight and algorithms of the new keys, unset
+	// fields keep those of the replaced keys.
+	Key keys.KeySpec `json:"key,omitempty"`
+}
+
+// CreateAccountRequest represents an optional JSON payload for creating an
+// account.
+type CreateAccountRequest struct {
+	accounts.Metadata
+	// Tokens lists the enabled tokens to set up vaults for when creating the account.
+	Tokens []string `json:"tokens,omitempty"`
+	// InitialFunding overrides the configured funding of the account.
+	InitialFunding *accounts.Funding `json:"initialFunding,omitempty"`
+	// Keys creates a multi-key account with a key for each spec.
+	Keys []keys.KeySpec `json:"keys,omitempty"`
+	// RecoveryKeys are externally held public keys added to the account.
+	RecoveryKeys []accounts.RecoveryKey `json:"recoveryKeys,omitempty"`
+}
+
+// AddNonCustodialAccountRequest represents a JSON payload for adding an
+// account to the watchlist.
+type AddNonCustodialAccountRequest struct {
+	Address string `json:"address"`
+	accounts.Metadata
+}
+
+// SetAccountStateRequest represents a JSON payload for changing the state of
+// an account.
+type SetAccountStateRequest struct {
+	State accounts.AccountState `json:"state"`
+}
+
+// ImportAccountRequest represents a JSON payload for importing an existing
+// account into custody.
+type ImportAccountRequest struct {
+	Address string                 `json:"address"`
+	Keys    []accounts.ImportedKey `json:"keys"`
+	accounts.Metadata
 }
 
 // NewAccounts initiates a new accounts server.
@@ -36,6 +81,10 @@ func (s *Accounts) AddNonCustodialAccount() http.Handler {
 	return http.HandlerFunc(s.AddNonCustodialAccountFunc)
 }
 
+func (s *Accounts) ImportAccount() http.Handler {
+	return http.HandlerFunc(s.ImportAccountFunc)
+}
+
 func (s *Accounts) DeleteNonCustodialAccount() http.Handler {
 	return http.HandlerFunc(s.DeleteNonCustodialAccountFunc)
 }
@@ -44,6 +93,30 @@ func (s *Accounts) SyncAccountKeyCount() http.Handler {
 	return http.HandlerFunc(s.SyncAccountKeyCountFunc)
 }
 
+func (s *Accounts) KeyDriftReport() http.Handler {
+	return http.HandlerFunc(s.KeyDriftReportFunc)
+}
+
+func (s *Accounts) RotateAccountKeys() http.Handler {
+	return http.HandlerFunc(s.RotateAccountKeysFunc)
+}
+
 func (s *Accounts) Details() http.Handler {
 	return http.HandlerFunc(s.DetailsFunc)
 }
+
+func (s *Accounts) UpdateMetadata() http.Handler {
+	return http.HandlerFunc(s.UpdateMetadataFunc)
+}
+
+func (s *Accounts) SetState() http.Handler {
+	return http.HandlerFunc(s.SetStateFunc)
+}
+
+func (s *Accounts) AddRecoveryKey() http.Handler {
+	return http.HandlerFunc(s.AddRecoveryKeyFunc)
+}
+
+func (s *Accounts) Export() http.Handler {
+	return http.HandlerFunc(s.ExportFunc)
+}
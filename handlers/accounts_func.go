@@ -3,12 +3,15 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/flow-hydraulics/flow-wallet-api/accounts"
 	"github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/gorilla/mux"
+	"github.com/onflow/flow-go-sdk"
 )
 
 // List returns all accounts.
@@ -23,7 +26,17 @@ func (s *Accounts) ListFunc(rw http.ResponseWriter, r *http.Request) {
 		offset = 0
 	}
 
-	res, err := s.service.List(limit, offset)
+	filter := accounts.AccountFilter{
+		ExternalID: r.FormValue("externalId"),
+		State:      accounts.AccountState(r.FormValue("state")),
+	}
+
+	// Labels may be repeated or comma separated
+	for _, l := range r.Form["label"] {
+		filter.Labels = append(filter.Labels, strings.Split(l, ",")...)
+	}
+
+	res, err := s.service.List(limit, offset, filter)
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -34,12 +47,42 @@ func (s *Accounts) ListFunc(rw http.ResponseWriter, r *http.Request) {
 }
 
 // Create creates a new account asynchronously.
+// The optional body sets the metadata of the account, the tokens to set up
+// for it and its initial funding.
 // It returns a Job JSON representation.
 func (s *Accounts) CreateFunc(rw http.ResponseWriter, r *http.Request) {
 	// Decide whether to serve sync or async, default async
 	sync := r.FormValue(SyncQueryParameter) != ""
 
-	job, acc, err := s.service.Create(r.Context(), sync)
+	var req CreateAccountRequest
+
+	if checkNonEmptyBody(r) == nil {
+		// Try to decode the request body, an empty body is allowed
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil && err != io.EOF {
+			handleError(rw, r, InvalidBodyError)
+			return
+		}
+	}
+
+	opts := []accounts.AccountOption{
+		accounts.WithMetadata(req.Metadata),
+		accounts.WithTokens(req.Tokens...),
+	}
+
+	if len(req.Keys) > 0 {
+		opts = append(opts, accounts.WithKeys(req.Keys...))
+	}
+
+	if len(req.RecoveryKeys) > 0 {
+		opts = append(opts, accounts.WithRecoveryKeys(req.RecoveryKeys...))
+	}
+
+	if req.InitialFunding != nil {
+		opts = append(opts, accounts.WithInitialFunding(req.InitialFunding.TokenName, req.InitialFunding.Amount))
+	}
+
+	job, acc, err := s.service.Create(r.Context(), sync, opts...)
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -72,6 +115,58 @@ func (s *Accounts) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
 	handleJsonResponse(rw, http.StatusOK, res)
 }
 
+// UpdateMetadata updates the external ID, labels and attributes of an
+// account. Fields missing from the body are left unchanged.
+func (s *Accounts) UpdateMetadataFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var metadata accounts.Metadata
+
+	// Try to decode the request body.
+	if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	res, err := s.service.UpdateMetadata(vars["address"], metadata)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+func (s *Accounts) SetStateFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var req SetAccountStateRequest
+
+	// Try to decode the request body.
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	res, err := s.service.SetState(vars["address"], req.State)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
 func (s *Accounts) AddNonCustodialAccountFunc(rw http.ResponseWriter, r *http.Request) {
 	err := checkNonEmptyBody(r)
 	if err != nil {
@@ -79,7 +174,36 @@ func (s *Accounts) AddNonCustodialAccountFunc(rw http.ResponseWriter, r *http.Re
 		return
 	}
 
-	var b accounts.Account
+	var b AddNonCustodialAccountRequest
+
+	// Try to decode the request body into the struct.
+	err = json.NewDecoder(r.Body).Decode(&b)
+	if err != nil {
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid body"),
+		}
+		handleError(rw, r, err)
+		return
+	}
+
+	a, err := s.service.AddNonCustodialAccount(b.Address, accounts.WithMetadata(b.Metadata))
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, a)
+}
+
+func (s *Accounts) ImportAccountFunc(rw http.ResponseWriter, r *http.Request) {
+	err := checkNonEmptyBody(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var b ImportAccountRequest
 
 	// Try to decode the request body into the struct.
 	err = json.NewDecoder(r.Body).Decode(&b)
@@ -92,9 +216,10 @@ func (s *Accounts) AddNonCustodialAccountFunc(rw http.ResponseWriter, r *http.Re
 		return
 	}
 
-	a, err := s.service.AddNonCustodialAccount(b.Address)
+	a, err := s.service.ImportAccount(r.Context(), b.Address, b.Keys, accounts.WithMetadata(b.Metadata))
 	if err != nil {
 		handleError(rw, r, err)
+		return
 	}
 
 	handleJsonResponse(rw, http.StatusCreated, a)
@@ -127,7 +252,7 @@ func (s *Accounts) SyncAccountKeyCountFunc(rw http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	job, err := s.service.SyncAccountKeyCount(r.Context(), req.Address)
+	job, err := s.service.SyncAccountKeyCount(r.Context(), req.Address, req.RevokeUnknownKeys)
 	if err != nil {
 		handleError(rw, r, err)
 		return
@@ -135,3 +260,77 @@ func (s *Accounts) SyncAccountKeyCountFunc(rw http.ResponseWriter, r *http.Reque
 
 	handleJsonResponse(rw, http.StatusOK, job)
 }
+
+func (s *Accounts) KeyDriftReportFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	report, err := s.service.KeyDriftReport(vars["jobId"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, report)
+}
+
+func (s *Accounts) RotateAccountKeysFunc(rw http.ResponseWriter, r *http.Request) {
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var req RotateAccountKeysRequest
+	// Try to decode the request body.
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		err = &errors.RequestError{StatusCode: http.StatusBadRequest, Err: fmt.Errorf("invalid body")}
+		handleError(rw, r, err)
+		return
+	}
+
+	job, err := s.service.RotateAccountKeys(r.Context(), req.Address, req.Key)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	// The job attributes hold the new key, only respond with the job details
+	handleJsonResponse(rw, http.StatusOK, job.ToJSONResponse())
+}
+
+func (s *Accounts) AddRecoveryKeyFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var req accounts.RecoveryKey
+
+	// Try to decode the request body.
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	job, err := s.service.AddRecoveryKey(r.Context(), flow.HexToAddress(vars["address"]), req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, job.ToJSONResponse())
+}
+
+func (s *Accounts) ExportFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := s.service.ExportAccount(r.Context(), flow.HexToAddress(vars["address"]))
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, job.ToJSONResponse())
+}
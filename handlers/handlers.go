@@ -3,6 +3,7 @@ package handlers
 
 import (
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -13,10 +14,22 @@ import (
 
 	"github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/flow-hydraulics/flow-wallet-api/handlers/middleware"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
 )
 
 const SyncQueryParameter = "sync"
 
+// DecodeEventsQueryParameter enables decoded, field-named event payloads in
+// transaction responses.
+const DecodeEventsQueryParameter = "decodeEvents"
+
+// BlockHeightQueryParameter and BlockIdQueryParameter select the block at
+// which scripts, such as balance queries, are executed.
+const (
+	BlockHeightQueryParameter = "blockHeight"
+	BlockIdQueryParameter     = "blockId"
+)
+
 var EmptyBodyError = &errors.RequestError{StatusCode: http.StatusBadRequest, Err: fmt.Errorf("empty body")}
 var InvalidBodyError = &errors.RequestError{StatusCode: http.StatusBadRequest, Err: fmt.Errorf("invalid body")}
 
@@ -47,9 +60,9 @@ func handleError(rw http.ResponseWriter, r *http.Request, err error) {
 		WithFields(log.Fields{"error": err}).
 		Warn("Error while handling request")
 
-		// Check if the error was an errors.RequestError
-	reqErr, isReqErr := err.(*errors.RequestError)
-	if isReqErr {
+	// Check if the error was, or wraps, an errors.RequestError
+	var reqErr *errors.RequestError
+	if stderrors.As(err, &reqErr) {
 		http.Error(rw, reqErr.Error(), reqErr.StatusCode)
 		return
 	}
@@ -70,6 +83,15 @@ func handleJsonResponse(rw http.ResponseWriter, status int, res interface{}) {
 	json.NewEncoder(rw).Encode(res) // nolint
 }
 
+// transactionResponse returns the JSON response for a transaction, with
+// decoded event payloads if requested.
+func transactionResponse(r *http.Request, t *transactions.Transaction) interface{} {
+	if r.FormValue(DecodeEventsQueryParameter) != "" {
+		return t.ToDecodedJSONResponse()
+	}
+	return t.ToJSONResponse()
+}
+
 func checkNonEmptyBody(r *http.Request) error {
 	if r.Body == nil || r.Body == http.NoBody {
 		return EmptyBodyError
@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/flow-hydraulics/flow-wallet-api/templates"
 	"github.com/flow-hydraulics/flow-wallet-api/tokens"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
 	"github.com/gorilla/mux"
 )
 
@@ -27,7 +29,7 @@ func (s *Tokens) SetupFunc(rw http.ResponseWriter, r *http.Request) {
 
 	var res interface{}
 	if sync {
-		res = transaction.ToJSONResponse()
+		res = transactionResponse(r, transaction)
 	} else {
 		res = job.ToJSONResponse()
 	}
@@ -56,7 +58,25 @@ func (s *Tokens) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
 	address := vars["address"]
 	tokenName := vars["tokenName"]
 
-	res, err := s.service.Details(r.Context(), tokenName, address)
+	// Read the balance at a specific block if requested, e.g. for reconciliation
+	var opts []transactions.ScriptOption
+	if v := r.FormValue(BlockHeightQueryParameter); v != "" {
+		height, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			err = &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid block height: %q", v),
+			}
+			handleError(rw, r, err)
+			return
+		}
+		opts = append(opts, transactions.WithBlockHeight(height))
+	}
+	if v := r.FormValue(BlockIdQueryParameter); v != "" {
+		opts = append(opts, transactions.WithBlockID(v))
+	}
+
+	res, err := s.service.Details(r.Context(), tokenName, address, opts...)
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -99,7 +119,7 @@ func (s *Tokens) CreateWithdrawalFunc(rw http.ResponseWriter, r *http.Request) {
 
 	var res interface{}
 	if sync {
-		res = transaction.ToJSONResponse()
+		res = transactionResponse(r, transaction)
 	} else {
 		res = job.ToJSONResponse()
 	}
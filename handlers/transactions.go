@@ -29,6 +29,20 @@ func (s *Transactions) Sign() http.Handler {
 	return UseJson(h)
 }
 
+func (s *Transactions) Submit() http.Handler {
+	h := http.HandlerFunc(s.SubmitFunc)
+	return UseJson(h)
+}
+
+func (s *Transactions) CreateBatch() http.Handler {
+	h := http.HandlerFunc(s.CreateBatchFunc)
+	return UseJson(h)
+}
+
+func (s *Transactions) Fees() http.Handler {
+	return http.HandlerFunc(s.FeesFunc)
+}
+
 func (s *Transactions) Details() http.Handler {
 	return http.HandlerFunc(s.DetailsFunc)
 }
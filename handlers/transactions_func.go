@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/flow-hydraulics/flow-wallet-api/transactions"
@@ -27,16 +28,29 @@ func (s *Transactions) ListFunc(rw http.ResponseWriter, r *http.Request) {
 		offset = 0
 	}
 
+	var status transactions.Status
+	if v := r.FormValue("status"); v != "" {
+		status, err = transactions.ParseStatus(v)
+		if err != nil {
+			err = &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        err,
+			}
+			handleError(rw, r, err)
+			return
+		}
+	}
+
 	vars := mux.Vars(r)
 
 	if address, ok := vars["address"]; ok {
 		// Handle account specific transactions
 		// This endpoint is used to handle "raw" transactions for an account
 		// so we use transactions.General type here
-		transactionSlice, err = s.service.ListForAccount(transactions.General, address, limit, offset)
+		transactionSlice, err = s.service.ListForAccount(transactions.General, address, status, limit, offset)
 	} else {
 		// Handle all transactions
-		transactionSlice, err = s.service.List(limit, offset)
+		transactionSlice, err = s.service.List(status, limit, offset)
 	}
 
 	if err != nil {
@@ -69,7 +83,10 @@ func (s *Transactions) CreateFunc(rw http.ResponseWriter, r *http.Request) {
 	var txReq transactions.JSONRequest
 
 	// Try to decode the request body into the struct.
-	err = json.NewDecoder(r.Body).Decode(&txReq)
+	// Keep numbers as is so large integer arguments do not lose precision.
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err = decoder.Decode(&txReq)
 	if err != nil {
 		err = &errors.RequestError{
 			StatusCode: http.StatusBadRequest,
@@ -81,7 +98,12 @@ func (s *Transactions) CreateFunc(rw http.ResponseWriter, r *http.Request) {
 
 	// Decide whether to serve sync or async, default async
 	sync := r.FormValue(SyncQueryParameter) != ""
-	job, transaction, err := s.service.Create(r.Context(), sync, vars["address"], txReq.Code, txReq.Arguments, transactions.General)
+	job, transaction, err := s.service.Create(
+		r.Context(), sync, vars["address"], txReq.Code, txReq.Arguments, transactions.General,
+		transactions.WithAuthorizers(txReq.Authorizers...),
+		transactions.WithGasLimit(txReq.GasLimit),
+		transactions.WithPayer(txReq.Payer),
+	)
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -90,7 +112,7 @@ func (s *Transactions) CreateFunc(rw http.ResponseWriter, r *http.Request) {
 
 	var res interface{}
 	if sync {
-		res = transaction.ToJSONResponse()
+		res = transactionResponse(r, transaction)
 	} else {
 		res = job.ToJSONResponse()
 	}
@@ -110,7 +132,10 @@ func (s *Transactions) SignFunc(rw http.ResponseWriter, r *http.Request) {
 	var txReq transactions.JSONRequest
 
 	// Try to decode the request body into the struct.
-	err = json.NewDecoder(r.Body).Decode(&txReq)
+	// Keep numbers as is so large integer arguments do not lose precision.
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err = decoder.Decode(&txReq)
 	if err != nil {
 		err = &errors.RequestError{
 			StatusCode: http.StatusBadRequest,
@@ -120,7 +145,12 @@ func (s *Transactions) SignFunc(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx, err := s.service.Sign(r.Context(), vars["address"], txReq.Code, txReq.Arguments)
+	tx, err := s.service.Sign(
+		r.Context(), vars["address"], txReq.Code, txReq.Arguments,
+		transactions.WithAuthorizers(txReq.Authorizers...),
+		transactions.WithGasLimit(txReq.GasLimit),
+		transactions.WithPayer(txReq.Payer),
+	)
 	if err != nil {
 		handleError(rw, r, err)
 		return
@@ -139,6 +169,89 @@ func (s *Transactions) SignFunc(rw http.ResponseWriter, r *http.Request) {
 	handleJsonResponse(rw, http.StatusCreated, resp)
 }
 
+func (s *Transactions) SubmitFunc(rw http.ResponseWriter, r *http.Request) {
+	err := checkNonEmptyBody(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	var txReq transactions.SignedTransactionJSONRequest
+
+	// Try to decode the request body into the struct.
+	err = json.NewDecoder(r.Body).Decode(&txReq)
+	if err != nil {
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid body"),
+		}
+		handleError(rw, r, err)
+		return
+	}
+
+	flowTx, err := txReq.ToFlowTransaction()
+	if err != nil {
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decide whether to serve sync or async, default async
+	sync := r.FormValue(SyncQueryParameter) != ""
+	job, transaction, err := s.service.Submit(r.Context(), sync, vars["address"], flowTx)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var res interface{}
+	if sync {
+		res = transactionResponse(r, transaction)
+	} else {
+		res = job.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, res)
+}
+
+func (s *Transactions) CreateBatchFunc(rw http.ResponseWriter, r *http.Request) {
+	err := checkNonEmptyBody(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var batchReq transactions.BatchJSONRequest
+
+	// Try to decode the request body into the struct.
+	// Keep numbers as is so large integer arguments do not lose precision.
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err = decoder.Decode(&batchReq)
+	if err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	results, err := s.service.CreateBatch(r.Context(), batchReq.Items)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := make([]transactions.BatchResultJSONResponse, len(results))
+	for i, result := range results {
+		res[i] = result.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, res)
+}
+
 func (s *Transactions) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
 	var (
 		transaction *transactions.Transaction
@@ -161,7 +274,7 @@ func (s *Transactions) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res := transaction.ToJSONResponse()
+	res := transactionResponse(r, transaction)
 
 	handleJsonResponse(rw, http.StatusOK, res)
 }
@@ -178,10 +291,13 @@ func (s *Transactions) ExecuteScriptFunc(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var txReq transactions.JSONRequest
+	var txReq transactions.ScriptJSONRequest
 
 	// Try to decode the request body into the struct.
-	err = json.NewDecoder(r.Body).Decode(&txReq)
+	// Keep numbers as is so large integer arguments do not lose precision.
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err = decoder.Decode(&txReq)
 	if err != nil {
 		err = &errors.RequestError{
 			StatusCode: http.StatusBadRequest,
@@ -191,7 +307,7 @@ func (s *Transactions) ExecuteScriptFunc(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
-	res, err := s.service.ExecuteScript(r.Context(), txReq.Code, txReq.Arguments)
+	res, err := s.service.ExecuteScript(r.Context(), txReq.Code, txReq.Arguments, txReq.Options()...)
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -200,3 +316,53 @@ func (s *Transactions) ExecuteScriptFunc(rw http.ResponseWriter, r *http.Request
 
 	handleJsonResponse(rw, http.StatusOK, res)
 }
+
+func (s *Transactions) FeesFunc(rw http.ResponseWriter, r *http.Request) {
+	groupBy := transactions.FeesByProposer
+	if v := r.FormValue("groupBy"); v != "" {
+		g, err := transactions.ParseFeeGrouping(v)
+		if err != nil {
+			handleError(rw, r, &errors.RequestError{StatusCode: http.StatusBadRequest, Err: err})
+			return
+		}
+		groupBy = g
+	}
+
+	var filter transactions.FeeFilter
+	for param, t := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		v := r.FormValue(param)
+		if v == "" {
+			continue
+		}
+		parsed, err := parseTime(v)
+		if err != nil {
+			handleError(rw, r, &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid %s: %q", param, v),
+			})
+			return
+		}
+		*t = parsed
+	}
+
+	summaries, err := s.service.FeeSummaries(groupBy, filter)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := make([]transactions.FeeSummaryJSONResponse, len(summaries))
+	for i, summary := range summaries {
+		res[i] = summary.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// parseTime parses either a date (2006-01-02) or an RFC3339 timestamp.
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
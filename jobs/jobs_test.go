@@ -378,3 +378,72 @@ func TestJobErrorMessages(t *testing.T) {
 		}
 	})
 }
+
+func TestReconcilers(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+
+	wp := NewWorkerPool(
+		&dummyStore{}, 1, 0,
+		WithLogger(logger),
+		WithReconcileInterval(10*time.Millisecond),
+	)
+
+	calls := make(chan struct{}, 10)
+
+	wp.RegisterReconciler("test", func(ctx context.Context) error {
+		select {
+		case calls <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	wp.Start()
+	defer wp.Stop(true)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-calls:
+		case <-time.After(time.Second):
+			t.Fatalf("expected reconciler to be called at least %d times", i+1)
+		}
+	}
+}
+
+func TestStatusGauges(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+
+	wp := NewWorkerPool(&dummyStore{}, 1, 0, WithLogger(logger))
+
+	status, err := wp.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Gauges != nil {
+		t.Fatalf("expected no gauges, got %v", status.Gauges)
+	}
+
+	wp.RegisterGauge("test", func() (int64, error) { return 3, nil })
+
+	status, err = wp.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Gauges["test"] != 3 {
+		t.Fatalf("expected gauge value 3, got %v", status.Gauges)
+	}
+
+	// A failing gauge is left out
+	wp.RegisterGauge("failing", func() (int64, error) { return 0, fmt.Errorf("gauge error") })
+
+	status, err = wp.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := status.Gauges["failing"]; ok || status.Gauges["test"] != 3 {
+		t.Fatalf("expected only the test gauge, got %v", status.Gauges)
+	}
+}
@@ -68,6 +68,12 @@ func WithReSchedulableGracePeriod(d time.Duration) WorkerPoolOption {
 	}
 }
 
+func WithReconcileInterval(d time.Duration) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		wp.reconcileInterval = d
+	}
+}
+
 func WithAttributes(attributes datatypes.JSON) JobOption {
 	return func(job *Job) {
 		job.Attributes = attributes
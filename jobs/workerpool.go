@@ -36,12 +36,26 @@ var (
 	// Grace time period before re-scheduling jobs that are up for immediate
 	// restart (such as NO_AVAILABLE_WORKERS or ERROR).
 	defaultReSchedulableGracePeriod = 1 * time.Minute
+
+	// Run registered reconcilers every 30s.
+	defaultReconcileInterval = 30 * time.Second
 )
 
 type ExecutorFunc func(ctx context.Context, j *Job) error
 
+// ReconcilerFunc is run periodically by the worker pool to bring stored state
+// in sync with external state (such as the chain).
+type ReconcilerFunc func(ctx context.Context) error
+
+// GaugeFunc reports a value included in the worker pool status, such as the
+// number of resources pre-created by jobs. Gauges that return an error are
+// left out of the status.
+type GaugeFunc func() (int64, error)
+
 type WorkerPool interface {
 	RegisterExecutor(jobType string, executorF ExecutorFunc)
+	RegisterReconciler(name string, reconcilerF ReconcilerFunc)
+	RegisterGauge(name string, gaugeF GaugeFunc)
 	CreateJob(jobType, txID string, opts ...JobOption) (*Job, error)
 	Schedule(j *Job) error
 	Status() (WorkerPoolStatus, error)
@@ -59,6 +73,8 @@ type WorkerPoolImpl struct {
 	context       context.Context
 	cancelContext context.CancelFunc
 	executors     map[string]ExecutorFunc
+	reconcilers   map[string]ReconcilerFunc
+	gauges        map[string]GaugeFunc
 	logger        *log.Logger
 
 	store       Store
@@ -69,6 +85,7 @@ type WorkerPoolImpl struct {
 	dbJobPollInterval        time.Duration
 	acceptedGracePeriod      time.Duration
 	reSchedulableGracePeriod time.Duration
+	reconcileInterval        time.Duration
 
 	notificationConfig *NotificationConfig
 	systemService      system.Service
@@ -78,6 +95,8 @@ type WorkerPoolStatus struct {
 	JobQueueStatus
 	Capacity    int `json:"poolCapacity"`
 	WorkerCount int `json:"workerCount"`
+	// Values of registered gauges by name
+	Gauges map[string]int64 `json:"gauges,omitempty"`
 }
 
 func NewWorkerPool(db Store, capacity uint, workerCount uint, opts ...WorkerPoolOption) WorkerPool {
@@ -90,6 +109,8 @@ func NewWorkerPool(db Store, capacity uint, workerCount uint, opts ...WorkerPool
 		context:       ctx,
 		cancelContext: cancel,
 		executors:     make(map[string]ExecutorFunc),
+		reconcilers:   make(map[string]ReconcilerFunc),
+		gauges:        make(map[string]GaugeFunc),
 		logger:        log.StandardLogger(),
 
 		store:       db,
@@ -100,6 +121,7 @@ func NewWorkerPool(db Store, capacity uint, workerCount uint, opts ...WorkerPool
 		dbJobPollInterval:        defaultDBJobPollInterval,
 		acceptedGracePeriod:      defaultAcceptedGracePeriod,
 		reSchedulableGracePeriod: defaultReSchedulableGracePeriod,
+		reconcileInterval:        defaultReconcileInterval,
 
 		notificationConfig: &NotificationConfig{},
 	}
@@ -147,6 +169,21 @@ func (wp *WorkerPoolImpl) Status() (WorkerPoolStatus, error) {
 	status.Capacity = int(wp.capacity)
 	status.WorkerCount = int(wp.workerCount)
 
+	if len(wp.gauges) > 0 {
+		status.Gauges = make(map[string]int64, len(wp.gauges))
+		for name, gaugeF := range wp.gauges {
+			value, err := gaugeF()
+			if err != nil {
+				// Leave out the gauge, the job queue status is still valid
+				wp.logger.
+					WithFields(log.Fields{"gauge": name, "error": err}).
+					Warn("Could not read worker pool status gauge")
+				continue
+			}
+			status.Gauges[name] = value
+		}
+	}
+
 	return status, nil
 }
 
@@ -176,6 +213,18 @@ func (wp *WorkerPoolImpl) RegisterExecutor(jobType string, executorF ExecutorFun
 	wp.executors[jobType] = executorF
 }
 
+// RegisterReconciler registers a function to be run periodically once the
+// worker pool has been started.
+func (wp *WorkerPoolImpl) RegisterReconciler(name string, reconcilerF ReconcilerFunc) {
+	wp.reconcilers[name] = reconcilerF
+}
+
+// RegisterGauge registers a function whose value is included in the worker
+// pool status.
+func (wp *WorkerPoolImpl) RegisterGauge(name string, gaugeF GaugeFunc) {
+	wp.gauges[name] = gaugeF
+}
+
 // Schedule will try to immediately schedule the run of a job
 func (wp *WorkerPoolImpl) Schedule(j *Job) error {
 	entry := j.logEntry(wp.logger.WithFields(log.Fields{
@@ -211,6 +260,7 @@ func (wp *WorkerPoolImpl) Start() {
 		wp.started = true
 		wp.startWorkers()
 		wp.startDBJobScheduler()
+		wp.startReconcilers()
 	}
 }
 
@@ -300,6 +350,50 @@ func (wp *WorkerPoolImpl) startDBJobScheduler() {
 	}()
 }
 
+func (wp *WorkerPoolImpl) startReconcilers() {
+	for name, reconcilerF := range wp.reconcilers {
+		go func(name string, reconcilerF ReconcilerFunc) {
+			entry := wp.logger.WithFields(log.Fields{
+				"package":    "jobs",
+				"function":   "WorkerPool.startReconcilers.goroutine",
+				"reconciler": name,
+			})
+
+			var restTime time.Duration
+
+		reconcileLoop:
+			for {
+				select {
+				case <-time.After(restTime):
+				case <-wp.stopChan:
+					break reconcileLoop
+				}
+
+				restTime = wp.reconcileInterval
+
+				if halted, err := wp.systemHalted(); err != nil {
+					entry.
+						WithFields(log.Fields{"error": err}).
+						Warn("Could not get system settings from DB")
+					continue
+				} else if halted {
+					continue
+				}
+
+				begin := time.Now()
+
+				if err := reconcilerF(wp.context); err != nil {
+					entry.
+						WithFields(log.Fields{"error": err}).
+						Warn("Reconciler run resulted with error")
+				}
+
+				restTime = wp.reconcileInterval - time.Since(begin)
+			}
+		}(name, reconcilerF)
+	}
+}
+
 func (wp *WorkerPoolImpl) startWorkers() {
 	for i := uint(0); i < wp.workerCount; i++ {
 		wp.wg.Add(1)
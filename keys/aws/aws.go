@@ -6,7 +6,6 @@ import (
 	"encoding/asn1"
 	"encoding/pem"
 	"fmt"
-	"math/big"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
@@ -29,16 +28,26 @@ type kmsPubKey struct {
 	PublicKey asn1.BitString
 }
 
-// Generates an asymmetric signing & verification key (ECC_SECG_P256K1 / ECDSA_SHA_256) in AWS KMS
-// and returns data required for account creation; a flow.AccountKey and a private key. The private
-// key has the KMS key ARN as the value.
-func Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int) (*flow.AccountKey, *keys.Private, error) {
+// Generates an asymmetric signing & verification key (ECC_NIST_P256 or ECC_SECG_P256K1 / ECDSA_SHA_256)
+// in AWS KMS and returns data required for account creation; a flow.AccountKey and a private key.
+// The private key has the KMS key ARN as the value. AWS KMS signs digests computed by the
+// service, so both SHA2_256 and SHA3_256 can be used.
+func Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int, signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (*flow.AccountKey, *keys.Private, error) {
+	keySpec, err := keySpecFor(signAlgo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if hashAlgo != crypto.SHA2_256 && hashAlgo != crypto.SHA3_256 {
+		return nil, nil, fmt.Errorf("unsupported hash algorithm for AWS KMS: %s", hashAlgo)
+	}
+
 	client := createKMSClient(ctx)
 
 	// Create the new key in AWS KMS
 	createKeyOutput, err := client.CreateKey(ctx, &kms.CreateKeyInput{
 		// CustomKeyStoreId: aws.String(""),                                                                         // TODO: Add support for custom key stores
-		KeySpec:     types.KeySpecEccSecgP256k1,                                                             // TODO: Make key type configurable
+		KeySpec:     keySpec,
 		Description: aws.String(fmt.Sprintf("custodial account key for flow-wallet-api @ %s", cfg.ChainID)), // TODO: Add relevant meta data to description or tags
 		KeyUsage:    types.KeyUsageTypeSignVerify,
 		Tags: []types.Tag{
@@ -62,22 +71,7 @@ func Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int) (*
 		return nil, nil, err
 	}
 
-	var dest kmsPubKey
-
-	// Decode the public key
-	_, err = asn1.Unmarshal(pbkOutput.PublicKey, &dest)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	// Parse signature & hash algorithm
-	signAlgo := parseSignatureAlgorithm(pbkOutput)
-	hashAlgo := parseHashAlgorithm(pbkOutput)
-
-	// Convert the decoded public key into a PEM in string format so that the
-	// DecodePublicKeyPEM from flow-go-sdk/crypto can be used
-	pemStr := string(pem.EncodeToMemory(&pem.Block{Bytes: pbkOutput.PublicKey})[:])
-	pbk, err := crypto.DecodePublicKeyPEM(signAlgo, pemStr)
+	pbk, err := decodePublicKey(pbkOutput, signAlgo)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -100,6 +94,16 @@ func Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int) (*
 }
 
 // Reference: https://docs.aws.amazon.com/kms/latest/developerguide/symm-asymm-choose.html#key-spec-ecc
+func keySpecFor(signAlgo crypto.SignatureAlgorithm) (types.KeySpec, error) {
+	switch signAlgo {
+	case crypto.ECDSA_P256:
+		return types.KeySpecEccNistP256, nil
+	case crypto.ECDSA_secp256k1:
+		return types.KeySpecEccSecgP256k1, nil
+	}
+	return "", fmt.Errorf("unsupported signature algorithm for AWS KMS: %s", signAlgo)
+}
+
 func parseSignatureAlgorithm(po *kms.GetPublicKeyOutput) crypto.SignatureAlgorithm {
 	switch po.KeySpec {
 	default:
@@ -111,15 +115,19 @@ func parseSignatureAlgorithm(po *kms.GetPublicKeyOutput) crypto.SignatureAlgorit
 	}
 }
 
-func parseHashAlgorithm(po *kms.GetPublicKeyOutput) crypto.HashAlgorithm {
-	switch po.KeySpec {
-	default:
-		return crypto.UnknownHashAlgorithm
-	case types.KeySpecEccSecgP256k1:
-		fallthrough
-	case types.KeySpecEccNistP256:
-		return crypto.SHA3_256
+// decodePublicKey decodes the DER encoded public key returned by AWS KMS.
+func decodePublicKey(po *kms.GetPublicKeyOutput, signAlgo crypto.SignatureAlgorithm) (crypto.PublicKey, error) {
+	var dest kmsPubKey
+
+	// Decode the public key
+	if _, err := asn1.Unmarshal(po.PublicKey, &dest); err != nil {
+		return nil, err
 	}
+
+	// Convert the decoded public key into a PEM in string format so that the
+	// DecodePublicKeyPEM from flow-go-sdk/crypto can be used
+	pemStr := string(pem.EncodeToMemory(&pem.Block{Bytes: po.PublicKey})[:])
+	return crypto.DecodePublicKeyPEM(signAlgo, pemStr)
 }
 
 // Signer creates a crypto.Signer for the given private key
@@ -161,21 +169,17 @@ func SignerForKey(
 		return nil, err
 	}
 
-	var sigAlgo crypto.SignatureAlgorithm
-	var hashAlgo crypto.HashAlgorithm
-
-	// Check that ECDSA_SHA_256 is available
-	// TODO: Make hashing algorithm configurable
-	for _, a := range pbkOutput.SigningAlgorithms {
-		if a == types.SigningAlgorithmSpecEcdsaSha256 {
-			hashAlgo = crypto.SHA3_256
-			sigAlgo = crypto.ECDSA_P256
-			break
-		}
+	if !signsDigests(pbkOutput) {
+		return nil, fmt.Errorf("keys/aws: key does not support %s", types.SigningAlgorithmSpecEcdsaSha256)
 	}
 
+	// The curve is defined by the KMS key, the hash algorithm is stored with
+	// the key and defaults to SHA3_256
+	sigAlgo := parseSignatureAlgorithm(pbkOutput)
+
+	hashAlgo := key.HashAlgo
 	if hashAlgo == crypto.UnknownHashAlgorithm {
-		return nil, fmt.Errorf("unknown hash algorithm")
+		hashAlgo = crypto.SHA3_256
 	}
 
 	hasher, err := crypto.NewHasher(hashAlgo)
@@ -183,10 +187,10 @@ func SignerForKey(
 		return nil, fmt.Errorf("keys/aws: failed to instantiate hasher: %w", err)
 	}
 
-	decodedPublicKey, _ := crypto.DecodePublicKey(
-		sigAlgo,
-		pbkOutput.PublicKey,
-	)
+	decodedPublicKey, err := decodePublicKey(pbkOutput, sigAlgo)
+	if err != nil {
+		return nil, fmt.Errorf("keys/aws: failed to decode public key: %w", err)
+	}
 
 	return &AWSSigner{
 		ctx:    ctx,
@@ -197,6 +201,15 @@ func SignerForKey(
 	}, nil
 }
 
+func signsDigests(po *kms.GetPublicKeyOutput) bool {
+	for _, a := range po.SigningAlgorithms {
+		if a == types.SigningAlgorithmSpecEcdsaSha256 {
+			return true
+		}
+	}
+	return false
+}
+
 // Sign signs the given message using the KMS signing key for this signer.
 //
 // Reference: https://docs.aws.amazon.com/kms/latest/APIReference/API_Sign.html
@@ -214,7 +227,7 @@ func (s *AWSSigner) Sign(message []byte) ([]byte, error) {
 		return nil, fmt.Errorf("keys/aws: failed to sign: %w", err)
 	}
 
-	sig, err := parseSignature(sigOut.Signature)
+	sig, err := keys.ParseDERSignature(sigOut.Signature)
 	if err != nil {
 		return nil, fmt.Errorf("keys/aws: failed to parse signature: %w", err)
 	}
@@ -235,38 +248,3 @@ func createKMSClient(ctx context.Context) *kms.Client {
 	client := kms.NewFromConfig(awsCfg)
 	return client
 }
-
-// Source: flow-go-sdk/crypto/cloudkms/signer.go
-
-// ecCoupleComponentSize is size of a component in either (r,s) couple for an elliptical curve signature
-// or (x,y) identifying a public key. Component size is needed for encoding couples comprised of variable length
-// numbers to []byte encoding. They are not always the same length, so occasionally padding is required.
-// Here's how one calculates the required length of each component:
-// 		ECDSA_CurveBits = 256
-// 		ecCoupleComponentSize := ECDSA_CurveBits / 8
-// 		if ECDSA_CurveBits % 8 > 0 {
-//			ecCoupleComponentSize++
-// 		}
-const ecCoupleComponentSize = 32
-
-func parseSignature(signature []byte) ([]byte, error) {
-	var parsedSig struct{ R, S *big.Int }
-	if _, err := asn1.Unmarshal(signature, &parsedSig); err != nil {
-		return nil, fmt.Errorf("asn1.Unmarshal: %w", err)
-	}
-
-	rBytes := parsedSig.R.Bytes()
-	rBytesPadded := rightPad(rBytes, ecCoupleComponentSize)
-
-	sBytes := parsedSig.S.Bytes()
-	sBytesPadded := rightPad(sBytes, ecCoupleComponentSize)
-
-	return append(rBytesPadded, sBytesPadded...), nil
-}
-
-// rightPad pads a byte slice with empty bytes (0x00) to the given length.
-func rightPad(b []byte, length int) []byte {
-	padded := make([]byte, length)
-	copy(padded[length-len(b):], b)
-	return padded
-}
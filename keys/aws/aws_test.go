@@ -6,6 +6,7 @@ import (
 
 	"github.com/flow-hydraulics/flow-wallet-api/configs"
 	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/onflow/flow-go-sdk/crypto"
 )
 
 // Needs to be run manually with proper env configuration
@@ -18,7 +19,7 @@ func TestGenerate(t *testing.T) {
 	}
 
 	t.Run("key is generated", func(t *testing.T) {
-		flowAccountKey, privateKey, err := Generate(cfg, context.Background(), 0, 1000)
+		flowAccountKey, privateKey, err := Generate(cfg, context.Background(), 0, 1000, crypto.ECDSA_secp256k1, crypto.SHA3_256)
 
 		if err != nil {
 			t.Fatal(err)
@@ -0,0 +1,29 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/flow-hydraulics/flow-wallet-api/keys/encryption"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+func init() {
+	keys.RegisterBackend(keys.AccountKeyTypeAWSKMS, backend{})
+}
+
+type backend struct{}
+
+func (backend) Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int, signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (*flow.AccountKey, *keys.Private, error) {
+	return Generate(cfg, ctx, keyIndex, weight, signAlgo, hashAlgo)
+}
+
+func (backend) Signer(cfg *configs.Config, ctx context.Context, key keys.Private) (crypto.Signer, error) {
+	return Signer(ctx, key)
+}
+
+func (backend) Crypter(cfg *configs.Config, key []byte) encryption.Crypter {
+	return NewAWSKMSCrypter(key)
+}
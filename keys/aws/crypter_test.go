@@ -5,7 +5,7 @@ import (
 	"testing"
 
 	"github.com/flow-hydraulics/flow-wallet-api/configs"
-	"github.com/flow-hydraulics/flow-wallet-api/keys/encryption"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
 )
 
 // Needs to be run manually with proper env configuration
@@ -13,8 +13,8 @@ import (
 func TestCrypter(t *testing.T) {
 	cfg := configs.ParseTestConfig(t)
 
-	if cfg.EncryptionKeyType != encryption.EncryptionKeyTypeAWSKMS {
-		t.Skip("skipping since EncryptionKeyType is not", encryption.EncryptionKeyTypeAWSKMS)
+	if cfg.EncryptionKeyType != keys.AccountKeyTypeAWSKMS {
+		t.Skip("skipping since EncryptionKeyType is not", keys.AccountKeyTypeAWSKMS)
 	}
 
 	// encrypt the test plaintext message
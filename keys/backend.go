@@ -0,0 +1,126 @@
+package keys
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys/encryption"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+// Backend generates and signs with the keys of one key type. Backends are
+// registered by key type with RegisterBackend, usually in the init function
+// of the backend package, and the key type is stored with each key.
+type Backend interface {
+	// Generate creates a new key and returns the data required for account
+	// creation; a flow.AccountKey and a private key of the backend key type.
+	Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int, signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (*flow.AccountKey, *Private, error)
+	// Signer creates a crypto.Signer for a private key of the backend key type.
+	Signer(cfg *configs.Config, ctx context.Context, key Private) (crypto.Signer, error)
+}
+
+// CrypterBackend is implemented by backends that can also encrypt the stored
+// keys, making the key type valid as the encryption key type.
+type CrypterBackend interface {
+	Backend
+	// Crypter returns a crypter for the configured encryption key.
+	Crypter(cfg *configs.Config, key []byte) encryption.Crypter
+}
+
+// AlgorithmChecker is implemented by backends that support only some of the
+// ECDSA_P256 and ECDSA_secp256k1 keys with SHA2_256 and SHA3_256.
+type AlgorithmChecker interface {
+	CheckAlgorithms(signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) error
+}
+
+// HashDefaulter is implemented by backends that use a hash algorithm other
+// than SHA3_256 when DEFAULT_HASH_ALGO is not set.
+type HashDefaulter interface {
+	DefaultHashAlgorithm() crypto.HashAlgorithm
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Backend{}
+)
+
+// RegisterBackend makes a key backend available by the key type. It panics
+// if the key type is already registered or reserved.
+func RegisterBackend(keyType string, b Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	if b == nil {
+		panic("keys: RegisterBackend backend is nil")
+	}
+
+	if keyType == "" || keyType == AccountKeyTypeRecovery {
+		panic(fmt.Sprintf("keys: RegisterBackend invalid key type %q", keyType))
+	}
+
+	if _, dup := backends[keyType]; dup {
+		panic(fmt.Sprintf("keys: RegisterBackend called twice for key type %q", keyType))
+	}
+
+	backends[keyType] = b
+}
+
+// LookupBackend returns the backend registered for the key type.
+func LookupBackend(keyType string) (Backend, error) {
+	backendsMu.RLock()
+	b, ok := backends[keyType]
+	backendsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key type %q, expected one of: %s", keyType, strings.Join(Backends(), ", "))
+	}
+
+	return b, nil
+}
+
+// Backends returns the sorted key types of the registered backends.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	types := make([]string, 0, len(backends))
+	for t := range backends {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// CheckConfig returns an error if the configured key types have no
+// registered backend, the encryption key type can not encrypt or the allowed
+// key specs are invalid.
+func CheckConfig(cfg *configs.Config) error {
+	if _, err := LookupBackend(cfg.DefaultKeyType); err != nil {
+		return fmt.Errorf("invalid DEFAULT_KEY_TYPE: %w", err)
+	}
+
+	if _, err := LookupBackend(cfg.AdminKeyType); err != nil {
+		return fmt.Errorf("invalid ADMIN_KEY_TYPE: %w", err)
+	}
+
+	b, err := LookupBackend(cfg.EncryptionKeyType)
+	if err != nil {
+		return fmt.Errorf("invalid ENCRYPTION_KEY_TYPE: %w", err)
+	}
+
+	if _, ok := b.(CrypterBackend); !ok {
+		return fmt.Errorf("invalid ENCRYPTION_KEY_TYPE: key type %q can not encrypt keys", cfg.EncryptionKeyType)
+	}
+
+	if _, err := AllowedKeySpecs(cfg); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package keys
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+type testBackend struct{}
+
+func (testBackend) Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int, signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (*flow.AccountKey, *Private, error) {
+	return nil, nil, fmt.Errorf("not implemented")
+}
+
+func (testBackend) Signer(cfg *configs.Config, ctx context.Context, key Private) (crypto.Signer, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (testBackend) CheckAlgorithms(signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) error {
+	if signAlgo != crypto.ECDSA_secp256k1 {
+		return fmt.Errorf("unsupported signature algorithm %q", signAlgo)
+	}
+	return nil
+}
+
+func TestRegisterBackend(t *testing.T) {
+	// Already registered when run with -count > 1
+	if _, err := LookupBackend("test_backend"); err != nil {
+		RegisterBackend("test_backend", testBackend{})
+	}
+
+	if _, err := LookupBackend("test_backend"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LookupBackend("test_unknown"); err == nil {
+		t.Error("expected an unknown key type to fail")
+	}
+
+	if err := CheckSupported(KeySpec{Type: "test_backend", SignAlgo: "ECDSA_secp256k1", HashAlgo: "SHA3_256"}); err != nil {
+		t.Error(err)
+	}
+
+	if err := CheckSupported(KeySpec{Type: "test_backend", SignAlgo: "ECDSA_P256", HashAlgo: "SHA3_256"}); err == nil {
+		t.Error("expected the backend to reject ECDSA_P256")
+	}
+
+	for _, keyType := range []string{"test_backend", AccountKeyTypeRecovery, ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected registering %q to panic", keyType)
+				}
+			}()
+			RegisterBackend(keyType, testBackend{})
+		}()
+	}
+}
@@ -0,0 +1,11 @@
+package basic
+
+// Built-in key backends, registered by key type. Other backends register
+// themselves with keys.RegisterBackend when their package is imported.
+import (
+	_ "github.com/flow-hydraulics/flow-wallet-api/keys/aws"
+	_ "github.com/flow-hydraulics/flow-wallet-api/keys/google"
+	_ "github.com/flow-hydraulics/flow-wallet-api/keys/local"
+	_ "github.com/flow-hydraulics/flow-wallet-api/keys/pkcs11"
+	_ "github.com/flow-hydraulics/flow-wallet-api/keys/vault"
+)
@@ -10,10 +10,7 @@ import (
 	"github.com/flow-hydraulics/flow-wallet-api/configs"
 	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
 	"github.com/flow-hydraulics/flow-wallet-api/keys"
-	"github.com/flow-hydraulics/flow-wallet-api/keys/aws"
 	"github.com/flow-hydraulics/flow-wallet-api/keys/encryption"
-	"github.com/flow-hydraulics/flow-wallet-api/keys/google"
-	"github.com/flow-hydraulics/flow-wallet-api/keys/local"
 	"github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go-sdk/crypto"
 )
@@ -27,8 +24,10 @@ type KeyManager struct {
 }
 
 // NewKeyManager initiates a new key manager.
-// It uses encryption.AESCrypter to encrypt and decrypt the keys.
-func NewKeyManager(cfg *configs.Config, store keys.Store, fc flow_helpers.FlowClient) *KeyManager {
+// It uses the crypter of the encryption key type backend to encrypt and
+// decrypt the keys, encryption.AESCrypter for local keys. Returns an error if
+// the encryption key type has no backend that can encrypt keys.
+func NewKeyManager(cfg *configs.Config, store keys.Store, fc flow_helpers.FlowClient) (*KeyManager, error) {
 	// TODO(latenssi): safeguard against nil config?
 
 	if cfg.DefaultKeyWeight < 0 {
@@ -40,26 +39,26 @@ func NewKeyManager(cfg *configs.Config, store keys.Store, fc flow_helpers.FlowCl
 		Type:     cfg.AdminKeyType,
 		Value:    cfg.AdminPrivateKey,
 		SignAlgo: crypto.StringToSignatureAlgorithm(cfg.DefaultSignAlgo),
-		HashAlgo: crypto.StringToHashAlgorithm(cfg.DefaultHashAlgo),
+		HashAlgo: crypto.StringToHashAlgorithm(keys.DefaultHashAlgo(cfg, cfg.AdminKeyType)),
 	}
 
-	var crypter encryption.Crypter
-	switch cfg.EncryptionKeyType {
-	default:
-		crypter = encryption.NewAESCrypter([]byte(cfg.EncryptionKey))
-	case encryption.EncryptionKeyTypeGoogleKMS:
-		crypter = google.NewGoogleKMSCrypter([]byte(cfg.EncryptionKey))
-	case encryption.EncryptionKeyTypeAWSKMS:
-		crypter = aws.NewAWSKMSCrypter([]byte(cfg.EncryptionKey))
+	b, err := keys.LookupBackend(cfg.EncryptionKeyType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key type: %w", err)
+	}
+
+	cb, ok := b.(keys.CrypterBackend)
+	if !ok {
+		return nil, fmt.Errorf("invalid encryption key type: key type %q can not encrypt keys", cfg.EncryptionKeyType)
 	}
 
 	return &KeyManager{
 		store,
 		fc,
-		crypter,
+		cb.Crypter(cfg, []byte(cfg.EncryptionKey)),
 		adminAccountKey,
 		cfg,
-	}
+	}, nil
 }
 
 func (s *KeyManager) CheckAdminProposalKeyCount(ctx context.Context) error {
@@ -101,19 +100,25 @@ func (s *KeyManager) CheckAdminProposalKeyCount(ctx context.Context) error {
 }
 
 func (s *KeyManager) Generate(ctx context.Context, keyIndex, weight int) (*flow.AccountKey, *keys.Private, error) {
-	switch s.cfg.DefaultKeyType {
-	default:
-		return nil, nil, fmt.Errorf("keyStore.Generate() not implmented for %s", s.cfg.DefaultKeyType)
-	case keys.AccountKeyTypeLocal:
-		return local.Generate(
-			keyIndex, weight,
-			crypto.StringToSignatureAlgorithm(s.cfg.DefaultSignAlgo),
-			crypto.StringToHashAlgorithm(s.cfg.DefaultHashAlgo))
-	case keys.AccountKeyTypeGoogleKMS:
-		return google.Generate(s.cfg, ctx, keyIndex, weight)
-	case keys.AccountKeyTypeAWSKMS:
-		return aws.Generate(s.cfg, ctx, keyIndex, weight)
+	return s.GenerateFromSpec(ctx, keys.KeySpec{Weight: weight}, keyIndex)
+}
+
+func (s *KeyManager) GenerateFromSpec(ctx context.Context, spec keys.KeySpec, keyIndex int) (*flow.AccountKey, *keys.Private, error) {
+	spec = keys.ResolveKeySpec(s.cfg, spec)
+
+	if err := keys.CheckSupported(spec); err != nil {
+		return nil, nil, err
 	}
+
+	signAlgo := crypto.StringToSignatureAlgorithm(spec.SignAlgo)
+	hashAlgo := crypto.StringToHashAlgorithm(spec.HashAlgo)
+
+	b, err := keys.LookupBackend(spec.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b.Generate(s.cfg, ctx, keyIndex, spec.Weight, signAlgo, hashAlgo)
 }
 
 func (s *KeyManager) GenerateDefault(ctx context.Context) (*flow.AccountKey, *keys.Private, error) {
@@ -152,6 +157,10 @@ func (s *KeyManager) AdminAuthorizer(ctx context.Context) (keys.Authorizer, erro
 	return s.MakeAuthorizer(ctx, flow.HexToAddress(s.cfg.AdminAddress))
 }
 
+func (s *KeyManager) Signer(ctx context.Context, key keys.Private) (crypto.Signer, error) {
+	return s.signerForKey(ctx, flow.EmptyAddress, key)
+}
+
 func (s *KeyManager) UserAuthorizer(ctx context.Context, address flow.Address) (keys.Authorizer, error) {
 	return s.MakeAuthorizer(ctx, address)
 }
@@ -178,16 +187,73 @@ func (s *KeyManager) MakeAuthorizer(ctx context.Context, address flow.Address) (
 		return keys.Authorizer{}, err
 	}
 
-	sig, err := signerForKey(ctx, address, k)
+	sig, err := s.signerForKey(ctx, address, k)
 	if err != nil {
 		return keys.Authorizer{}, err
 	}
 
-	return keys.Authorizer{
+	a := keys.Authorizer{
 		Address: address,
 		Key:     acc.Keys[k.Index],
 		Signer:  sig,
-	}, nil
+	}
+
+	// Keys of multi-key accounts need other keys to reach the threshold
+	if address != flow.HexToAddress(s.cfg.AdminAddress) && a.Key.Weight < flow.AccountKeyWeightThreshold {
+		if err := s.addCoSigners(ctx, &a, acc); err != nil {
+			return keys.Authorizer{}, err
+		}
+	}
+
+	return a, nil
+}
+
+// addCoSigners adds stored keys of the account to the authorizer until their
+// combined weight reaches the threshold. Only one key per public key is used,
+// so that clones of a single key can not authorize alone.
+func (s *KeyManager) addCoSigners(ctx context.Context, a *keys.Authorizer, acc *flow.Account) error {
+	stored, err := s.store.AccountKeys(flow_helpers.FormatAddress(a.Address))
+	if err != nil {
+		return err
+	}
+
+	used := map[string]bool{a.Key.PublicKey.String(): true}
+	weight := a.Key.Weight
+
+	for _, sk := range stored {
+		if weight >= flow.AccountKeyWeightThreshold {
+			break
+		}
+
+		if sk.Index < 0 || sk.Index >= len(acc.Keys) {
+			continue
+		}
+
+		key := acc.Keys[sk.Index]
+		if key.Revoked || used[key.PublicKey.String()] {
+			continue
+		}
+
+		k, err := s.Load(sk)
+		if err != nil {
+			return err
+		}
+
+		sig, err := s.signerForKey(ctx, a.Address, k)
+		if err != nil {
+			return err
+		}
+
+		used[key.PublicKey.String()] = true
+		weight += key.Weight
+		a.CoSigners = append(a.CoSigners, keys.CoSigner{Key: key, Signer: sig})
+	}
+
+	if weight < flow.AccountKeyWeightThreshold {
+		return fmt.Errorf("not enough key weight to sign for %s: %d", a.Address, weight)
+	}
+
+	return nil
 }
 
 func (s *KeyManager) AdminProposalKey(ctx context.Context) (keys.Authorizer, error) {
@@ -203,7 +269,7 @@ func (s *KeyManager) AdminProposalKey(ctx context.Context) (keys.Authorizer, err
 		return keys.Authorizer{}, err
 	}
 
-	sig, err := signerForKey(ctx, adminAcc, s.adminAccountKey)
+	sig, err := s.signerForKey(ctx, adminAcc, s.adminAccountKey)
 	if err != nil {
 		return keys.Authorizer{}, err
 	}
@@ -220,31 +286,15 @@ func (s *KeyManager) AdminProposalKey(ctx context.Context) (keys.Authorizer, err
 	}, nil
 }
 
-func signerForKey(ctx context.Context, address flow.Address, k keys.Private) (crypto.Signer, error) {
-	var (
-		sig crypto.Signer
-		err error
-	)
-
-	switch k.Type {
-	default:
-		return nil, fmt.Errorf("key.Type not recognised: %s", k.Type)
-	case keys.AccountKeyTypeLocal:
-		sig, err = local.Signer(ctx, k)
-		if err != nil {
-			return nil, err
-		}
-	case keys.AccountKeyTypeGoogleKMS:
-		sig, err = google.Signer(ctx, k)
-		if err != nil {
-			return nil, err
-		}
-	case keys.AccountKeyTypeAWSKMS:
-		sig, err = aws.Signer(ctx, k)
-		if err != nil {
-			return nil, err
-		}
+func (s *KeyManager) signerForKey(ctx context.Context, address flow.Address, k keys.Private) (crypto.Signer, error) {
+	if k.Type == keys.AccountKeyTypeRecovery {
+		return nil, fmt.Errorf("recovery key %d can not be used for signing", k.Index)
+	}
+
+	b, err := keys.LookupBackend(k.Type)
+	if err != nil {
+		return nil, fmt.Errorf("key.Type not recognised: %w", err)
 	}
 
-	return sig, nil
+	return b.Signer(s.cfg, ctx, k)
 }
@@ -0,0 +1,29 @@
+package basic
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+)
+
+func TestNewKeyManagerEncryptionKeyType(t *testing.T) {
+	cfg := &configs.Config{
+		AdminKeyType:      keys.AccountKeyTypeLocal,
+		EncryptionKeyType: keys.AccountKeyTypeLocal,
+		EncryptionKey:     "faffb2f5fd1d5cc0d36fd8ffdb48d5e5",
+	}
+
+	if _, err := NewKeyManager(cfg, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown key types and key types that can not encrypt are not replaced
+	// with local encryption
+	for _, keyType := range []string{"unknown", keys.AccountKeyTypePKCS11} {
+		cfg.EncryptionKeyType = keyType
+		if _, err := NewKeyManager(cfg, nil, nil); err == nil {
+			t.Errorf("expected encryption key type %q to be invalid", keyType)
+		}
+	}
+}
@@ -5,7 +5,3 @@ type Crypter interface {
 	Encrypt(message []byte) (encrypted []byte, err error)
 	Decrypt(encrypted []byte) (message []byte, err error)
 }
-
-const EncryptionKeyTypeGoogleKMS = "google_kms"
-const EncryptionKeyTypeAWSKMS = "aws_kms"
-const EncryptionKeyTypeLocal = "local"
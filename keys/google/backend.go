@@ -0,0 +1,43 @@
+package google
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/flow-hydraulics/flow-wallet-api/keys/encryption"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+func init() {
+	keys.RegisterBackend(keys.AccountKeyTypeGoogleKMS, backend{})
+}
+
+type backend struct{}
+
+func (backend) Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int, signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (*flow.AccountKey, *keys.Private, error) {
+	return Generate(cfg, ctx, keyIndex, weight, signAlgo, hashAlgo)
+}
+
+func (backend) Signer(cfg *configs.Config, ctx context.Context, key keys.Private) (crypto.Signer, error) {
+	return Signer(ctx, key)
+}
+
+func (backend) Crypter(cfg *configs.Config, key []byte) encryption.Crypter {
+	return NewGoogleKMSCrypter(key)
+}
+
+// DefaultHashAlgorithm is SHA2_256, the only hash Google KMS signs.
+func (backend) DefaultHashAlgorithm() crypto.HashAlgorithm {
+	return crypto.SHA2_256
+}
+
+// CheckAlgorithms allows SHA2_256 only, Google KMS signs SHA2_256 digests.
+func (backend) CheckAlgorithms(signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) error {
+	if hashAlgo != crypto.SHA2_256 {
+		return fmt.Errorf("unsupported hash algorithm %q", hashAlgo)
+	}
+	return nil
+}
@@ -5,7 +5,7 @@ import (
 	"testing"
 
 	"github.com/flow-hydraulics/flow-wallet-api/configs"
-	"github.com/flow-hydraulics/flow-wallet-api/keys/encryption"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
 )
 
 // Needs to be run manually with proper env configuration
@@ -14,8 +14,8 @@ func TestCrypter(t *testing.T) {
 	cfg := configs.ParseTestConfig(t)
 
 	// Skip if not explicitly testing Google KMS keys
-	if cfg.EncryptionKeyType != encryption.EncryptionKeyTypeGoogleKMS {
-		t.Skip("skipping since EncryptionKeyType is not", encryption.EncryptionKeyTypeGoogleKMS)
+	if cfg.EncryptionKeyType != keys.AccountKeyTypeGoogleKMS {
+		t.Skip("skipping since EncryptionKeyType is not", keys.AccountKeyTypeGoogleKMS)
 	}
 
 	// Encrypt example test message with configured encryption key (key resource name)
@@ -67,7 +67,12 @@ func getPublicKey(ctx context.Context, c *cloudkms.Client, k *cloudkms.Key) (*cr
 
 // Generate creates a new asymmetric signing & verification key in Google KMS
 // and returns the required data to use the key with the Flow blockchain
-func Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int) (*flow.AccountKey, *keys.Private, error) {
+func Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int, signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (*flow.AccountKey, *keys.Private, error) {
+	algorithm, err := asymKeyAlgorithm(signAlgo, hashAlgo)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	u := uuid.New()
 
 	// Create the new key in Google KMS
@@ -75,6 +80,7 @@ func Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int) (*
 		ctx,
 		fmt.Sprintf("projects/%s/locations/%s/keyRings/%s", cfg.GoogleKMSProjectID, cfg.GoogleKMSLocationID, cfg.GoogleKMSKeyRingID),
 		fmt.Sprintf("flow-wallet-account-key-%s", u.String()),
+		algorithm,
 	)
 	if err != nil {
 		return nil, nil, err
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/onflow/flow-go-sdk/crypto"
 	"github.com/onflow/flow-go-sdk/crypto/cloudkms"
 
 	kms "cloud.google.com/go/kms/apiv1"
@@ -13,7 +14,7 @@ import (
 
 // AsymKey creates a new asymmetric signing key in Google KMS and returns
 // a cloudkms.Key (the "raw" result isn't needed)
-func AsymKey(ctx context.Context, parent, id string) (*cloudkms.Key, error) {
+func AsymKey(ctx context.Context, parent, id string, algorithm kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm) (*cloudkms.Key, error) {
 	c, err := kms.NewKeyManagementClient(ctx)
 	if err != nil {
 		return nil, err
@@ -28,7 +29,8 @@ func AsymKey(ctx context.Context, parent, id string) (*cloudkms.Key, error) {
 		CryptoKey: &kmspb.CryptoKey{
 			Purpose: kmspb.CryptoKey_ASYMMETRIC_SIGN,
 			VersionTemplate: &kmspb.CryptoKeyVersionTemplate{
-				Algorithm: kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256,
+				Algorithm:       algorithm,
+				ProtectionLevel: protectionLevel(algorithm),
 			},
 			// TODO: Set relevant labels at creation, update post-creation if necessary
 			Labels: map[string]string{
@@ -59,3 +61,29 @@ func AsymKey(ctx context.Context, parent, id string) (*cloudkms.Key, error) {
 
 	return &k, nil
 }
+
+// asymKeyAlgorithm returns the Google KMS algorithm for the signature and hash
+// algorithms. Google KMS only signs SHA2_256 digests.
+func asymKeyAlgorithm(signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm, error) {
+	if hashAlgo != crypto.SHA2_256 {
+		return 0, fmt.Errorf("unsupported hash algorithm for Google KMS: %s", hashAlgo)
+	}
+
+	switch signAlgo {
+	case crypto.ECDSA_P256:
+		return kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256, nil
+	case crypto.ECDSA_secp256k1:
+		return kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256, nil
+	}
+
+	return 0, fmt.Errorf("unsupported signature algorithm for Google KMS: %s", signAlgo)
+}
+
+// protectionLevel returns the protection level required by the algorithm,
+// secp256k1 keys are only available in Cloud HSM.
+func protectionLevel(algorithm kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm) kmspb.ProtectionLevel {
+	if algorithm == kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256 {
+		return kmspb.ProtectionLevel_HSM
+	}
+	return kmspb.ProtectionLevel_SOFTWARE
+}
@@ -15,6 +15,13 @@ const (
 	AccountKeyTypeLocal     = "local"
 	AccountKeyTypeGoogleKMS = "google_kms"
 	AccountKeyTypeAWSKMS    = "aws_kms"
+	// Keys in a HashiCorp Vault transit mount.
+	AccountKeyTypeVaultTransit = "vault_transit"
+	// Keys in a PKCS#11 HSM token.
+	AccountKeyTypePKCS11 = "pkcs11"
+	// Recovery keys are held outside the service, only their public key is
+	// stored and they are never used for signing.
+	AccountKeyTypeRecovery = "recovery"
 )
 
 var ErrAdminProposalKeyCountMismatch = errors.New("admin-proposal-key count mismatch")
@@ -23,6 +30,8 @@ var ErrAdminProposalKeyCountMismatch = errors.New("admin-proposal-key count mism
 type Manager interface {
 	// Generate generates a new Key using provided key index and weight.
 	Generate(ctx context.Context, keyIndex, weight int) (*flow.AccountKey, *Private, error)
+	// GenerateFromSpec generates a new Key using provided key index and spec.
+	GenerateFromSpec(ctx context.Context, spec KeySpec, keyIndex int) (*flow.AccountKey, *Private, error)
 	// GenerateDefault generates a new Key using application defaults.
 	GenerateDefault(context.Context) (*flow.AccountKey, *Private, error)
 	// Save is responsible for converting an "in flight" key to a storable key.
@@ -31,6 +40,8 @@ type Manager interface {
 	Load(Storable) (Private, error)
 	// AdminAuthorizer returns an Authorizer for the applications admin account.
 	AdminAuthorizer(context.Context) (Authorizer, error)
+	// Signer returns a crypto.Signer for the given "in flight" key.
+	Signer(ctx context.Context, key Private) (crypto.Signer, error)
 	// UserAuthorizer returns an Authorizer for the given address.
 	UserAuthorizer(ctx context.Context, address flow.Address) (Authorizer, error)
 	// CheckAdminProposalKeyCount checks if admin proposal keys have been correctly initiated (counts match).
@@ -91,8 +102,54 @@ type Authorizer struct {
 	Address flow.Address
 	Key     *flow.AccountKey
 	Signer  crypto.Signer
+	// CoSigners sign together with Key when its weight is below the
+	// threshold, e.g. for accounts with keys in different backends.
+	CoSigners []CoSigner
+}
+
+// CoSigner is an additional key of an Authorizer.
+type CoSigner struct {
+	Key    *flow.AccountKey
+	Signer crypto.Signer
 }
 
 func (a *Authorizer) Equals(t Authorizer) bool {
 	return a.Address.Hex() == t.Address.Hex() && a.Key.Index == t.Key.Index
 }
+
+// Weight returns the combined weight of the keys of the authorizer.
+func (a *Authorizer) Weight() int {
+	w := a.Key.Weight
+	for _, c := range a.CoSigners {
+		w += c.Key.Weight
+	}
+	return w
+}
+
+// SignPayload signs the payload of the transaction with all the keys of the
+// authorizer.
+func (a *Authorizer) SignPayload(tx *flow.Transaction) error {
+	if err := tx.SignPayload(a.Address, a.Key.Index, a.Signer); err != nil {
+		return err
+	}
+	for _, c := range a.CoSigners {
+		if err := tx.SignPayload(a.Address, c.Key.Index, c.Signer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SignEnvelope signs the envelope of the transaction with all the keys of
+// the authorizer.
+func (a *Authorizer) SignEnvelope(tx *flow.Transaction) error {
+	if err := tx.SignEnvelope(a.Address, a.Key.Index, a.Signer); err != nil {
+		return err
+	}
+	for _, c := range a.CoSigners {
+		if err := tx.SignEnvelope(a.Address, c.Key.Index, c.Signer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
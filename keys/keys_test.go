@@ -0,0 +1,71 @@
+package keys
+
+import (
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+func testSigner(t *testing.T, seedByte byte) (*flow.AccountKey, crypto.Signer) {
+	seed := make([]byte, crypto.MinSeedLength)
+	seed[0] = seedByte
+	pk, err := crypto.GeneratePrivateKey(crypto.ECDSA_P256, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := flow.NewAccountKey().FromPrivateKey(pk).SetHashAlgo(crypto.SHA3_256)
+
+	signer, err := crypto.NewInMemorySigner(pk, key.HashAlgo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, signer
+}
+
+func TestAuthorizerCoSigners(t *testing.T) {
+	address := flow.HexToAddress("0x01")
+
+	key, signer := testSigner(t, 1)
+	key.SetWeight(500)
+
+	coKey, coSigner := testSigner(t, 2)
+	coKey.SetWeight(500)
+	coKey.Index = 1
+
+	a := Authorizer{
+		Address:   address,
+		Key:       key,
+		Signer:    signer,
+		CoSigners: []CoSigner{{Key: coKey, Signer: coSigner}},
+	}
+
+	if a.Weight() != flow.AccountKeyWeightThreshold {
+		t.Errorf("expected combined weight %d, got %d", flow.AccountKeyWeightThreshold, a.Weight())
+	}
+
+	tx := flow.NewTransaction().
+		SetProposalKey(address, 0, 0).
+		SetPayer(flow.HexToAddress("0x02")).
+		AddAuthorizer(address)
+
+	if err := a.SignPayload(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tx.PayloadSignatures) != 2 {
+		t.Fatalf("expected a payload signature from each key, got %d", len(tx.PayloadSignatures))
+	}
+
+	tx.SetPayer(address)
+
+	if err := a.SignEnvelope(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tx.EnvelopeSignatures) != 2 {
+		t.Fatalf("expected an envelope signature from each key, got %d", len(tx.EnvelopeSignatures))
+	}
+}
@@ -0,0 +1,31 @@
+package local
+
+import (
+	"context"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/flow-hydraulics/flow-wallet-api/keys/encryption"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+func init() {
+	keys.RegisterBackend(keys.AccountKeyTypeLocal, backend{})
+}
+
+// backend stores the keys encrypted in the database. Stored keys are
+// encrypted with AES.
+type backend struct{}
+
+func (backend) Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int, signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (*flow.AccountKey, *keys.Private, error) {
+	return Generate(keyIndex, weight, signAlgo, hashAlgo)
+}
+
+func (backend) Signer(cfg *configs.Config, ctx context.Context, key keys.Private) (crypto.Signer, error) {
+	return Signer(ctx, key)
+}
+
+func (backend) Crypter(cfg *configs.Config, key []byte) encryption.Crypter {
+	return encryption.NewAESCrypter(key)
+}
@@ -0,0 +1,93 @@
+package pkcs11
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+// Named curve OIDs used as CKA_EC_PARAMS.
+var (
+	oidP256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	oidSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// ecParams returns the DER encoded CKA_EC_PARAMS of the signature algorithm.
+func ecParams(signAlgo crypto.SignatureAlgorithm) ([]byte, error) {
+	switch signAlgo {
+	case crypto.ECDSA_P256:
+		return asn1.Marshal(oidP256)
+	case crypto.ECDSA_secp256k1:
+		return asn1.Marshal(oidSecp256k1)
+	default:
+		return nil, fmt.Errorf("unsupported signature algorithm for PKCS#11: %s", signAlgo)
+	}
+}
+
+// signAlgoForParams returns the signature algorithm of DER encoded
+// CKA_EC_PARAMS.
+func signAlgoForParams(params []byte) (crypto.SignatureAlgorithm, error) {
+	var oid asn1.ObjectIdentifier
+	if rest, err := asn1.Unmarshal(params, &oid); err != nil || len(rest) > 0 {
+		return crypto.UnknownSignatureAlgorithm, fmt.Errorf("keys/pkcs11: EC parameters are not a named curve")
+	}
+
+	switch {
+	case oid.Equal(oidP256):
+		return crypto.ECDSA_P256, nil
+	case oid.Equal(oidSecp256k1):
+		return crypto.ECDSA_secp256k1, nil
+	default:
+		return crypto.UnknownSignatureAlgorithm, fmt.Errorf("keys/pkcs11: unsupported curve %s", oid)
+	}
+}
+
+// decodeECPoint decodes a CKA_EC_POINT value to a public key. The point is
+// an uncompressed point wrapped in a DER octet string, although some modules
+// return the bare point.
+func decodeECPoint(signAlgo crypto.SignatureAlgorithm, value []byte) (crypto.PublicKey, error) {
+	point := value
+
+	var wrapped []byte
+	if rest, err := asn1.Unmarshal(value, &wrapped); err == nil && len(rest) == 0 {
+		point = wrapped
+	}
+
+	if len(point) == 0 || point[0] != 0x04 {
+		return nil, fmt.Errorf("keys/pkcs11: EC point is not uncompressed")
+	}
+
+	pbk, err := crypto.DecodePublicKey(signAlgo, point[1:])
+	if err != nil {
+		return nil, fmt.Errorf("keys/pkcs11: failed to decode public key: %w", err)
+	}
+
+	return pbk, nil
+}
+
+// userPIN returns the user PIN of the token from the config or the PIN file.
+func userPIN(cfg *configs.Config) (string, error) {
+	if cfg.PKCS11PINFile != "" {
+		b, err := os.ReadFile(cfg.PKCS11PINFile)
+		if err != nil {
+			return "", fmt.Errorf("keys/pkcs11: failed to read PIN file: %w", err)
+		}
+		return string(bytes.TrimRight(b, "\r\n")), nil
+	}
+
+	if cfg.PKCS11PIN == "" {
+		return "", fmt.Errorf("keys/pkcs11: PKCS11_PIN or PKCS11_PIN_FILE is required")
+	}
+
+	return cfg.PKCS11PIN, nil
+}
+
+// tokenLabel trims the blank padding of a token label.
+func tokenLabel(label string) string {
+	return strings.TrimRight(label, " \x00")
+}
@@ -0,0 +1,100 @@
+package pkcs11
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+func TestECParams(t *testing.T) {
+	for _, signAlgo := range []crypto.SignatureAlgorithm{crypto.ECDSA_P256, crypto.ECDSA_secp256k1} {
+		params, err := ecParams(signAlgo)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := signAlgoForParams(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != signAlgo {
+			t.Errorf("expected %s, got %s", signAlgo, got)
+		}
+	}
+
+	if _, err := ecParams(crypto.UnknownSignatureAlgorithm); err == nil {
+		t.Error("expected an unknown algorithm to be unsupported")
+	}
+
+	// secp384r1
+	params, _ := asn1.Marshal(asn1.ObjectIdentifier{1, 3, 132, 0, 34})
+	if _, err := signAlgoForParams(params); err == nil {
+		t.Error("expected secp384r1 to be unsupported")
+	}
+}
+
+func TestDecodeECPoint(t *testing.T) {
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	point := elliptic.Marshal(elliptic.P256(), pk.X, pk.Y)
+
+	expected, err := crypto.DecodePublicKey(crypto.ECDSA_P256, point[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrapped, err := asn1.Marshal(point)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range [][]byte{wrapped, point} {
+		pbk, err := decodeECPoint(crypto.ECDSA_P256, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !pbk.Equals(expected) {
+			t.Error("expected the decoded public key to match")
+		}
+	}
+
+	compressed := elliptic.MarshalCompressed(elliptic.P256(), pk.X, pk.Y)
+	if _, err := decodeECPoint(crypto.ECDSA_P256, compressed); err == nil {
+		t.Error("expected a compressed point to be rejected")
+	}
+}
+
+func TestUserPIN(t *testing.T) {
+	cfg := &configs.Config{}
+
+	if _, err := userPIN(cfg); err == nil {
+		t.Error("expected an error without a PIN")
+	}
+
+	cfg.PKCS11PIN = "1234"
+	if pin, err := userPIN(cfg); err != nil || pin != "1234" {
+		t.Errorf("expected the configured PIN, got %q %v", pin, err)
+	}
+
+	file := path.Join(t.TempDir(), "pin")
+	if err := os.WriteFile(file, []byte("5678\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.PKCS11PINFile = file
+	if pin, err := userPIN(cfg); err != nil || pin != "5678" {
+		t.Errorf("expected the PIN from the file, got %q %v", pin, err)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !cgo
+// +build !cgo
+
+// Package pkcs11 provides functions for key and signer generation in a
+// PKCS#11 HSM token. PKCS#11 modules are loaded with cgo, so the functions
+// return an error in builds without it.
+package pkcs11
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+var errNoCgo = fmt.Errorf("keys/pkcs11: PKCS#11 support requires a build with cgo enabled")
+
+// Generate returns an error, see the package documentation.
+func Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int, signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (*flow.AccountKey, *keys.Private, error) {
+	return nil, nil, errNoCgo
+}
+
+// Signer returns an error, see the package documentation.
+func Signer(cfg *configs.Config, ctx context.Context, key keys.Private) (crypto.Signer, error) {
+	return nil, errNoCgo
+}
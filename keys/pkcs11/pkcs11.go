@@ -0,0 +1,221 @@
+//go:build cgo
+// +build cgo
+
+// Package pkcs11 provides functions for key and signer generation in a
+// PKCS#11 HSM token.
+package pkcs11
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/google/uuid"
+	p11 "github.com/miekg/pkcs11"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+// Generate creates a new ECDSA key pair in the configured token and returns
+// the data required for account creation; a flow.AccountKey and a private
+// key. The private key is sensitive and not extractable, so it never leaves
+// the HSM. The private key has the label of the key pair as the value.
+// Digests are computed by the service, so both SHA2_256 and SHA3_256 can
+// be used.
+func Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int, signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (*flow.AccountKey, *keys.Private, error) {
+	if hashAlgo != crypto.SHA2_256 && hashAlgo != crypto.SHA3_256 {
+		return nil, nil, fmt.Errorf("unsupported hash algorithm for PKCS#11: %s", hashAlgo)
+	}
+
+	params, err := ecParams(signAlgo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t, err := openToken(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id := uuid.New()
+	label := fmt.Sprintf("flow-wallet-account-key-%s", id.String())
+
+	publicTemplate := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_PUBLIC_KEY),
+		p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_EC),
+		p11.NewAttribute(p11.CKA_TOKEN, true),
+		p11.NewAttribute(p11.CKA_VERIFY, true),
+		p11.NewAttribute(p11.CKA_EC_PARAMS, params),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+		p11.NewAttribute(p11.CKA_ID, id[:]),
+	}
+
+	privateTemplate := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_PRIVATE_KEY),
+		p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_EC),
+		p11.NewAttribute(p11.CKA_TOKEN, true),
+		p11.NewAttribute(p11.CKA_PRIVATE, true),
+		p11.NewAttribute(p11.CKA_SENSITIVE, true),
+		p11.NewAttribute(p11.CKA_EXTRACTABLE, false),
+		p11.NewAttribute(p11.CKA_SIGN, true),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+		p11.NewAttribute(p11.CKA_ID, id[:]),
+	}
+
+	var pbk crypto.PublicKey
+
+	err = t.withSession(func(session p11.SessionHandle) error {
+		public, _, err := t.ctx.GenerateKeyPair(
+			session,
+			[]*p11.Mechanism{p11.NewMechanism(p11.CKM_EC_KEY_PAIR_GEN, nil)},
+			publicTemplate,
+			privateTemplate,
+		)
+		if err != nil {
+			return fmt.Errorf("keys/pkcs11: failed to generate key pair: %w", err)
+		}
+
+		attrs, err := t.ctx.GetAttributeValue(session, public, []*p11.Attribute{
+			p11.NewAttribute(p11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return fmt.Errorf("keys/pkcs11: failed to read public key: %w", err)
+		}
+
+		pbk, err = decodeECPoint(signAlgo, attrs[0].Value)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f := flow.NewAccountKey().
+		SetPublicKey(pbk).
+		SetHashAlgo(hashAlgo).
+		SetWeight(weight)
+	f.Index = keyIndex
+
+	p := &keys.Private{
+		Index:    keyIndex,
+		Type:     keys.AccountKeyTypePKCS11,
+		Value:    label,
+		SignAlgo: signAlgo,
+		HashAlgo: hashAlgo,
+	}
+
+	return f, p, nil
+}
+
+// Signer creates a crypto.Signer for the given private key (key label)
+func Signer(cfg *configs.Config, ctx context.Context, key keys.Private) (crypto.Signer, error) {
+	s, err := SignerForKey(cfg, ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// PKCS11Signer is a PKCS#11 implementation of crypto.Signer.
+type PKCS11Signer struct {
+	token     *token
+	label     string
+	hasher    crypto.Hasher
+	publicKey crypto.PublicKey
+}
+
+// SignerForKey returns a new PKCS11Signer for the given private key. The
+// curve is read from the public key object in the token.
+func SignerForKey(cfg *configs.Config, ctx context.Context, key keys.Private) (*PKCS11Signer, error) {
+	t, err := openToken(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var pbk crypto.PublicKey
+
+	err = t.withSession(func(session p11.SessionHandle) error {
+		public, err := findObject(t.ctx, session, p11.CKO_PUBLIC_KEY, key.Value)
+		if err != nil {
+			return err
+		}
+
+		attrs, err := t.ctx.GetAttributeValue(session, public, []*p11.Attribute{
+			p11.NewAttribute(p11.CKA_EC_PARAMS, nil),
+			p11.NewAttribute(p11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return fmt.Errorf("keys/pkcs11: failed to read public key: %w", err)
+		}
+
+		signAlgo, err := signAlgoForParams(attrs[0].Value)
+		if err != nil {
+			return err
+		}
+
+		pbk, err = decodeECPoint(signAlgo, attrs[1].Value)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys stored before the hash algorithm was recorded use SHA3_256
+	hashAlgo := key.HashAlgo
+	if hashAlgo == crypto.UnknownHashAlgorithm {
+		hashAlgo = crypto.SHA3_256
+	}
+
+	hasher, err := crypto.NewHasher(hashAlgo)
+	if err != nil {
+		return nil, fmt.Errorf("keys/pkcs11: failed to instantiate hasher: %w", err)
+	}
+
+	return &PKCS11Signer{
+		token:     t,
+		label:     key.Value,
+		hasher:    hasher,
+		publicKey: pbk,
+	}, nil
+}
+
+// Sign signs the given message using the private key of this signer. The
+// digest is computed locally and signed with CKM_ECDSA, which returns the
+// raw r||s signature Flow expects.
+func (s *PKCS11Signer) Sign(message []byte) ([]byte, error) {
+	digest := s.hasher.ComputeHash(message)
+
+	var sig []byte
+
+	err := s.token.withSession(func(session p11.SessionHandle) error {
+		private, err := findObject(s.token.ctx, session, p11.CKO_PRIVATE_KEY, s.label)
+		if err != nil {
+			return err
+		}
+
+		if err := s.token.ctx.SignInit(session, []*p11.Mechanism{p11.NewMechanism(p11.CKM_ECDSA, nil)}, private); err != nil {
+			return fmt.Errorf("keys/pkcs11: failed to sign: %w", err)
+		}
+
+		sig, err = s.token.ctx.Sign(session, digest)
+		if err != nil {
+			return fmt.Errorf("keys/pkcs11: failed to sign: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sig) != 2*32 {
+		return nil, fmt.Errorf("keys/pkcs11: unexpected signature length %d", len(sig))
+	}
+
+	return sig, nil
+}
+
+func (s *PKCS11Signer) PublicKey() crypto.PublicKey {
+	return s.publicKey
+}
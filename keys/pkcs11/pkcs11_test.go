@@ -0,0 +1,69 @@
+//go:build cgo
+// +build cgo
+
+package pkcs11
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+// Needs to be run manually with proper env configuration, e.g. with SoftHSM:
+//
+//	softhsm2-util --init-token --free --label flow-wallet --pin 1234 --so-pin 1234
+//	FLOW_WALLET_PKCS11_MODULE_PATH=/usr/lib/softhsm/libsofthsm2.so \
+//	FLOW_WALLET_PKCS11_TOKEN_LABEL=flow-wallet \
+//	FLOW_WALLET_PKCS11_PIN=1234 \
+//	go test ./keys/pkcs11/
+//
+// It's skipped during standard test execution
+func TestGenerateAndSign(t *testing.T) {
+	cfg := configs.ParseTestConfig(t)
+
+	if cfg.PKCS11ModulePath == "" {
+		t.Skip("skipping since PKCS11ModulePath is not set")
+	}
+
+	ctx := context.Background()
+
+	for _, signAlgo := range []crypto.SignatureAlgorithm{crypto.ECDSA_P256, crypto.ECDSA_secp256k1} {
+		for _, hashAlgo := range []crypto.HashAlgorithm{crypto.SHA2_256, crypto.SHA3_256} {
+			flowAccountKey, privateKey, err := Generate(cfg, ctx, 0, 1000, signAlgo, hashAlgo)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signer, err := Signer(cfg, ctx, *privateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !signer.PublicKey().Equals(flowAccountKey.PublicKey) {
+				t.Fatal("expected the signer public key to match the account key")
+			}
+
+			message := []byte("hello flow")
+			sig, err := signer.Sign(message)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hasher, err := crypto.NewHasher(hashAlgo)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			valid, err := flowAccountKey.PublicKey.Verify(sig, message, hasher)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !valid {
+				t.Errorf("expected a valid %s %s signature", signAlgo, hashAlgo)
+			}
+		}
+	}
+}
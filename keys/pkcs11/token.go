@@ -0,0 +1,188 @@
+//go:build cgo
+// +build cgo
+
+package pkcs11
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	p11 "github.com/miekg/pkcs11"
+)
+
+// token is a logged in PKCS#11 token. Login state is shared by all sessions
+// of the process, so the login session is kept open and each operation runs
+// in a session of its own.
+type token struct {
+	key   string
+	ctx   *p11.Ctx
+	slot  uint
+	login p11.SessionHandle
+}
+
+var (
+	tokensMu sync.Mutex
+	// A module may only be initialized once per process
+	modules = map[string]*p11.Ctx{}
+	tokens  = map[string]*token{}
+)
+
+// openToken returns the logged in token of the config.
+func openToken(cfg *configs.Config) (*token, error) {
+	if cfg.PKCS11ModulePath == "" {
+		return nil, fmt.Errorf("keys/pkcs11: PKCS11_MODULE_PATH is required")
+	}
+
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	key := fmt.Sprintf("%s|%d|%s", cfg.PKCS11ModulePath, cfg.PKCS11Slot, cfg.PKCS11TokenLabel)
+	if t, ok := tokens[key]; ok {
+		return t, nil
+	}
+
+	ctx, ok := modules[cfg.PKCS11ModulePath]
+	if !ok {
+		ctx = p11.New(cfg.PKCS11ModulePath)
+		if ctx == nil {
+			return nil, fmt.Errorf("keys/pkcs11: failed to load module %s", cfg.PKCS11ModulePath)
+		}
+
+		if err := ctx.Initialize(); err != nil && !isError(err, p11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+			ctx.Destroy()
+			return nil, fmt.Errorf("keys/pkcs11: failed to initialize module: %w", err)
+		}
+
+		modules[cfg.PKCS11ModulePath] = ctx
+	}
+
+	slot, err := findSlot(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pin, err := userPIN(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION|p11.CKF_RW_SESSION)
+	if err != nil {
+		return nil, fmt.Errorf("keys/pkcs11: failed to open session: %w", err)
+	}
+
+	if err := ctx.Login(session, p11.CKU_USER, pin); err != nil && !isError(err, p11.CKR_USER_ALREADY_LOGGED_IN) {
+		_ = ctx.CloseSession(session)
+		return nil, fmt.Errorf("keys/pkcs11: failed to log in: %w", err)
+	}
+
+	t := &token{key: key, ctx: ctx, slot: slot, login: session}
+	tokens[key] = t
+
+	return t, nil
+}
+
+// findSlot returns the slot of the token with the configured label, or the
+// configured slot ID.
+func findSlot(ctx *p11.Ctx, cfg *configs.Config) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("keys/pkcs11: failed to list slots: %w", err)
+	}
+
+	for _, slot := range slots {
+		if cfg.PKCS11TokenLabel == "" {
+			if slot == cfg.PKCS11Slot {
+				return slot, nil
+			}
+			continue
+		}
+
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("keys/pkcs11: failed to get token info: %w", err)
+		}
+
+		if tokenLabel(info.Label) == cfg.PKCS11TokenLabel {
+			return slot, nil
+		}
+	}
+
+	if cfg.PKCS11TokenLabel != "" {
+		return 0, fmt.Errorf("keys/pkcs11: token %q not found", cfg.PKCS11TokenLabel)
+	}
+
+	return 0, fmt.Errorf("keys/pkcs11: no token in slot %d", cfg.PKCS11Slot)
+}
+
+// withSession runs f in a new session. The token is dropped from the cache
+// if it's no longer usable, so that the next operation logs in again.
+func (t *token) withSession(f func(session p11.SessionHandle) error) error {
+	session, err := t.ctx.OpenSession(t.slot, p11.CKF_SERIAL_SESSION|p11.CKF_RW_SESSION)
+	if err != nil {
+		t.evict(err)
+		return fmt.Errorf("keys/pkcs11: failed to open session: %w", err)
+	}
+	defer t.ctx.CloseSession(session) // nolint
+
+	err = f(session)
+	if err != nil {
+		t.evict(err)
+	}
+
+	return err
+}
+
+func (t *token) evict(err error) {
+	if !isError(err, p11.CKR_USER_NOT_LOGGED_IN) &&
+		!isError(err, p11.CKR_SESSION_HANDLE_INVALID) &&
+		!isError(err, p11.CKR_SESSION_CLOSED) &&
+		!isError(err, p11.CKR_TOKEN_NOT_PRESENT) &&
+		!isError(err, p11.CKR_DEVICE_REMOVED) {
+		return
+	}
+
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	if tokens[t.key] == t {
+		delete(tokens, t.key)
+		_ = t.ctx.CloseSession(t.login)
+	}
+}
+
+// findObject returns the handle of the object with the given class and label.
+func findObject(ctx *p11.Ctx, session p11.SessionHandle, class uint, label string) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, class),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+	}
+
+	if err := ctx.FindObjectsInit(session, template); err != nil {
+		return 0, fmt.Errorf("keys/pkcs11: failed to find key: %w", err)
+	}
+
+	objects, _, err := ctx.FindObjects(session, 2)
+	if finalErr := ctx.FindObjectsFinal(session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("keys/pkcs11: failed to find key: %w", err)
+	}
+
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("keys/pkcs11: key %q not found", label)
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, fmt.Errorf("keys/pkcs11: more than one key labeled %q", label)
+	}
+}
+
+func isError(err error, code uint) bool {
+	var e p11.Error
+	return errors.As(err, &e) && uint(e) == code
+}
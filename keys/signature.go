@@ -0,0 +1,44 @@
+package keys
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+)
+
+// Source: flow-go-sdk/crypto/cloudkms/signer.go
+
+// ecCoupleComponentSize is size of a component in either (r,s) couple for an elliptical curve signature
+// or (x,y) identifying a public key. Component size is needed for encoding couples comprised of variable length
+// numbers to []byte encoding. They are not always the same length, so occasionally padding is required.
+// Here's how one calculates the required length of each component:
+// 		ECDSA_CurveBits = 256
+// 		ecCoupleComponentSize := ECDSA_CurveBits / 8
+// 		if ECDSA_CurveBits % 8 > 0 {
+//			ecCoupleComponentSize++
+// 		}
+const ecCoupleComponentSize = 32
+
+// ParseDERSignature converts an ASN.1 DER encoded ECDSA signature, as returned
+// by KMS services, to the raw r||s format used by Flow.
+func ParseDERSignature(signature []byte) ([]byte, error) {
+	var parsedSig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(signature, &parsedSig); err != nil {
+		return nil, fmt.Errorf("asn1.Unmarshal: %w", err)
+	}
+
+	rBytes := parsedSig.R.Bytes()
+	rBytesPadded := rightPad(rBytes, ecCoupleComponentSize)
+
+	sBytes := parsedSig.S.Bytes()
+	sBytesPadded := rightPad(sBytes, ecCoupleComponentSize)
+
+	return append(rBytesPadded, sBytesPadded...), nil
+}
+
+// rightPad pads a byte slice with empty bytes (0x00) to the given length.
+func rightPad(b []byte, length int) []byte {
+	padded := make([]byte, length)
+	copy(padded[length-len(b):], b)
+	return padded
+}
@@ -0,0 +1,161 @@
+package keys
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+// KeySpec describes a key to generate for an account, empty fields use the
+// application defaults.
+type KeySpec struct {
+	Type     string `json:"type,omitempty"`
+	Weight   int    `json:"weight,omitempty"`
+	SignAlgo string `json:"signAlgo,omitempty"`
+	HashAlgo string `json:"hashAlgo,omitempty"`
+}
+
+// String returns the spec in the "type:signAlgo:hashAlgo" form used in the
+// allowed key specs config.
+func (spec KeySpec) String() string {
+	return fmt.Sprintf("%s:%s:%s", spec.Type, spec.SignAlgo, spec.HashAlgo)
+}
+
+// ParseKeySpec parses a key spec in "type:signAlgo:hashAlgo" form.
+func ParseKeySpec(s string) (KeySpec, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 3 {
+		return KeySpec{}, fmt.Errorf("invalid key spec %q, expected type:signAlgo:hashAlgo", s)
+	}
+
+	spec := KeySpec{
+		Type:     parts[0],
+		SignAlgo: crypto.StringToSignatureAlgorithm(parts[1]).String(),
+		HashAlgo: crypto.StringToHashAlgorithm(parts[2]).String(),
+	}
+
+	if err := CheckSupported(spec); err != nil {
+		return KeySpec{}, err
+	}
+
+	return spec, nil
+}
+
+// ResolveKeySpec fills in the unset fields of spec with the configured
+// defaults and normalizes the algorithm names.
+func ResolveKeySpec(cfg *configs.Config, spec KeySpec) KeySpec {
+	if spec.Type == "" {
+		spec.Type = cfg.DefaultKeyType
+	}
+
+	if spec.Weight == 0 {
+		spec.Weight = cfg.DefaultKeyWeight
+	}
+
+	if spec.Weight < 0 {
+		spec.Weight = flow.AccountKeyWeightThreshold
+	}
+
+	if spec.SignAlgo == "" {
+		spec.SignAlgo = cfg.DefaultSignAlgo
+	}
+
+	if spec.HashAlgo == "" {
+		spec.HashAlgo = DefaultHashAlgo(cfg, spec.Type)
+	}
+
+	spec.SignAlgo = crypto.StringToSignatureAlgorithm(spec.SignAlgo).String()
+	spec.HashAlgo = crypto.StringToHashAlgorithm(spec.HashAlgo).String()
+
+	return spec
+}
+
+// DefaultHashAlgo returns the configured default hash algorithm, or the
+// default of the key type if it's not set.
+func DefaultHashAlgo(cfg *configs.Config, keyType string) string {
+	if cfg.DefaultHashAlgo != "" {
+		return cfg.DefaultHashAlgo
+	}
+
+	if b, err := LookupBackend(keyType); err == nil {
+		if d, ok := b.(HashDefaulter); ok {
+			return d.DefaultHashAlgorithm().String()
+		}
+	}
+
+	return crypto.SHA3_256.String()
+}
+
+// CheckSupported returns an error if the key type has no registered backend
+// or the backend can not generate keys with the algorithms of the spec.
+func CheckSupported(spec KeySpec) error {
+	b, err := LookupBackend(spec.Type)
+	if err != nil {
+		return err
+	}
+
+	signAlgo := crypto.StringToSignatureAlgorithm(spec.SignAlgo)
+	switch signAlgo {
+	case crypto.ECDSA_P256, crypto.ECDSA_secp256k1:
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", spec.SignAlgo)
+	}
+
+	hashAlgo := crypto.StringToHashAlgorithm(spec.HashAlgo)
+	switch hashAlgo {
+	case crypto.SHA2_256, crypto.SHA3_256:
+	default:
+		return fmt.Errorf("unsupported hash algorithm %q for %s", spec.HashAlgo, spec.Type)
+	}
+
+	if c, ok := b.(AlgorithmChecker); ok {
+		if err := c.CheckAlgorithms(signAlgo, hashAlgo); err != nil {
+			return fmt.Errorf("%w for %s", err, spec.Type)
+		}
+	}
+
+	return nil
+}
+
+// AllowedKeySpecs returns the key specs allowed in the config. Only the
+// default key spec is allowed if none are configured.
+func AllowedKeySpecs(cfg *configs.Config) ([]KeySpec, error) {
+	if len(cfg.AllowedKeySpecs) == 0 {
+		spec := ResolveKeySpec(cfg, KeySpec{})
+		if err := CheckSupported(spec); err != nil {
+			return nil, fmt.Errorf("invalid default key spec %s: %w", spec, err)
+		}
+		return []KeySpec{spec}, nil
+	}
+
+	specs := make([]KeySpec, 0, len(cfg.AllowedKeySpecs))
+	for _, s := range cfg.AllowedKeySpecs {
+		spec, err := ParseKeySpec(s)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
+
+// CheckAllowed returns an error if the resolved spec is not one of the
+// allowed key specs.
+func CheckAllowed(cfg *configs.Config, spec KeySpec) error {
+	allowed, err := AllowedKeySpecs(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range allowed {
+		if a.Type == spec.Type && a.SignAlgo == spec.SignAlgo && a.HashAlgo == spec.HashAlgo {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("key spec %s is not allowed", spec)
+}
@@ -0,0 +1,140 @@
+package keys_test
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	_ "github.com/flow-hydraulics/flow-wallet-api/keys/basic"
+)
+
+func TestParseKeySpec(t *testing.T) {
+	valid := []string{
+		"local:ECDSA_P256:SHA3_256",
+		"local:ECDSA_secp256k1:SHA2_256",
+		"aws_kms:ECDSA_secp256k1:SHA3_256",
+		" google_kms:ECDSA_P256:SHA2_256 ",
+		"vault_transit:ECDSA_P256:SHA3_256",
+		"pkcs11:ECDSA_secp256k1:SHA2_256",
+	}
+
+	for _, s := range valid {
+		if _, err := keys.ParseKeySpec(s); err != nil {
+			t.Errorf("expected %q to be valid, got %s", s, err)
+		}
+	}
+
+	invalid := []string{
+		"local:ECDSA_P256",
+		"unknown:ECDSA_P256:SHA3_256",
+		"local:BLS_BLS12_381:SHA3_256",
+		"local:ECDSA_P256:SHA2_384",
+		"google_kms:ECDSA_P256:SHA3_256",
+		"vault_transit:ECDSA_secp256k1:SHA3_256",
+	}
+
+	for _, s := range invalid {
+		if _, err := keys.ParseKeySpec(s); err == nil {
+			t.Errorf("expected %q to be invalid", s)
+		}
+	}
+}
+
+func TestCheckAllowed(t *testing.T) {
+	cfg := &configs.Config{
+		DefaultKeyType:   keys.AccountKeyTypeLocal,
+		DefaultKeyWeight: -1,
+		DefaultSignAlgo:  "ECDSA_P256",
+		DefaultHashAlgo:  "SHA3_256",
+	}
+
+	spec := keys.ResolveKeySpec(cfg, keys.KeySpec{})
+	if spec.String() != "local:ECDSA_P256:SHA3_256" || spec.Weight != 1000 {
+		t.Fatalf("expected the defaults, got %s with weight %d", spec, spec.Weight)
+	}
+
+	// Only the defaults are allowed without configuration
+	if err := keys.CheckAllowed(cfg, spec); err != nil {
+		t.Error(err)
+	}
+
+	other := keys.ResolveKeySpec(cfg, keys.KeySpec{SignAlgo: "ECDSA_secp256k1"})
+	if err := keys.CheckAllowed(cfg, other); err == nil {
+		t.Errorf("expected %s not to be allowed", other)
+	}
+
+	cfg.AllowedKeySpecs = []string{"local:ECDSA_secp256k1:SHA3_256"}
+
+	if err := keys.CheckAllowed(cfg, other); err != nil {
+		t.Error(err)
+	}
+
+	if err := keys.CheckAllowed(cfg, spec); err == nil {
+		t.Errorf("expected %s not to be allowed", spec)
+	}
+}
+
+func TestDefaultHashAlgo(t *testing.T) {
+	cfg := &configs.Config{
+		DefaultKeyType:   keys.AccountKeyTypeGoogleKMS,
+		AdminKeyType:     keys.AccountKeyTypeGoogleKMS,
+		DefaultKeyWeight: -1,
+		DefaultSignAlgo:  "ECDSA_P256",
+	}
+
+	// Google KMS only signs SHA2_256 digests, the others default to SHA3_256
+	if spec := keys.ResolveKeySpec(cfg, keys.KeySpec{}); spec.HashAlgo != "SHA2_256" {
+		t.Errorf("expected SHA2_256 for google_kms, got %s", spec.HashAlgo)
+	}
+
+	if spec := keys.ResolveKeySpec(cfg, keys.KeySpec{Type: keys.AccountKeyTypeLocal}); spec.HashAlgo != "SHA3_256" {
+		t.Errorf("expected SHA3_256 for local, got %s", spec.HashAlgo)
+	}
+
+	cfg.EncryptionKeyType = keys.AccountKeyTypeGoogleKMS
+	if err := keys.CheckConfig(cfg); err != nil {
+		t.Errorf("expected the google_kms defaults to be valid, got %s", err)
+	}
+
+	// An explicitly set hash algorithm is used for all key types
+	cfg.DefaultHashAlgo = "SHA3_256"
+	if spec := keys.ResolveKeySpec(cfg, keys.KeySpec{}); spec.HashAlgo != "SHA3_256" {
+		t.Errorf("expected the configured hash algorithm, got %s", spec.HashAlgo)
+	}
+
+	if err := keys.CheckConfig(cfg); err == nil {
+		t.Error("expected google_kms with SHA3_256 to be invalid")
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	cfg := &configs.Config{
+		DefaultKeyType:    keys.AccountKeyTypeLocal,
+		AdminKeyType:      keys.AccountKeyTypeLocal,
+		EncryptionKeyType: keys.AccountKeyTypeLocal,
+		DefaultKeyWeight:  -1,
+		DefaultSignAlgo:   "ECDSA_P256",
+		DefaultHashAlgo:   "SHA3_256",
+	}
+
+	if err := keys.CheckConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []func(cfg *configs.Config){
+		func(cfg *configs.Config) { cfg.DefaultKeyType = "unknown" },
+		func(cfg *configs.Config) { cfg.AdminKeyType = "unknown" },
+		func(cfg *configs.Config) { cfg.AdminKeyType = keys.AccountKeyTypeRecovery },
+		func(cfg *configs.Config) { cfg.EncryptionKeyType = keys.AccountKeyTypePKCS11 },
+		func(cfg *configs.Config) { cfg.AllowedKeySpecs = []string{"unknown:ECDSA_P256:SHA3_256"} },
+	}
+
+	for i, modify := range invalid {
+		c := *cfg
+		modify(&c)
+
+		if err := keys.CheckConfig(&c); err == nil {
+			t.Errorf("expected config %d to be invalid", i)
+		}
+	}
+}
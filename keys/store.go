@@ -1,8 +1,10 @@
 package keys
 
 // Store is the interface required by key manager for data storage.
+// Recovery keys are left out of the account keys as they can not sign.
 type Store interface {
 	AccountKey(address string) (Storable, error)
+	AccountKeys(address string) ([]Storable, error)
 	ProposalKeyIndex(limitKeyCount int) (int, error)
 	ProposalKeyCount() (int64, error)
 	InsertProposalKey(proposalKey ProposalKey) error
@@ -31,6 +31,7 @@ func (s *GormStore) AccountKey(address string) (Storable, error) {
 			// NOWAIT so this call will fail rather than use a stale value
 			Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
 			Where(&Storable{AccountAddress: address}).
+			Where("type <> ?", AccountKeyTypeRecovery).
 			Order("updated_at asc").
 			Limit(1).Find(&k).Error; err != nil {
 			return err
@@ -46,6 +47,15 @@ func (s *GormStore) AccountKey(address string) (Storable, error) {
 	return k, err
 }
 
+func (s *GormStore) AccountKeys(address string) (kk []Storable, err error) {
+	err = s.db.
+		Where(&Storable{AccountAddress: address}).
+		Where("type <> ?", AccountKeyTypeRecovery).
+		Order("updated_at asc").
+		Find(&kk).Error
+	return
+}
+
 func (s *GormStore) ProposalKeyIndex(limitKeyCount int) (int, error) {
 	s.proposalKeyMutex.Lock()
 	defer s.proposalKeyMutex.Unlock()
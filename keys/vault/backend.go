@@ -0,0 +1,39 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/flow-hydraulics/flow-wallet-api/keys/encryption"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+func init() {
+	keys.RegisterBackend(keys.AccountKeyTypeVaultTransit, backend{})
+}
+
+type backend struct{}
+
+func (backend) Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int, signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (*flow.AccountKey, *keys.Private, error) {
+	return Generate(cfg, ctx, keyIndex, weight, signAlgo, hashAlgo)
+}
+
+func (backend) Signer(cfg *configs.Config, ctx context.Context, key keys.Private) (crypto.Signer, error) {
+	return Signer(ctx, key)
+}
+
+func (backend) Crypter(cfg *configs.Config, key []byte) encryption.Crypter {
+	return NewVaultTransitCrypter(key)
+}
+
+// CheckAlgorithms allows ECDSA_P256 only, the transit engine has no
+// secp256k1 keys.
+func (backend) CheckAlgorithms(signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) error {
+	if signAlgo != crypto.ECDSA_P256 {
+		return fmt.Errorf("unsupported signature algorithm %q", signAlgo)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultAddress is the default address of the Vault CLI.
+const defaultAddress = "https://127.0.0.1:8200"
+
+// client is a minimal client for the Vault HTTP API. It is configured with
+// the standard Vault environment variables VAULT_ADDR, VAULT_TOKEN and
+// VAULT_NAMESPACE.
+type client struct {
+	address   string
+	token     string
+	namespace string
+	http      *http.Client
+}
+
+func newClient() (*client, error) {
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("keys/vault: VAULT_TOKEN is not set")
+	}
+
+	address := os.Getenv("VAULT_ADDR")
+	if address == "" {
+		address = defaultAddress
+	}
+
+	return &client{
+		address:   strings.TrimSuffix(address, "/"),
+		token:     token,
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+		http:      &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// response is the envelope of Vault API responses.
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []string        `json:"errors"`
+}
+
+// read sends a GET request to the path and decodes the response data to out.
+func (c *client) read(ctx context.Context, path string, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+// write sends a POST request with the body to the path and decodes the
+// response data to out, if it's not nil.
+func (c *client) write(ctx context.Context, path string, body, out interface{}) error {
+	return c.do(ctx, http.MethodPost, path, body, out)
+}
+
+func (c *client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/v1/%s", c.address, strings.TrimPrefix(path, "/")), reqBody)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Vault-Token", c.token)
+	req.Header.Set("X-Vault-Request", "true")
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("keys/vault: %w", err)
+	}
+	defer res.Body.Close()
+
+	var r response
+	if res.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(res.Body).Decode(&r); err != nil && err != io.EOF {
+			return fmt.Errorf("keys/vault: failed to decode response: %w", err)
+		}
+	}
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("keys/vault: %s %s: %d %s", method, path, res.StatusCode, strings.Join(r.Errors, ", "))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if len(r.Data) == 0 {
+		return fmt.Errorf("keys/vault: %s %s: empty response", method, path)
+	}
+
+	return json.Unmarshal(r.Data, out)
+}
+
+// splitKeyRef splits a signing key reference "<mount>/<key name>[:<version>]"
+// into the mount path, the key name and the version, which is 0 if not set.
+func splitKeyRef(ref string) (mount, name string, version int, err error) {
+	if i := strings.LastIndex(ref, ":"); i >= 0 {
+		version, err = strconv.Atoi(ref[i+1:])
+		if err != nil || version < 1 {
+			return "", "", 0, fmt.Errorf("keys/vault: invalid transit key version in %q", ref)
+		}
+		ref = ref[:i]
+	}
+
+	mount, name, err = splitKeyPath(ref)
+	return
+}
+
+// splitKeyPath splits a transit key reference "<mount>/<key name>" into the
+// mount path and the key name.
+func splitKeyPath(ref string) (mount, name string, err error) {
+	ref = strings.Trim(ref, "/")
+	i := strings.LastIndex(ref, "/")
+	if i <= 0 || i == len(ref)-1 {
+		return "", "", fmt.Errorf("keys/vault: invalid transit key %q, expected <mount>/<key name>", ref)
+	}
+	return ref[:i], ref[i+1:], nil
+}
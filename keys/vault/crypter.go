@@ -0,0 +1,77 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+)
+
+// VaultTransitCrypter encrypts and decrypts with a Vault transit key.
+type VaultTransitCrypter struct {
+	key string
+}
+
+// NewVaultTransitCrypter returns a crypter for the transit key reference
+// "<mount>/<key name>".
+func NewVaultTransitCrypter(key []byte) *VaultTransitCrypter {
+	return &VaultTransitCrypter{key: string(key)}
+}
+
+// Encrypt returns the Vault ciphertext ("vault:v1:...") of the message.
+func (c *VaultTransitCrypter) Encrypt(message []byte) (encrypted []byte, err error) {
+	ctx := context.Background()
+
+	mount, name, err := splitKeyPath(c.key)
+	if err != nil {
+		return encrypted, err
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return encrypted, err
+	}
+
+	var out struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+
+	err = client.write(ctx, fmt.Sprintf("%s/encrypt/%s", mount, name), map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(message),
+	}, &out)
+	if err != nil {
+		return encrypted, err
+	}
+
+	encrypted = []byte(out.Ciphertext)
+
+	return encrypted, err
+}
+
+func (c *VaultTransitCrypter) Decrypt(encrypted []byte) (message []byte, err error) {
+	ctx := context.Background()
+
+	mount, name, err := splitKeyPath(c.key)
+	if err != nil {
+		return message, err
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return message, err
+	}
+
+	var out struct {
+		Plaintext string `json:"plaintext"`
+	}
+
+	err = client.write(ctx, fmt.Sprintf("%s/decrypt/%s", mount, name), map[string]interface{}{
+		"ciphertext": string(encrypted),
+	}, &out)
+	if err != nil {
+		return message, err
+	}
+
+	message, err = base64.StdEncoding.DecodeString(out.Plaintext)
+
+	return message, err
+}
@@ -0,0 +1,219 @@
+// Package vault provides functions for key and signer generation and
+// encryption in HashiCorp Vault, using the transit secrets engine.
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+type transitKey struct {
+	Type          string `json:"type"`
+	LatestVersion int    `json:"latest_version"`
+	Keys          map[string]struct {
+		PublicKey string `json:"public_key"`
+	} `json:"keys"`
+}
+
+// Generate creates a new ECDSA P-256 signing key in the configured Vault
+// transit mount and returns the data required for account creation; a
+// flow.AccountKey and a private key. The private key has the transit key
+// reference "<mount>/<key name>:<version>" as the value. Vault signs digests
+// computed by the service, so both SHA2_256 and SHA3_256 can be used.
+func Generate(cfg *configs.Config, ctx context.Context, keyIndex, weight int, signAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm) (*flow.AccountKey, *keys.Private, error) {
+	if signAlgo != crypto.ECDSA_P256 {
+		return nil, nil, fmt.Errorf("unsupported signature algorithm for Vault transit: %s", signAlgo)
+	}
+
+	if hashAlgo != crypto.SHA2_256 && hashAlgo != crypto.SHA3_256 {
+		return nil, nil, fmt.Errorf("unsupported hash algorithm for Vault transit: %s", hashAlgo)
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mount := strings.Trim(cfg.VaultTransitMount, "/")
+	name := fmt.Sprintf("flow-wallet-account-key-%s", uuid.New().String())
+
+	// Create the new key in Vault, private keys are not exportable by default
+	if err := c.write(ctx, fmt.Sprintf("%s/keys/%s", mount, name), map[string]interface{}{"type": "ecdsa-p256"}, nil); err != nil {
+		return nil, nil, err
+	}
+
+	pbk, version, err := publicKey(ctx, c, mount, name, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f := flow.NewAccountKey().
+		SetPublicKey(pbk).
+		SetHashAlgo(hashAlgo).
+		SetWeight(weight)
+	f.Index = keyIndex
+
+	p := &keys.Private{
+		Index:    keyIndex,
+		Type:     keys.AccountKeyTypeVaultTransit,
+		Value:    fmt.Sprintf("%s/%s:%d", mount, name, version),
+		SignAlgo: signAlgo,
+		HashAlgo: hashAlgo,
+	}
+
+	return f, p, nil
+}
+
+// publicKey returns the public key of the given version of the transit key,
+// or of the latest version if version is 0, and the version.
+func publicKey(ctx context.Context, c *client, mount, name string, version int) (crypto.PublicKey, int, error) {
+	var k transitKey
+	if err := c.read(ctx, fmt.Sprintf("%s/keys/%s", mount, name), &k); err != nil {
+		return nil, 0, err
+	}
+
+	if k.Type != "ecdsa-p256" {
+		return nil, 0, fmt.Errorf("keys/vault: unsupported transit key type %q", k.Type)
+	}
+
+	if version == 0 {
+		version = k.LatestVersion
+	}
+
+	v, ok := k.Keys[strconv.Itoa(version)]
+	if !ok {
+		return nil, 0, fmt.Errorf("keys/vault: public key of version %d not found", version)
+	}
+
+	pbk, err := crypto.DecodePublicKeyPEM(crypto.ECDSA_P256, v.PublicKey)
+	if err != nil {
+		return nil, 0, fmt.Errorf("keys/vault: failed to decode public key: %w", err)
+	}
+
+	return pbk, version, nil
+}
+
+// Signer creates a crypto.Signer for the given private key
+// (transit key reference)
+func Signer(ctx context.Context, key keys.Private) (crypto.Signer, error) {
+	s, err := SignerForKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// VaultSigner is a Vault transit implementation of crypto.Signer.
+type VaultSigner struct {
+	ctx       context.Context
+	client    *client
+	mount     string
+	name      string
+	version   int
+	hasher    crypto.Hasher
+	publicKey crypto.PublicKey
+}
+
+// SignerForKey returns a new VaultSigner for the given private key. The
+// signer uses the version of the transit key in the key reference, or the
+// first version if there is none, so rotating the key in Vault does not
+// change the key used for signing.
+func SignerForKey(ctx context.Context, key keys.Private) (*VaultSigner, error) {
+	mount, name, version, err := splitKeyRef(key.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == 0 {
+		version = 1
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	pbk, _, err := publicKey(ctx, c, mount, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys stored before the hash algorithm was recorded use SHA3_256
+	hashAlgo := key.HashAlgo
+	if hashAlgo == crypto.UnknownHashAlgorithm {
+		hashAlgo = crypto.SHA3_256
+	}
+
+	hasher, err := crypto.NewHasher(hashAlgo)
+	if err != nil {
+		return nil, fmt.Errorf("keys/vault: failed to instantiate hasher: %w", err)
+	}
+
+	return &VaultSigner{
+		ctx:       ctx,
+		client:    c,
+		mount:     mount,
+		name:      name,
+		version:   version,
+		hasher:    hasher,
+		publicKey: pbk,
+	}, nil
+}
+
+// Sign signs the given message using the transit key of this signer. The
+// digest is computed locally and signed as prehashed input.
+//
+// Reference: https://www.vaultproject.io/api-docs/secret/transit#sign-data
+func (s *VaultSigner) Sign(message []byte) ([]byte, error) {
+	digest := s.hasher.ComputeHash(message)
+
+	var out struct {
+		Signature string `json:"signature"`
+	}
+
+	err := s.client.write(s.ctx, fmt.Sprintf("%s/sign/%s", s.mount, s.name), map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString(digest),
+		"key_version":          s.version,
+		"prehashed":            true,
+		"hash_algorithm":       "sha2-256", // Only tells the digest length when prehashed
+		"marshaling_algorithm": "asn1",
+	}, &out)
+	if err != nil {
+		return nil, fmt.Errorf("keys/vault: failed to sign: %w", err)
+	}
+
+	der, err := decodeVaultValue(out.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("keys/vault: failed to decode signature: %w", err)
+	}
+
+	sig, err := keys.ParseDERSignature(der)
+	if err != nil {
+		return nil, fmt.Errorf("keys/vault: failed to parse signature: %w", err)
+	}
+
+	return sig, nil
+}
+
+func (s *VaultSigner) PublicKey() crypto.PublicKey {
+	return s.publicKey
+}
+
+// decodeVaultValue decodes a "vault:v<version>:<base64>" value.
+func decodeVaultValue(v string) ([]byte, error) {
+	parts := strings.SplitN(v, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, fmt.Errorf("unexpected format")
+	}
+	return base64.StdEncoding.DecodeString(parts[2])
+}
@@ -0,0 +1,314 @@
+package vault
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+// fakeTransit is an in-memory stand-in for a Vault transit mount at "transit".
+// Each key holds its versions, oldest first.
+type fakeTransit struct {
+	mu   sync.Mutex
+	keys map[string][]*ecdsa.PrivateKey
+}
+
+func newFakeTransit(t *testing.T) *httptest.Server {
+	f := &fakeTransit{keys: map[string][]*ecdsa.PrivateKey{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (f *fakeTransit) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != "test-token" {
+		writeJSON(rw, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/transit/"), "/")
+	if len(parts) == 3 && parts[0] == "keys" && parts[2] == "rotate" {
+		parts = []string{"rotate", parts[1]}
+	}
+	if len(parts) != 2 {
+		writeJSON(rw, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+		return
+	}
+	op, name := parts[0], parts[1]
+
+	var body map[string]interface{}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(rw, http.StatusBadRequest, map[string]interface{}{"errors": []string{err.Error()}})
+			return
+		}
+	}
+
+	switch {
+	case (op == "keys" || op == "rotate") && r.Method == http.MethodPost:
+		pk, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		f.keys[name] = append(f.keys[name], pk)
+		rw.WriteHeader(http.StatusNoContent)
+	case op == "keys" && r.Method == http.MethodGet:
+		versions, ok := f.keys[name]
+		if !ok {
+			writeJSON(rw, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+			return
+		}
+		pbks := map[string]interface{}{}
+		for i, pk := range versions {
+			der, _ := x509.MarshalPKIXPublicKey(&pk.PublicKey)
+			pemStr := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+			pbks[strconv.Itoa(i+1)] = map[string]interface{}{"public_key": pemStr}
+		}
+		writeJSON(rw, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"type":           "ecdsa-p256",
+			"latest_version": len(versions),
+			"keys":           pbks,
+		}})
+	case op == "sign":
+		versions := f.keys[name]
+		version := len(versions)
+		if v, ok := body["key_version"].(float64); ok && v > 0 {
+			version = int(v)
+		}
+		if version > len(versions) {
+			writeJSON(rw, http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid key version"}})
+			return
+		}
+		digest, _ := base64.StdEncoding.DecodeString(body["input"].(string))
+		sig, _ := ecdsa.SignASN1(rand.Reader, versions[version-1], digest)
+		writeJSON(rw, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"signature": fmt.Sprintf("vault:v%d:%s", version, base64.StdEncoding.EncodeToString(sig)),
+		}})
+	case op == "encrypt":
+		writeJSON(rw, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"ciphertext": "vault:v1:" + body["plaintext"].(string),
+		}})
+	case op == "decrypt":
+		writeJSON(rw, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"plaintext": strings.TrimPrefix(body["ciphertext"].(string), "vault:v1:"),
+		}})
+	default:
+		writeJSON(rw, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(v)
+}
+
+// setupVault points the client to a Vault dev server if VAULT_ADDR and
+// VAULT_TOKEN are set, otherwise to an in-memory fake. The dev server needs
+// the transit secrets engine enabled at "transit".
+func setupVault(t *testing.T) {
+	if os.Getenv("VAULT_ADDR") != "" && os.Getenv("VAULT_TOKEN") != "" {
+		return
+	}
+
+	srv := newFakeTransit(t)
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "test-token")
+}
+
+func TestGenerateAndSign(t *testing.T) {
+	setupVault(t)
+
+	ctx := context.Background()
+	cfg := &configs.Config{VaultTransitMount: "transit"}
+
+	for _, hashAlgo := range []crypto.HashAlgorithm{crypto.SHA3_256, crypto.SHA2_256} {
+		flowAccountKey, privateKey, err := Generate(cfg, ctx, 0, 1000, crypto.ECDSA_P256, hashAlgo)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(privateKey.Value, "transit/flow-wallet-account-key-") || !strings.HasSuffix(privateKey.Value, ":1") {
+			t.Fatalf("expected a versioned transit key reference, got %s", privateKey.Value)
+		}
+
+		signer, err := Signer(ctx, *privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !signer.PublicKey().Equals(flowAccountKey.PublicKey) {
+			t.Fatal("expected the signer public key to match the account key")
+		}
+
+		message := []byte("hello flow")
+		sig, err := signer.Sign(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(sig) != 64 {
+			t.Fatalf("expected a raw r||s signature, got %d bytes", len(sig))
+		}
+
+		hasher, err := crypto.NewHasher(hashAlgo)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		valid, err := flowAccountKey.PublicKey.Verify(sig, message, hasher)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !valid {
+			t.Fatalf("expected a valid %s signature", hashAlgo)
+		}
+	}
+
+	if _, _, err := Generate(cfg, ctx, 0, 1000, crypto.ECDSA_secp256k1, crypto.SHA3_256); err == nil {
+		t.Error("expected secp256k1 to be unsupported")
+	}
+}
+
+func TestCrypter(t *testing.T) {
+	setupVault(t)
+
+	ctx := context.Background()
+	c, err := newClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.write(ctx, "transit/keys/flow-wallet-test-encryption-key", map[string]interface{}{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	crypter := NewVaultTransitCrypter([]byte("transit/flow-wallet-test-encryption-key"))
+
+	message := []byte("secret")
+
+	encrypted, err := crypter.Encrypt(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(encrypted), "vault:v") {
+		t.Fatalf("expected a Vault ciphertext, got %s", encrypted)
+	}
+
+	decrypted, err := crypter.Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(decrypted) != string(message) {
+		t.Fatalf("expected %s, got %s", message, decrypted)
+	}
+}
+
+func TestSignAfterRotation(t *testing.T) {
+	setupVault(t)
+
+	ctx := context.Background()
+	cfg := &configs.Config{VaultTransitMount: "transit"}
+
+	flowAccountKey, privateKey, err := Generate(cfg, ctx, 0, 1000, crypto.ECDSA_P256, crypto.SHA3_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := newClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mount, name, _, err := splitKeyRef(privateKey.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.write(ctx, fmt.Sprintf("%s/keys/%s/rotate", mount, name), map[string]interface{}{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The signer keeps using the version of the account key
+	signer, err := Signer(ctx, *privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !signer.PublicKey().Equals(flowAccountKey.PublicKey) {
+		t.Fatal("expected the signer public key to match the account key after rotation")
+	}
+
+	message := []byte("hello flow")
+	sig, err := signer.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hasher, err := crypto.NewHasher(crypto.SHA3_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if valid, err := flowAccountKey.PublicKey.Verify(sig, message, hasher); err != nil || !valid {
+		t.Fatalf("expected a valid signature after rotation, got %t %v", valid, err)
+	}
+}
+
+func TestSplitKeyRef(t *testing.T) {
+	mount, name, version, err := splitKeyRef("ns/transit/my-key:2")
+	if err != nil || mount != "ns/transit" || name != "my-key" || version != 2 {
+		t.Errorf("unexpected result: %s %s %d %v", mount, name, version, err)
+	}
+
+	if _, _, version, err := splitKeyRef("transit/my-key"); err != nil || version != 0 {
+		t.Errorf("expected no version, got %d %v", version, err)
+	}
+
+	for _, ref := range []string{"transit/my-key:", "transit/my-key:0", "transit/my-key:v1", "my-key:1"} {
+		if _, _, _, err := splitKeyRef(ref); err == nil {
+			t.Errorf("expected %q to be invalid", ref)
+		}
+	}
+}
+
+func TestSplitKeyPath(t *testing.T) {
+	mount, name, err := splitKeyPath("ns/transit/my-key")
+	if err != nil || mount != "ns/transit" || name != "my-key" {
+		t.Errorf("unexpected result: %s %s %v", mount, name, err)
+	}
+
+	for _, ref := range []string{"", "my-key", "transit/"} {
+		if _, _, err := splitKeyPath(ref); err == nil {
+			t.Errorf("expected %q to be invalid", ref)
+		}
+	}
+}
+
+func TestMissingToken(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "")
+
+	if _, err := Signer(context.Background(), keys.Private{Value: "transit/my-key"}); err == nil {
+		t.Error("expected an error without a token")
+	}
+}
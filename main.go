@@ -112,6 +112,7 @@ func runServer(cfg *configs.Config) {
 		jobs.WithDbJobPollInterval(cfg.DBJobPollInterval),
 		jobs.WithAcceptedGracePeriod(cfg.AcceptedGracePeriod),
 		jobs.WithReSchedulableGracePeriod(cfg.ReSchedulableGracePeriod),
+		jobs.WithReconcileInterval(cfg.ReconcileInterval),
 	)
 
 	defer func() {
@@ -122,13 +123,21 @@ func runServer(cfg *configs.Config) {
 	txRatelimiter := ratelimit.New(cfg.TransactionMaxSendRate, ratelimit.WithoutSlack)
 
 	// Key manager
-	km := basic.NewKeyManager(cfg, keys.NewGormStore(db), fc)
+	if err := keys.CheckConfig(cfg); err != nil {
+		log.Fatal(err)
+	}
+
+	km, err := basic.NewKeyManager(cfg, keys.NewGormStore(db), fc)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Services
 	templateService := templates.NewService(cfg, templates.NewGormStore(db))
 	jobsService := jobs.NewService(jobs.NewGormStore(db))
-	transactionService := transactions.NewService(cfg, transactions.NewGormStore(db), km, fc, wp, transactions.WithTxRatelimiter(txRatelimiter))
-	accountService := accounts.NewService(cfg, accounts.NewGormStore(db), km, fc, wp, transactionService, accounts.WithTxRatelimiter(txRatelimiter))
+	accountStore := accounts.NewGormStore(db)
+	transactionService := transactions.NewService(cfg, transactions.NewGormStore(db), km, fc, wp, transactions.WithTxRatelimiter(txRatelimiter), transactions.WithSignerCheck(accounts.SignerCheck(accountStore)))
+	accountService := accounts.NewService(cfg, accountStore, km, fc, wp, transactionService, templateService, accounts.WithTxRatelimiter(txRatelimiter))
 	tokenService := tokens.NewService(cfg, tokens.NewGormStore(db), km, fc, wp, transactionService, templateService, accountService)
 
 	// Register a handler for account added events
@@ -142,6 +151,10 @@ func runServer(cfg *configs.Config) {
 		log.Fatal(err)
 	}
 
+	if err := accountService.CheckFundingAccount(); err != nil {
+		log.Fatal(err)
+	}
+
 	wp.Start()
 	log.Info("Started workerpool")
 
@@ -172,6 +185,8 @@ func runServer(cfg *configs.Config) {
 	rv.Handle("/system/settings", systemHandler.SetSettings()).Methods(http.MethodPost)
 
 	rv.Handle("/system/sync-account-key-count", accountHandler.SyncAccountKeyCount()).Methods(http.MethodPost)
+	rv.Handle("/system/sync-account-key-count/{jobId}", accountHandler.KeyDriftReport()).Methods(http.MethodGet)
+	rv.Handle("/system/rotate-account-keys", accountHandler.RotateAccountKeys()).Methods(http.MethodPost)
 
 	// Jobs
 	rv.Handle("/jobs", jobsHandler.List()).Methods(http.MethodGet)            // list
@@ -189,19 +204,27 @@ func runServer(cfg *configs.Config) {
 
 	// Transactions
 	rv.Handle("/transactions", transactionHandler.List()).Methods(http.MethodGet)                    // list
+	rv.Handle("/transactions/fees", transactionHandler.Fees()).Methods(http.MethodGet)               // fee summaries
 	rv.Handle("/transactions/{transactionId}", transactionHandler.Details()).Methods(http.MethodGet) // details
 
 	// Account
-	rv.Handle("/accounts", accountHandler.List()).Methods(http.MethodGet)              // list
-	rv.Handle("/accounts", accountHandler.Create()).Methods(http.MethodPost)           // create
-	rv.Handle("/accounts/{address}", accountHandler.Details()).Methods(http.MethodGet) // details
+	rv.Handle("/accounts", accountHandler.List()).Methods(http.MethodGet)                                    // list
+	rv.Handle("/accounts", accountHandler.Create()).Methods(http.MethodPost)                                 // create
+	rv.Handle("/accounts/import", accountHandler.ImportAccount()).Methods(http.MethodPost)                   // import
+	rv.Handle("/accounts/{address}", accountHandler.Details()).Methods(http.MethodGet)                       // details
+	rv.Handle("/accounts/{address}", accountHandler.UpdateMetadata()).Methods(http.MethodPatch)              // update metadata
+	rv.Handle("/accounts/{address}/state", accountHandler.SetState()).Methods(http.MethodPut)                // set state
+	rv.Handle("/accounts/{address}/recovery-keys", accountHandler.AddRecoveryKey()).Methods(http.MethodPost) // add recovery key
+	rv.Handle("/accounts/{address}/export", accountHandler.Export()).Methods(http.MethodPost)                // export
 
 	// Account raw transactions
 	if !cfg.DisableRawTransactions {
 		rv.Handle("/accounts/{address}/sign", transactionHandler.Sign()).Methods(http.MethodPost)                           // sign
+		rv.Handle("/accounts/{address}/submit", transactionHandler.Submit()).Methods(http.MethodPost)                       // submit externally signed
 		rv.Handle("/accounts/{address}/transactions", transactionHandler.List()).Methods(http.MethodGet)                    // list
 		rv.Handle("/accounts/{address}/transactions", transactionHandler.Create()).Methods(http.MethodPost)                 // create
 		rv.Handle("/accounts/{address}/transactions/{transactionId}", transactionHandler.Details()).Methods(http.MethodGet) // details
+		rv.Handle("/transactions/batch", transactionHandler.CreateBatch()).Methods(http.MethodPost)                         // create batch
 	} else {
 		log.Info("raw transactions disabled")
 	}
@@ -348,10 +371,11 @@ func runServer(cfg *configs.Config) {
 
 		// Register a handler for chain events
 		chain_events.ChainEvent.Register(&tokens.ChainEventHandler{
-			AccountService:  accountService,
-			ChainListener:   listener,
-			TemplateService: templateService,
-			TokenService:    tokenService,
+			AccountService:     accountService,
+			ChainListener:      listener,
+			TemplateService:    templateService,
+			TokenService:       tokenService,
+			TransactionService: transactionService,
 		})
 
 		listener.Start()
@@ -643,6 +643,20 @@ func TestTransactionHandlers(t *testing.T) {
 			expected: `(?m)^\[{\"transactionId\":.*\]$`,
 			status:   http.StatusOK,
 		},
+		{
+			name:     "list by status",
+			method:   http.MethodGet,
+			url:      "/?status=sealed",
+			expected: `(?m)^\[{\"transactionId\":\"\w+\",\"transactionType\":\"\w+\",\"status\":\"SEALED\".*\]$`,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "list invalid status",
+			method:   http.MethodGet,
+			url:      "/?status=invalid",
+			expected: "invalid transaction status",
+			status:   http.StatusBadRequest,
+		},
 		{
 			name:     "details invalid id",
 			method:   http.MethodGet,
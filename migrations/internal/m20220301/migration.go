@@ -0,0 +1,41 @@
+// m20220301 handles adding the `PreviousTransactionId` field to Transaction
+package m20220301
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const ID = "20220301"
+
+type Transaction struct {
+	TransactionId         string         `gorm:"column:transaction_id;primaryKey"`
+	PreviousTransactionId string         `gorm:"column:previous_transaction_id;index"`
+	TransactionType       int            `gorm:"column:transaction_type;index"`
+	ProposerAddress       string         `gorm:"column:proposer_address;index"`
+	FlowTransaction       []byte         `gorm:"column:flow_transaction;type:bytes"`
+	CreatedAt             time.Time      `gorm:"column:created_at"`
+	UpdatedAt             time.Time      `gorm:"column:updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Transaction{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropColumn(&Transaction{}, "previous_transaction_id"); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+// m20220302 handles adding the on-chain status fields to Transaction
+package m20220302
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const ID = "20220302"
+
+type Transaction struct {
+	TransactionId         string         `gorm:"column:transaction_id;primaryKey"`
+	PreviousTransactionId string         `gorm:"column:previous_transaction_id;index"`
+	TransactionType       int            `gorm:"column:transaction_type;index"`
+	ProposerAddress       string         `gorm:"column:proposer_address;index"`
+	FlowTransaction       []byte         `gorm:"column:flow_transaction;type:bytes"`
+	Status                string         `gorm:"column:status;default:PENDING;index"`
+	ErrorMessage          string         `gorm:"column:error_message"`
+	BlockHeight           uint64         `gorm:"column:block_height"`
+	CreatedAt             time.Time      `gorm:"column:created_at"`
+	UpdatedAt             time.Time      `gorm:"column:updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Transaction{}); err != nil {
+		return err
+	}
+
+	// Transactions of unfinished jobs may still be in flight, they are kept
+	// pending so that the reconciler resolves their status. The results of
+	// the other existing transactions are not known, mark them so that they
+	// are not polled as pending.
+	unfinishedJobs := tx.
+		Table("jobs").
+		Select("1").
+		Where("jobs.transaction_id = transactions.transaction_id").
+		Where("jobs.state IN ?", []string{"INIT", "ACCEPTED", "NO_AVAILABLE_WORKERS", "ERROR"}).
+		Where("jobs.deleted_at IS NULL")
+
+	if err := tx.
+		Model(&Transaction{}).
+		Where("status = ?", "PENDING").
+		Where("NOT EXISTS (?)", unfinishedJobs).
+		Update("status", "UNKNOWN").Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	for _, c := range []string{"status", "error_message", "block_height"} {
+		if err := tx.Migrator().DropColumn(&Transaction{}, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+// m20220303 handles adding the `transaction_events` table and the
+// `EventsStored` field to Transaction
+package m20220303
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const ID = "20220303"
+
+type Transaction struct {
+	TransactionId         string         `gorm:"column:transaction_id;primaryKey"`
+	PreviousTransactionId string         `gorm:"column:previous_transaction_id;index"`
+	TransactionType       int            `gorm:"column:transaction_type;index"`
+	ProposerAddress       string         `gorm:"column:proposer_address;index"`
+	FlowTransaction       []byte         `gorm:"column:flow_transaction;type:bytes"`
+	Status                string         `gorm:"column:status;default:PENDING;index"`
+	ErrorMessage          string         `gorm:"column:error_message"`
+	BlockHeight           uint64         `gorm:"column:block_height"`
+	EventsStored          bool           `gorm:"column:events_stored"`
+	CreatedAt             time.Time      `gorm:"column:created_at"`
+	UpdatedAt             time.Time      `gorm:"column:updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+type Event struct {
+	TransactionId    string    `gorm:"column:transaction_id;primaryKey"`
+	EventIndex       int       `gorm:"column:event_index;primaryKey;autoIncrement:false"`
+	TransactionIndex int       `gorm:"column:transaction_index"`
+	Type             string    `gorm:"column:type;index"`
+	Payload          []byte    `gorm:"column:payload;type:bytes"`
+	CreatedAt        time.Time `gorm:"column:created_at"`
+}
+
+func (Event) TableName() string {
+	return "transaction_events"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Transaction{}, &Event{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&Event{}); err != nil {
+		return err
+	}
+
+	if err := tx.Migrator().DropColumn(&Transaction{}, "events_stored"); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+// m20220304 handles adding the `ExternallySigned` field to Transaction
+package m20220304
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const ID = "20220304"
+
+type Transaction struct {
+	TransactionId         string         `gorm:"column:transaction_id;primaryKey"`
+	PreviousTransactionId string         `gorm:"column:previous_transaction_id;index"`
+	TransactionType       int            `gorm:"column:transaction_type;index"`
+	ProposerAddress       string         `gorm:"column:proposer_address;index"`
+	FlowTransaction       []byte         `gorm:"column:flow_transaction;type:bytes"`
+	Status                string         `gorm:"column:status;default:PENDING;index"`
+	ErrorMessage          string         `gorm:"column:error_message"`
+	BlockHeight           uint64         `gorm:"column:block_height"`
+	EventsStored          bool           `gorm:"column:events_stored"`
+	ExternallySigned      bool           `gorm:"column:externally_signed"`
+	CreatedAt             time.Time      `gorm:"column:created_at"`
+	UpdatedAt             time.Time      `gorm:"column:updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Transaction{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropColumn(&Transaction{}, "externally_signed"); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+// m20220305 handles adding the fee and payer fields to Transaction
+package m20220305
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const ID = "20220305"
+
+type Transaction struct {
+	TransactionId         string         `gorm:"column:transaction_id;primaryKey"`
+	PreviousTransactionId string         `gorm:"column:previous_transaction_id;index"`
+	TransactionType       int            `gorm:"column:transaction_type;index"`
+	ProposerAddress       string         `gorm:"column:proposer_address;index"`
+	PayerAddress          string         `gorm:"column:payer_address;index"`
+	FlowTransaction       []byte         `gorm:"column:flow_transaction;type:bytes"`
+	Status                string         `gorm:"column:status;default:PENDING;index"`
+	ErrorMessage          string         `gorm:"column:error_message"`
+	BlockHeight           uint64         `gorm:"column:block_height"`
+	EventsStored          bool           `gorm:"column:events_stored"`
+	ExternallySigned      bool           `gorm:"column:externally_signed"`
+	FeeAmount             uint64         `gorm:"column:fee_amount"`
+	InclusionEffort       uint64         `gorm:"column:inclusion_effort"`
+	ExecutionEffort       uint64         `gorm:"column:execution_effort"`
+	CreatedAt             time.Time      `gorm:"column:created_at"`
+	UpdatedAt             time.Time      `gorm:"column:updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Transaction{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	for _, column := range []string{"payer_address", "fee_amount", "inclusion_effort", "execution_effort"} {
+		if err := tx.Migrator().DropColumn(&Transaction{}, column); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+// m20220306 handles adding metadata fields to Account and the AccountLabel table
+package m20220306
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const ID = "20220306"
+
+type Account struct {
+	Address    string         `gorm:"primaryKey"`
+	Type       string         `gorm:"default:custodial"`
+	ExternalID *string        `gorm:"column:external_id;uniqueIndex"`
+	Attributes datatypes.JSON `gorm:"column:attributes"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+func (Account) TableName() string {
+	return "accounts"
+}
+
+type AccountLabel struct {
+	AccountAddress string `gorm:"column:account_address;primaryKey"`
+	Label          string `gorm:"column:label;primaryKey;index"`
+}
+
+func (AccountLabel) TableName() string {
+	return "account_labels"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Account{}, &AccountLabel{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&AccountLabel{}); err != nil {
+		return err
+	}
+
+	if err := tx.Migrator().DropIndex(&Account{}, "ExternalID"); err != nil {
+		return err
+	}
+
+	for _, column := range []string{"external_id", "attributes"} {
+		if err := tx.Migrator().DropColumn(&Account{}, column); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
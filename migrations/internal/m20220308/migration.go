@@ -0,0 +1,47 @@
+// m20220308 handles adding the state field to Account
+package m20220308
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const ID = "20220308"
+
+type Account struct {
+	Address    string         `gorm:"primaryKey"`
+	Type       string         `gorm:"default:custodial"`
+	State      string         `gorm:"column:state;default:active;index"`
+	ExternalID *string        `gorm:"column:external_id;uniqueIndex"`
+	Attributes datatypes.JSON `gorm:"column:attributes"`
+	Pooled     bool           `gorm:"column:pooled;index"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+func (Account) TableName() string {
+	return "accounts"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Account{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropIndex(&Account{}, "State"); err != nil {
+		return err
+	}
+
+	if err := tx.Migrator().DropColumn(&Account{}, "state"); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+// m20220309 handles adding the BatchReference table
+package m20220309
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const ID = "20220309"
+
+type BatchReference struct {
+	Reference     string    `gorm:"column:reference;primaryKey"`
+	JobID         uuid.UUID `gorm:"column:job_id;type:uuid"`
+	TransactionId string    `gorm:"column:transaction_id;index"`
+	CreatedAt     time.Time `gorm:"column:created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at"`
+}
+
+func (BatchReference) TableName() string {
+	return "transaction_batch_references"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&BatchReference{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&BatchReference{}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+// m20220310 handles adding the KeyDriftReport table
+package m20220310
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const ID = "20220310"
+
+type KeyDriftReport struct {
+	JobID     uuid.UUID      `gorm:"column:job_id;primaryKey;type:uuid"`
+	Address   string         `gorm:"column:address;index"`
+	Report    datatypes.JSON `gorm:"column:report"`
+	CreatedAt time.Time      `gorm:"column:created_at"`
+	UpdatedAt time.Time      `gorm:"column:updated_at"`
+}
+
+func (KeyDriftReport) TableName() string {
+	return "account_key_drift_reports"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&KeyDriftReport{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&KeyDriftReport{}); err != nil {
+		return err
+	}
+
+	return nil
+}
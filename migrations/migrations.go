@@ -11,6 +11,16 @@ import (
 	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20211221_1"
 	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20211221_2"
 	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220212"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220301"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220302"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220303"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220304"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220305"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220306"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220307"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220308"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220309"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220310"
 	"github.com/go-gormigrate/gormigrate/v2"
 )
 
@@ -66,6 +76,56 @@ func List() []*gormigrate.Migration {
 			Migrate:  m20220212.Migrate,
 			Rollback: m20220212.Rollback,
 		},
+		{
+			ID:       m20220301.ID,
+			Migrate:  m20220301.Migrate,
+			Rollback: m20220301.Rollback,
+		},
+		{
+			ID:       m20220302.ID,
+			Migrate:  m20220302.Migrate,
+			Rollback: m20220302.Rollback,
+		},
+		{
+			ID:       m20220303.ID,
+			Migrate:  m20220303.Migrate,
+			Rollback: m20220303.Rollback,
+		},
+		{
+			ID:       m20220304.ID,
+			Migrate:  m20220304.Migrate,
+			Rollback: m20220304.Rollback,
+		},
+		{
+			ID:       m20220305.ID,
+			Migrate:  m20220305.Migrate,
+			Rollback: m20220305.Rollback,
+		},
+		{
+			ID:       m20220306.ID,
+			Migrate:  m20220306.Migrate,
+			Rollback: m20220306.Rollback,
+		},
+		{
+			ID:       m20220307.ID,
+			Migrate:  m20220307.Migrate,
+			Rollback: m20220307.Rollback,
+		},
+		{
+			ID:       m20220308.ID,
+			Migrate:  m20220308.Migrate,
+			Rollback: m20220308.Rollback,
+		},
+		{
+			ID:       m20220309.ID,
+			Migrate:  m20220309.Migrate,
+			Rollback: m20220309.Rollback,
+		},
+		{
+			ID:       m20220310.ID,
+			Migrate:  m20220310.Migrate,
+			Rollback: m20220310.Rollback,
+		},
 	}
 	return ms
 }
@@ -74,6 +74,50 @@ transaction {
 }
 `
 
+// RotateAccountKeyTransaction adds counts[i] copies of each key and revokes
+// the keys at the given indices. It is also used to reconcile the key count
+// and to add recovery keys.
+const RotateAccountKeyTransaction = `
+transaction(publicKeys: [String], signatureAlgorithms: [UInt8], hashAlgorithms: [UInt8], weights: [UFix64], counts: [Int], revokeKeyIndices: [Int]) {
+  prepare(signer: AuthAccount) {
+    var k = 0
+    while k < publicKeys.length {
+      let key = PublicKey(
+        publicKey: publicKeys[k].decodeHex(),
+        signatureAlgorithm: SignatureAlgorithm(rawValue: signatureAlgorithms[k])!
+      )
+
+      var i = 0
+      while i < counts[k] {
+        signer.keys.add(
+          publicKey: key,
+          hashAlgorithm: HashAlgorithm(rawValue: hashAlgorithms[k])!,
+          weight: weights[k]
+        )
+        i = i + 1
+      }
+
+      k = k + 1
+    }
+
+    for keyIndex in revokeKeyIndices {
+      signer.keys.revoke(keyIndex: keyIndex)
+    }
+  }
+}
+`
+
+// RevokeAccountKeysTransaction revokes the keys at the given indices.
+const RevokeAccountKeysTransaction = `
+transaction(revokeKeyIndices: [Int]) {
+  prepare(signer: AuthAccount) {
+    for keyIndex in revokeKeyIndices {
+      signer.keys.revoke(keyIndex: keyIndex)
+    }
+  }
+}
+`
+
 const AddProposalKeyTransaction = `
 transaction(adminKeyIndex: Int, numProposalKeys: UInt16) {
   prepare(account: AuthAccount) {
@@ -2,10 +2,29 @@ package tests
 
 import (
 	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/flow-hydraulics/flow-wallet-api/accounts"
+	"github.com/flow-hydraulics/flow-wallet-api/datastore"
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/flow-hydraulics/flow-wallet-api/templates/template_strings"
 	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
+	"github.com/flow-hydraulics/flow-wallet-api/tokens"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
+	"github.com/google/uuid"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
 )
 
 func Test_Add_New_Non_Custodial_Account(t *testing.T) {
@@ -134,9 +153,9 @@ func Test_Add_Multiple_New_Custodial_Accounts(t *testing.T) {
 		t.Skip("skipped as \"cfg.AdminProposalKeyCount\" is less than or equal to 1")
 	}
 
-	if accounts, err := svcs[0].GetAccounts().List(0, 0); err != nil {
+	if aa, err := svcs[0].GetAccounts().List(0, 0, accounts.AccountFilter{}); err != nil {
 		t.Fatal(err)
-	} else if len(accounts) > 1 {
+	} else if len(aa) > 1 {
 		t.Fatal("expected there to be only 1 account")
 	}
 
@@ -171,9 +190,1057 @@ func Test_Add_Multiple_New_Custodial_Accounts(t *testing.T) {
 	default:
 	}
 
-	if accounts, err := svcs[0].GetAccounts().List(0, 0); err != nil {
+	if aa, err := svcs[0].GetAccounts().List(0, 0, accounts.AccountFilter{}); err != nil {
 		t.Fatal(err)
-	} else if len(accounts) < 1+accountsToCreate {
+	} else if len(aa) < 1+accountsToCreate {
 		t.Fatalf("expected there to be %d accounts", 1+accountsToCreate)
 	}
 }
+
+func Test_AccountMetadata(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	svc := test.GetServices(t, cfg).GetAccounts()
+
+	externalID := "user-1"
+	labels := []string{"customer", "vip", "customer"}
+
+	a, err := svc.AddNonCustodialAccount("0x0123456789", accounts.WithMetadata(accounts.Metadata{
+		ExternalID: &externalID,
+		Labels:     &labels,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.Labels) != 2 {
+		t.Fatalf("expected duplicate labels to be removed, got %v", a.Labels)
+	}
+
+	// A retried create must not produce a second account for the same external ID
+	_, err = svc.AddNonCustodialAccount("0x0123456788", accounts.WithMetadata(accounts.Metadata{ExternalID: &externalID}))
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusConflict {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+
+	// Nil fields are left unchanged
+	attrs := json.RawMessage(`{"tier":"gold"}`)
+	updated, err := svc.UpdateMetadata(a.Address, accounts.Metadata{Attributes: attrs})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated.ExternalID == nil || *updated.ExternalID != externalID {
+		t.Fatalf("expected external id to be unchanged, got %v", updated.ExternalID)
+	}
+
+	if len(updated.Labels) != 2 {
+		t.Fatalf("expected labels to be unchanged, got %v", updated.Labels)
+	}
+
+	aa, err := svc.List(0, 0, accounts.AccountFilter{Labels: []string{"vip"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(aa) != 1 || aa[0].Address != a.Address || string(aa[0].Attributes) != string(attrs) {
+		t.Fatalf("expected the updated account, got %v", aa)
+	}
+
+	// Null attributes clear the stored values
+	var nullAttributes accounts.Metadata
+	if err := json.Unmarshal([]byte(`{"attributes":null}`), &nullAttributes); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err = svc.UpdateMetadata(a.Address, nullAttributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated.Attributes != nil || len(updated.Labels) != 2 {
+		t.Fatalf("expected only the attributes to be cleared, got %+v", updated)
+	}
+}
+
+func Test_AccountStoreFilters(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	store := accounts.NewGormStore(test.GetDatabase(t, cfg))
+
+	id1, id2 := "user-1", "user-2"
+
+	aa := []accounts.Account{
+		{Address: "0x01", ExternalID: &id1, Labels: []string{"customer", "vip"}},
+		{Address: "0x02", ExternalID: &id2, Labels: []string{"customer"}},
+		{Address: "0x03"},
+	}
+
+	for i := range aa {
+		if err := store.InsertAccount(&aa[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The external ID is unique
+	if err := store.InsertAccount(&accounts.Account{Address: "0x04", ExternalID: &id1}); err == nil {
+		t.Fatal("expected an error when inserting a duplicate external id")
+	}
+
+	testCases := []struct {
+		name     string
+		filter   accounts.AccountFilter
+		expected []string
+	}{
+		{name: "no filter", filter: accounts.AccountFilter{}, expected: []string{"0x01", "0x02", "0x03"}},
+		{name: "external id", filter: accounts.AccountFilter{ExternalID: id2}, expected: []string{"0x02"}},
+		{name: "label", filter: accounts.AccountFilter{Labels: []string{"customer"}}, expected: []string{"0x01", "0x02"}},
+		{name: "all labels", filter: accounts.AccountFilter{Labels: []string{"customer", "vip"}}, expected: []string{"0x01"}},
+		{name: "external id and label", filter: accounts.AccountFilter{ExternalID: id2, Labels: []string{"vip"}}, expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := store.Accounts(datastore.ListOptions{Limit: 10}, tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			addresses := []string{}
+			for _, a := range result {
+				addresses = append(addresses, a.Address)
+			}
+			sort.Strings(addresses)
+
+			if strings.Join(addresses, ",") != strings.Join(tc.expected, ",") {
+				t.Fatalf("expected %v, got %v", tc.expected, addresses)
+			}
+		})
+	}
+
+	a, err := store.Account("0x01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(a.Labels, ",") != "customer,vip" {
+		t.Fatalf("expected labels to be loaded, got %v", a.Labels)
+	}
+
+	a.ExternalID = nil
+	a.Labels = []string{"archived"}
+	if err := store.UpdateAccountMetadata(&a); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err = store.Account("0x01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.ExternalID != nil || strings.Join(a.Labels, ",") != "archived" {
+		t.Fatalf("expected updated metadata, got %v %v", a.ExternalID, a.Labels)
+	}
+}
+
+func Test_Create_Account_With_Tokens(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+
+	_, a, err := svcs.GetAccounts().Create(context.Background(), true, accounts.WithTokens("fusd", "FlowToken"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The vault is usable without a separate setup transaction
+	if _, err := svcs.GetTokens().Details(context.Background(), "FUSD", a.Address); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := svcs.GetTokens().Setup(context.Background(), true, "FUSD", a.Address); err == nil || !strings.Contains(err.Error(), "vault exists") {
+		t.Fatalf("expected vault to exist, got %v", err)
+	}
+
+	_, _, err = svcs.GetAccounts().Create(context.Background(), true, accounts.WithTokens("NotEnabled"))
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a bad request error, got %v", err)
+	}
+}
+
+func Test_Create_Account_With_Initial_Funding(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+
+	_, a, err := svcs.GetAccounts().Create(context.Background(), true, accounts.WithInitialFunding("FlowToken", "0.5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Funding is transferred by an initial funding job and recorded as a withdrawal
+	// from the admin account
+	for i := 0; ; i++ {
+		ww, err := svcs.GetTokens().ListWithdrawals(cfg.AdminAddress, "FlowToken")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found := false
+		for _, w := range ww {
+			found = found || (w.RecipientAddress == a.Address && w.FtAmount == "0.50000000")
+		}
+
+		if found {
+			break
+		}
+
+		if i > 60 {
+			t.Fatal("expected the initial funding to be recorded")
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func Test_Create_Account_Records_Transaction(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+
+	job, _, err := svcs.GetAccounts().Create(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err = test.WaitForJob(svcs.GetJobs(), job.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The account creation transaction is stored for its fees
+	tx, err := svcs.GetTransactions().Details(context.Background(), job.TransactionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.PayerAddress != cfg.AdminAddress || tx.Status != transactions.StatusSealed {
+		t.Fatalf("expected a sealed transaction paid by the admin account, got %+v", tx)
+	}
+}
+
+func Test_AccountStorePool(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	store := accounts.NewGormStore(test.GetDatabase(t, cfg))
+
+	day := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	aa := []accounts.Account{
+		{Address: "0x01", Pooled: true, CreatedAt: day.Add(time.Hour)},
+		{Address: "0x02", Pooled: true, CreatedAt: day},
+		{Address: "0x03"},
+	}
+
+	for i := range aa {
+		if err := store.InsertAccount(&aa[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := store.PooledAccountCount(); err != nil || n != 2 {
+		t.Fatalf("expected 2 pooled accounts, got %d %v", n, err)
+	}
+
+	// Pooled accounts are not listed
+	if listed, err := store.Accounts(datastore.ListOptions{Limit: 10}, accounts.AccountFilter{}); err != nil || len(listed) != 1 {
+		t.Fatalf("expected only the assigned account to be listed, got %v %v", listed, err)
+	}
+
+	externalID := "user-1"
+	a := accounts.Account{ExternalID: &externalID, Labels: []string{"customer"}}
+	if err := store.AssignPooledAccount(&a); err != nil {
+		t.Fatal(err)
+	}
+
+	// Oldest pooled account first
+	if a.Address != "0x02" || a.Pooled || a.ExternalID == nil || *a.ExternalID != externalID || len(a.Labels) != 1 {
+		t.Fatalf("expected the oldest pooled account with metadata, got %+v", a)
+	}
+
+	// The creation time of the account is kept
+	if !a.CreatedAt.Equal(day) {
+		t.Fatalf("expected created at %s, got %s", day, a.CreatedAt)
+	}
+
+	if found, err := store.AccountByExternalID(externalID); err != nil || found.Address != "0x02" {
+		t.Fatalf("expected assigned account to be stored, got %v %v", found, err)
+	}
+
+	if err := store.AssignPooledAccount(&accounts.Account{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.AssignPooledAccount(&accounts.Account{}); err == nil || !strings.Contains(err.Error(), "record not found") {
+		t.Fatalf("expected an empty pool, got %v", err)
+	}
+
+	if n, err := store.PooledAccountCount(); err != nil || n != 0 {
+		t.Fatalf("expected no pooled accounts, got %d %v", n, err)
+	}
+}
+
+func Test_AccountStorePendingPoolRefillJobs(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	store := accounts.NewGormStore(db)
+	jobStore := jobs.NewGormStore(db)
+
+	day := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	jj := []jobs.Job{
+		{Type: accounts.AccountPoolRefillJobType, State: jobs.Accepted, CreatedAt: day.Add(time.Hour)},
+		{Type: accounts.AccountPoolRefillJobType, State: jobs.Error, CreatedAt: day},
+		{Type: accounts.AccountPoolRefillJobType, State: jobs.Complete, CreatedAt: day},
+		{Type: accounts.AccountPoolRefillJobType, State: jobs.Failed, CreatedAt: day},
+		{Type: accounts.AccountCreateJobType, State: jobs.Init, CreatedAt: day},
+	}
+
+	for i := range jj {
+		if err := jobStore.InsertJob(&jj[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pending, err := store.PendingPoolRefillJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Oldest first
+	if len(pending) != 2 || pending[0].ID != jj[1].ID || pending[1].ID != jj[0].ID {
+		t.Fatalf("expected the accepted and errored refill jobs, got %+v", pending)
+	}
+}
+
+func Test_AccountStoreKeyDriftReport(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	store := accounts.NewGormStore(test.GetDatabase(t, cfg))
+
+	jobID := uuid.New()
+
+	if _, err := store.KeyDriftReport(jobID); err == nil {
+		t.Fatal("expected an error for a missing report")
+	}
+
+	report := accounts.KeyDriftReport{Address: "0x01", NumKeys: 1, ValidKeys: []int{0}, StaleKeys: []int{1}}
+	if err := store.InsertKeyDriftReport(jobID, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	// A retried job replaces the report
+	report.NumKeys = 2
+	if err := store.InsertKeyDriftReport(jobID, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := store.KeyDriftReport(jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Address != "0x01" || stored.NumKeys != 2 || len(stored.StaleKeys) != 1 {
+		t.Fatalf("expected the replaced report, got %+v", stored)
+	}
+}
+
+func Test_Create_Account_From_Pool(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	cfg.AccountPoolSize = 2
+	cfg.AccountPoolLowWaterMark = 1
+
+	svc := test.GetServices(t, cfg).GetAccounts()
+	store := accounts.NewGormStore(test.GetDatabase(t, cfg))
+
+	waitForPool := func(depth int64) {
+		for i := 0; ; i++ {
+			n, err := store.PooledAccountCount()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n == depth {
+				return
+			}
+			if i > 120 {
+				t.Fatalf("expected pool depth %d, got %d", depth, n)
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+
+	// Filled on startup
+	waitForPool(2)
+
+	begin := time.Now()
+	_, a, err := svc.Create(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if time.Since(begin) > time.Second {
+		t.Fatalf("expected account to be assigned from the pool, took %s", time.Since(begin))
+	}
+
+	if details, err := svc.Details(a.Address); err != nil || len(details.Keys) == 0 {
+		t.Fatalf("expected assigned account to have keys, got %v %v", details, err)
+	}
+
+	// Refilled after dropping to the low water mark
+	waitForPool(2)
+}
+
+func Test_AccountStoreReplaceKeys(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	store := accounts.NewGormStore(test.GetDatabase(t, cfg))
+
+	a := accounts.Account{
+		Address: "0x01",
+		Type:    accounts.AccountTypeCustodial,
+		Keys: []keys.Storable{
+			{Index: 0, PublicKey: "0xold"},
+			{Index: 1, PublicKey: "0xold"},
+		},
+	}
+	if err := store.InsertAccount(&a); err != nil {
+		t.Fatal(err)
+	}
+
+	err := store.ReplaceAccountKeys(a.Address, []keys.Storable{
+		{Index: 2, PublicKey: "0xnew"},
+		{Index: 3, PublicKey: "0xnew"},
+		{Index: 4, PublicKey: "0xnew"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := store.Account(a.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stored.Keys) != 3 {
+		t.Fatalf("expected 3 keys, got %d", len(stored.Keys))
+	}
+
+	for _, k := range stored.Keys {
+		if k.PublicKey != "0xnew" || k.AccountAddress != a.Address {
+			t.Fatalf("expected only the new keys, got %+v", k)
+		}
+	}
+}
+
+func Test_Rotate_Account_Keys(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	cfg.DefaultAccountKeyCount = 2
+
+	app := test.GetServices(t, cfg)
+	svc := app.GetAccounts()
+	fc := app.GetFlowClient()
+	ctx := context.Background()
+
+	_, a, err := svc.Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldKey := a.Keys[0].PublicKey
+
+	job, err := svc.RotateAccountKeys(ctx, flow.HexToAddress(a.Address), keys.KeySpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := test.WaitForJob(app.GetJobs(), job.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := svc.Details(a.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rotated.Keys) != int(cfg.DefaultAccountKeyCount) {
+		t.Fatalf("expected %d keys, got %d", cfg.DefaultAccountKeyCount, len(rotated.Keys))
+	}
+
+	newIndices := map[int]bool{}
+	for _, k := range rotated.Keys {
+		if k.PublicKey == oldKey {
+			t.Fatalf("expected old key to be replaced, got %+v", k)
+		}
+		newIndices[k.Index] = true
+	}
+
+	flowAccount, err := fc.GetAccount(ctx, flow.HexToAddress(a.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range flowAccount.Keys {
+		if k.Revoked == newIndices[k.Index] {
+			t.Fatalf("expected only the old keys to be revoked, key %d revoked: %t", k.Index, k.Revoked)
+		}
+	}
+
+	// The account can still sign with the new key
+	code := "transaction { prepare(signer: AuthAccount) {} }"
+	if _, _, err := app.GetTransactions().Create(ctx, true, a.Address, code, nil, transactions.General); err != nil {
+		t.Fatal(err)
+	}
+
+	// The admin account keys are not rotated
+	_, err = svc.RotateAccountKeys(ctx, flow.HexToAddress(cfg.AdminAddress), keys.KeySpec{})
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a bad request error, got %v", err)
+	}
+}
+
+func Test_Rotate_Account_Keys_With_Key_Spec(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	cfg.AllowedKeySpecs = []string{"local:ECDSA_P256:SHA3_256", "local:ECDSA_secp256k1:SHA2_256"}
+	app := test.GetServices(t, cfg)
+	svc := app.GetAccounts()
+	ctx := context.Background()
+
+	_, a, err := svc.Create(ctx, true, accounts.WithKeys(keys.KeySpec{SignAlgo: "ECDSA_P256", HashAlgo: "SHA3_256"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Combinations not allowed in the config are rejected
+	_, err = svc.RotateAccountKeys(ctx, flow.HexToAddress(a.Address), keys.KeySpec{SignAlgo: "ECDSA_secp256k1"})
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a bad request error, got %v", err)
+	}
+
+	job, err := svc.RotateAccountKeys(ctx, flow.HexToAddress(a.Address), keys.KeySpec{SignAlgo: "ECDSA_secp256k1", HashAlgo: "SHA2_256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := test.WaitForJob(app.GetJobs(), job.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := svc.Details(a.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range rotated.Keys {
+		if k.SignAlgo != "ECDSA_secp256k1" || k.HashAlgo != "SHA2_256" {
+			t.Fatalf("expected the new key spec, got %s %s", k.SignAlgo, k.HashAlgo)
+		}
+	}
+
+	// The account can sign with the new key
+	code := "transaction { prepare(signer: AuthAccount) {} }"
+	if _, _, err := app.GetTransactions().Create(ctx, true, a.Address, code, nil, transactions.General); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Sync_Account_Key_Count_Revokes_Surplus_And_Unknown_Keys(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	cfg.DefaultAccountKeyCount = 3
+
+	app := test.GetServices(t, cfg)
+	svc := app.GetAccounts()
+	ctx := context.Background()
+
+	_, a, err := svc.Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a key the service does not know about
+	seed := make([]byte, crypto.MinSeedLength)
+	seed[0] = 1
+	foreignKey, err := crypto.GeneratePrivateKey(crypto.ECDSA_P256, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foreignPbk, err := cadence.NewString(strings.TrimPrefix(foreignKey.PublicKey().String(), "0x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []transactions.Argument{cadence.NewArray([]cadence.Value{foreignPbk})}
+	if _, _, err := app.GetTransactions().Create(ctx, true, a.Address, template_strings.AddAccountKeysTransaction, args, transactions.General); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.DefaultAccountKeyCount = 1
+
+	job, err := svc.SyncAccountKeyCount(ctx, flow.HexToAddress(a.Address), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err = test.WaitForJob(app.GetJobs(), job.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := fmt.Sprintf("%s:%d", a.Address, 1); job.Result != expected {
+		t.Fatalf("expected job result %q, got %q", expected, job.Result)
+	}
+
+	report, err := svc.KeyDriftReport(job.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.NumKeys != 1 || len(report.RevokedKeys) != 2 {
+		t.Fatalf("expected 2 surplus keys to be revoked, got %+v", report)
+	}
+
+	if len(report.UnknownKeys) != 1 || !report.UnknownKeys[0].Revoked || report.UnknownKeys[0].PublicKey != foreignKey.PublicKey().String() {
+		t.Fatalf("expected the foreign key to be reported and revoked, got %+v", report.UnknownKeys)
+	}
+
+	details, err := svc.Details(a.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(details.Keys) != 1 || details.Keys[0].Index != report.ValidKeys[0] {
+		t.Fatalf("expected only the lowest valid key to be stored, got %+v", details.Keys)
+	}
+
+	flowAccount, err := app.GetFlowClient().GetAccount(ctx, flow.HexToAddress(a.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range flowAccount.Keys {
+		if k.Revoked == (k.Index == details.Keys[0].Index) {
+			t.Fatalf("expected only the stored key to remain valid, key %d revoked: %t", k.Index, k.Revoked)
+		}
+	}
+}
+
+func Test_Import_Account(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	app := test.GetServices(t, cfg)
+	svc := app.GetAccounts()
+	km := app.GetKeyManager()
+	store := accounts.NewGormStore(test.GetDatabase(t, cfg))
+	ctx := context.Background()
+
+	// Create an account and remove it from custody to get an existing account
+	// with a known private key
+	_, a, err := svc.Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	private, err := km.Load(a.Keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.ReplaceAccountKeys(a.Address, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.HardDeleteAccount(a); err != nil {
+		t.Fatal(err)
+	}
+
+	// A key that does not match the on-chain key is rejected
+	seed := make([]byte, crypto.MinSeedLength)
+	wrongKey, err := crypto.GeneratePrivateKey(crypto.ECDSA_P256, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.ImportAccount(ctx, a.Address, []accounts.ImportedKey{{Index: 0, Value: wrongKey.String()}})
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a bad request error, got %v", err)
+	}
+
+	labels := []string{"imported"}
+	imported, err := svc.ImportAccount(ctx, a.Address, []accounts.ImportedKey{{Index: 0, Value: private.Value}}, accounts.WithMetadata(accounts.Metadata{Labels: &labels}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imported.Type != accounts.AccountTypeCustodial || len(imported.Keys) != 1 || imported.Keys[0].PublicKey != a.Keys[0].PublicKey {
+		t.Fatalf("expected a custodial account with the imported key, got %+v", imported)
+	}
+
+	// Importing the same account again conflicts
+	_, err = svc.ImportAccount(ctx, a.Address, []accounts.ImportedKey{{Index: 0, Value: private.Value}})
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusConflict {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+
+	// The service can sign with the imported key
+	code := "transaction { prepare(signer: AuthAccount) {} }"
+	if _, _, err := app.GetTransactions().Create(ctx, true, imported.Address, code, nil, transactions.General); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_AccountStoreStates(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	store := accounts.NewGormStore(test.GetDatabase(t, cfg))
+
+	aa := []accounts.Account{
+		{Address: "0x01", State: accounts.AccountStateActive},
+		{Address: "0x02", State: accounts.AccountStateFrozen},
+		{Address: "0x03", State: accounts.AccountStateArchived},
+	}
+
+	for i := range aa {
+		if err := store.InsertAccount(&aa[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(state accounts.AccountState) []string {
+		listed, err := store.Accounts(datastore.ListOptions{Limit: 10}, accounts.AccountFilter{State: state})
+		if err != nil {
+			t.Fatal(err)
+		}
+		addresses := []string{}
+		for _, a := range listed {
+			addresses = append(addresses, a.Address)
+		}
+		sort.Strings(addresses)
+		return addresses
+	}
+
+	if listed := list(""); strings.Join(listed, ",") != "0x01,0x02" {
+		t.Fatalf("expected archived accounts to be left out, got %v", listed)
+	}
+
+	if listed := list(accounts.AccountStateArchived); strings.Join(listed, ",") != "0x03" {
+		t.Fatalf("expected only archived accounts, got %v", listed)
+	}
+
+	aa[2].State = accounts.AccountStateActive
+	if err := store.UpdateAccountState(&aa[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	if a, err := store.Account("0x03"); err != nil || a.State != accounts.AccountStateActive {
+		t.Fatalf("expected account to be active, got %v %v", a.State, err)
+	}
+}
+
+func Test_Frozen_Account_Can_Not_Sign(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	app := test.GetServices(t, cfg)
+	svc := app.GetAccounts()
+	ctx := context.Background()
+
+	_, a, err := svc.Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.SetState(a.Address, accounts.AccountStateFrozen); err != nil {
+		t.Fatal(err)
+	}
+
+	isForbidden := func(err error) bool {
+		var reqErr *errors.RequestError
+		return stderrors.As(err, &reqErr) && reqErr.StatusCode == http.StatusForbidden
+	}
+
+	code := "transaction { prepare(signer: AuthAccount) {} }"
+	if _, _, err := app.GetTransactions().Create(ctx, true, a.Address, code, nil, transactions.General); !isForbidden(err) {
+		t.Fatalf("expected frozen account to be rejected as proposer, got %v", err)
+	}
+
+	if _, err := app.GetTransactions().Sign(ctx, a.Address, code, nil); !isForbidden(err) {
+		t.Fatalf("expected frozen account to be rejected when signing, got %v", err)
+	}
+
+	_, _, err = app.GetTokens().CreateWithdrawal(ctx, false, a.Address, tokens.WithdrawalRequest{
+		TokenName: "FlowToken",
+		Recipient: cfg.AdminAddress,
+		FtAmount:  "0.1",
+	})
+	if !isForbidden(err) {
+		t.Fatalf("expected withdrawal from frozen account to be rejected, got %v", err)
+	}
+
+	// Frozen accounts are still listed, archived are not
+	if _, err := svc.SetState(a.Address, accounts.AccountStateArchived); err != nil {
+		t.Fatal(err)
+	}
+
+	listed, err := svc.List(0, 0, accounts.AccountFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range listed {
+		if l.Address == a.Address {
+			t.Fatal("expected archived account to be left out of listing")
+		}
+	}
+
+	if details, err := svc.Details(a.Address); err != nil || len(details.Keys) == 0 {
+		t.Fatalf("expected archived account to keep its keys, got %v %v", details, err)
+	}
+
+	if _, err := svc.SetState(a.Address, accounts.AccountStateActive); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := app.GetTransactions().Create(ctx, true, a.Address, code, nil, transactions.General); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Create_Multi_Key_Account(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	app := test.GetServices(t, cfg)
+	svc := app.GetAccounts()
+	ctx := context.Background()
+
+	// A single key below the threshold can not sign
+	_, _, err := svc.Create(ctx, true, accounts.WithKeys(keys.KeySpec{Type: keys.AccountKeyTypeLocal, Weight: 500}))
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a bad request error, got %v", err)
+	}
+
+	_, a, err := svc.Create(ctx, true, accounts.WithKeys(
+		keys.KeySpec{Type: keys.AccountKeyTypeLocal, Weight: 500},
+		keys.KeySpec{Type: keys.AccountKeyTypeLocal, Weight: 500},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := 2 * int(cfg.DefaultAccountKeyCount); len(a.Keys) != expected {
+		t.Fatalf("expected %d keys, got %d", expected, len(a.Keys))
+	}
+
+	flowAccount, err := app.GetFlowClient().GetAccount(ctx, flow.HexToAddress(a.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range flowAccount.Keys {
+		if key.Weight != 500 {
+			t.Fatalf("expected key %d to have weight 500, got %d", key.Index, key.Weight)
+		}
+	}
+
+	// The keys sign together to reach the threshold
+	code := "transaction { prepare(signer: AuthAccount) {} }"
+	if _, _, err := app.GetTransactions().Create(ctx, true, a.Address, code, nil, transactions.General); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each key is rotated keeping its weight
+	job, err := svc.RotateAccountKeys(ctx, flow.HexToAddress(a.Address), keys.KeySpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := test.WaitForJob(app.GetJobs(), job.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := svc.Details(a.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := 2 * int(cfg.DefaultAccountKeyCount); len(rotated.Keys) != expected {
+		t.Fatalf("expected %d keys, got %d", expected, len(rotated.Keys))
+	}
+
+	rotatedPbks := map[string]bool{}
+	for _, k := range rotated.Keys {
+		for _, old := range a.Keys {
+			if k.PublicKey == old.PublicKey {
+				t.Fatalf("expected old key to be replaced, got %+v", k)
+			}
+		}
+		rotatedPbks[k.PublicKey] = true
+	}
+
+	if len(rotatedPbks) != 2 {
+		t.Fatalf("expected 2 distinct new keys, got %d", len(rotatedPbks))
+	}
+
+	flowAccount, err = app.GetFlowClient().GetAccount(ctx, flow.HexToAddress(a.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range flowAccount.Keys {
+		if !key.Revoked && key.Weight != 500 {
+			t.Fatalf("expected key %d to have weight 500, got %d", key.Index, key.Weight)
+		}
+	}
+
+	// The key count of each key is synced
+	cfg.DefaultAccountKeyCount++
+
+	job, err = svc.SyncAccountKeyCount(ctx, flow.HexToAddress(a.Address), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := test.WaitForJob(app.GetJobs(), job.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := svc.KeyDriftReport(job.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := 2 * int(cfg.DefaultAccountKeyCount); report.NumKeys != expected || report.AddedKeys != 2 {
+		t.Fatalf("expected %d keys with 2 added, got %+v", expected, report)
+	}
+
+	if _, _, err := app.GetTransactions().Create(ctx, true, a.Address, code, nil, transactions.General); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Create_Account_With_Key_Spec(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	cfg.AllowedKeySpecs = []string{"local:ECDSA_P256:SHA3_256", "local:ECDSA_secp256k1:SHA2_256"}
+	app := test.GetServices(t, cfg)
+	svc := app.GetAccounts()
+	ctx := context.Background()
+
+	// Combinations not allowed in the config are rejected
+	_, _, err := svc.Create(ctx, true, accounts.WithKeys(keys.KeySpec{SignAlgo: "ECDSA_secp256k1", HashAlgo: "SHA3_256"}))
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a bad request error, got %v", err)
+	}
+
+	_, a, err := svc.Create(ctx, true, accounts.WithKeys(keys.KeySpec{SignAlgo: "ECDSA_secp256k1", HashAlgo: "SHA2_256"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flowAccount, err := app.GetFlowClient().GetAccount(ctx, flow.HexToAddress(a.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := flowAccount.Keys[0]
+	if key.SigAlgo != crypto.ECDSA_secp256k1 || key.HashAlgo != crypto.SHA2_256 {
+		t.Fatalf("expected a ECDSA_secp256k1 SHA2_256 key, got %s %s", key.SigAlgo, key.HashAlgo)
+	}
+
+	code := "transaction { prepare(signer: AuthAccount) {} }"
+	if _, _, err := app.GetTransactions().Create(ctx, true, a.Address, code, nil, transactions.General); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Recovery_Keys_And_Export(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	app := test.GetServices(t, cfg)
+	svc := app.GetAccounts()
+	ctx := context.Background()
+
+	seed := make([]byte, crypto.MinSeedLength)
+	seed[0] = 1
+	recoveryKey, err := crypto.GeneratePrivateKey(crypto.ECDSA_P256, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recovery := accounts.RecoveryKey{PublicKey: recoveryKey.PublicKey().String(), Weight: 500}
+
+	_, a, err := svc.Create(ctx, true, accounts.WithRecoveryKeys(recovery))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flowAccount, err := app.GetFlowClient().GetAccount(ctx, flow.HexToAddress(a.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recoveryIndex := int(cfg.DefaultAccountKeyCount)
+	if len(flowAccount.Keys) != recoveryIndex+1 || !flowAccount.Keys[recoveryIndex].PublicKey.Equals(recoveryKey.PublicKey()) {
+		t.Fatalf("expected the recovery key after the service keys, got %d keys", len(flowAccount.Keys))
+	}
+
+	// The service keeps signing with its own keys
+	code := "transaction { prepare(signer: AuthAccount) {} }"
+	if _, _, err := app.GetTransactions().Create(ctx, true, a.Address, code, nil, transactions.General); err != nil {
+		t.Fatal(err)
+	}
+
+	// Not enough recovery weight to export
+	_, err = svc.ExportAccount(ctx, flow.HexToAddress(a.Address))
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a bad request error, got %v", err)
+	}
+
+	seed[0] = 2
+	otherRecoveryKey, err := crypto.GeneratePrivateKey(crypto.ECDSA_P256, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := svc.AddRecoveryKey(ctx, flow.HexToAddress(a.Address), accounts.RecoveryKey{PublicKey: otherRecoveryKey.PublicKey().String(), Weight: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := test.WaitForJob(app.GetJobs(), job.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err = svc.ExportAccount(ctx, flow.HexToAddress(a.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := test.WaitForJob(app.GetJobs(), job.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := svc.Details(a.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exported.Type != accounts.AccountTypeNonCustodial || len(exported.Keys) != 2 {
+		t.Fatalf("expected a non-custodial account with the recovery keys, got %+v", exported)
+	}
+
+	for _, k := range exported.Keys {
+		if k.Type != keys.AccountKeyTypeRecovery {
+			t.Fatalf("expected only recovery keys to be stored, got %s", k.Type)
+		}
+	}
+
+	flowAccount, err = app.GetFlowClient().GetAccount(ctx, flow.HexToAddress(a.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range flowAccount.Keys[:recoveryIndex] {
+		if !key.Revoked {
+			t.Fatalf("expected service key %d to be revoked", key.Index)
+		}
+	}
+
+	// The service can no longer sign for the account
+	if _, _, err := app.GetTransactions().Create(ctx, true, a.Address, code, nil, transactions.General); err == nil {
+		t.Fatal("expected signing to fail after export")
+	}
+}
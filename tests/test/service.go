@@ -101,14 +101,19 @@ func GetServices(t *testing.T, cfg *configs.Config) Services {
 		jobs.WithDbJobPollInterval(time.Second),
 		jobs.WithAcceptedGracePeriod(1000),
 		jobs.WithReSchedulableGracePeriod(1000),
+		jobs.WithReconcileInterval(time.Second),
 		jobs.WithSystemService(systemService),
 	)
 
-	km := basic.NewKeyManager(cfg, keys.NewGormStore(db), fc)
+	km, err := basic.NewKeyManager(cfg, keys.NewGormStore(db), fc)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	templateService := templates.NewService(cfg, templates.NewGormStore(db))
-	transactionService := transactions.NewService(cfg, transactions.NewGormStore(db), km, fc, wp)
-	accountService := accounts.NewService(cfg, accounts.NewGormStore(db), km, fc, wp, transactionService)
+	accountStore := accounts.NewGormStore(db)
+	transactionService := transactions.NewService(cfg, transactions.NewGormStore(db), km, fc, wp, transactions.WithSignerCheck(accounts.SignerCheck(accountStore)))
+	accountService := accounts.NewService(cfg, accountStore, km, fc, wp, transactionService, templateService)
 	jobService := jobs.NewService(jobs.NewGormStore(db))
 	tokenService := tokens.NewService(cfg, tokens.NewGormStore(db), km, fc, wp, transactionService, templateService, accountService)
 
@@ -139,13 +144,14 @@ func GetServices(t *testing.T, cfg *configs.Config) Services {
 
 	// Register a handler for chain events
 	chain_events.ChainEvent.Register(&tokens.ChainEventHandler{
-		AccountService:  accountService,
-		ChainListener:   listener,
-		TemplateService: templateService,
-		TokenService:    tokenService,
+		AccountService:     accountService,
+		ChainListener:      listener,
+		TemplateService:    templateService,
+		TokenService:       tokenService,
+		TransactionService: transactionService,
 	})
 
-	err := accountService.InitAdminAccount(context.Background())
+	err = accountService.InitAdminAccount(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/flow-hydraulics/flow-wallet-api/jobs"
 	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
+	"github.com/flow-hydraulics/flow-wallet-api/tokens"
 	"github.com/flow-hydraulics/flow-wallet-api/transactions"
 )
 
@@ -107,3 +108,66 @@ func Test_TokensSetup(t *testing.T) {
 		})
 	}
 }
+
+func Test_TokensDetailsAtBlockHeight(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+	svc := svcs.GetTokens()
+	ctx := context.Background()
+
+	_, account, err := svcs.GetAccounts().Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := svcs.GetFlowClient().GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := svc.Details(ctx, "FlowToken", account.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = svc.CreateWithdrawal(ctx, true, cfg.AdminAddress, tokens.WithdrawalRequest{
+		TokenName: "FlowToken",
+		Recipient: account.Address,
+		FtAmount:  "1.0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := svc.Details(ctx, "FlowToken", account.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if after.Balance.CadenceValue.String() == before.Balance.CadenceValue.String() {
+		t.Fatalf("expected balance to change, got %s", after.Balance.CadenceValue)
+	}
+
+	atHeight, err := svc.Details(ctx, "FlowToken", account.Address, transactions.WithBlockHeight(block.Height))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if atHeight.Balance.CadenceValue.String() != before.Balance.CadenceValue.String() {
+		t.Fatalf("expected balance %s at height %d, got %s", before.Balance.CadenceValue, block.Height, atHeight.Balance.CadenceValue)
+	}
+
+	atID, err := svc.Details(ctx, "FlowToken", account.Address, transactions.WithBlockID(block.ID.Hex()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if atID.Balance.CadenceValue.String() != before.Balance.CadenceValue.String() {
+		t.Fatalf("expected balance %s at block %s, got %s", before.Balance.CadenceValue, block.ID, atID.Balance.CadenceValue)
+	}
+
+	_, err = svc.Details(ctx, "FlowToken", account.Address, transactions.WithBlockHeight(block.Height), transactions.WithBlockID(block.ID.Hex()))
+	if err == nil {
+		t.Fatal("expected an error when both block height and id are given")
+	}
+}
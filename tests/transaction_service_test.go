@@ -4,10 +4,13 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
 	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
 	"github.com/flow-hydraulics/flow-wallet-api/transactions"
+	"github.com/google/go-cmp/cmp"
 	"github.com/onflow/flow-go-sdk"
 )
 
@@ -66,6 +69,73 @@ func Test_TransactionSignByAnotherAccount(t *testing.T) {
 	}
 }
 
+func Test_TransactionWithMultipleAuthorizers(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+
+	_, acc1, err := svcs.GetAccounts().Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, acc2, err := svcs.GetAccounts().Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := "transaction() { prepare(a: AuthAccount, b: AuthAccount, c: AuthAccount){} execute {}}"
+	authorizers := []string{acc1.Address, acc2.Address, cfg.AdminAddress}
+
+	t.Run("sign", func(t *testing.T) {
+		tx, err := svcs.GetTransactions().Sign(ctx, acc1.Address, code, nil, transactions.WithAuthorizers(authorizers...))
+		if err != nil {
+			t.Fatalf("expected err == nil, got %#v", err)
+		}
+
+		if len(tx.Authorizers) != len(authorizers) {
+			t.Fatalf("expected %d authorizers, got %d", len(authorizers), len(tx.Authorizers))
+		}
+
+		// Verify authorizer order
+		for i, a := range authorizers {
+			if flow.HexToAddress(a) != tx.Authorizers[i] {
+				t.Fatalf("expected authorizer %d to be %s, got %s", i, a, tx.Authorizers[i])
+			}
+		}
+
+		// Verify both custodial accounts signed the payload
+		for _, a := range []string{acc1.Address, acc2.Address} {
+			if !addressExists(a, tx.PayloadSignatures) {
+				t.Fatalf("couldn't find %s from payload signatures", a)
+			}
+		}
+
+		// Admin is the payer and should only sign the envelope
+		if addressExists(cfg.AdminAddress, tx.PayloadSignatures) {
+			t.Fatal("expected admin not to sign the payload")
+		}
+
+		if !addressExists(cfg.AdminAddress, tx.EnvelopeSignatures) {
+			t.Fatal("couldn't find admin from envelope signatures")
+		}
+	})
+
+	t.Run("send", func(t *testing.T) {
+		_, _, err := svcs.GetTransactions().Create(ctx, true, acc1.Address, code, nil, transactions.General, transactions.WithAuthorizers(authorizers...))
+		if err != nil {
+			t.Fatalf("expected err == nil, got %#v", err)
+		}
+	})
+
+	t.Run("invalid authorizer", func(t *testing.T) {
+		_, err := svcs.GetTransactions().Sign(ctx, acc1.Address, code, nil, transactions.WithAuthorizers("not-an-address"))
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
 func addressExists(addr string, sigs []flow.TransactionSignature) bool {
 	addr = strings.TrimPrefix(addr, "0x")
 	for _, s := range sigs {
@@ -113,8 +183,6 @@ func Test_TransactionProposalKeySequenceNumber(t *testing.T) {
 	})
 
 	t.Run("update sequence number during job run", func(t *testing.T) {
-		t.Skip("not supported currently")
-
 		cfg := test.LoadConfig(t)
 		cfg.AdminProposalKeyCount = 1
 		cfg.WorkerCount = 1
@@ -172,3 +240,448 @@ func Test_TransactionProposalKeySequenceNumber(t *testing.T) {
 	})
 
 }
+
+func Test_TransactionStatus(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	app := test.GetServices(t, cfg)
+	txSvc := app.GetTransactions()
+
+	t.Run("sealed", func(t *testing.T) {
+		_, tx, err := txSvc.Create(ctx, true, cfg.AdminAddress, "transaction() { prepare(signer: AuthAccount){} execute {}}", nil, transactions.General)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		details, err := txSvc.Details(ctx, tx.TransactionId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if details.Status != transactions.StatusSealed {
+			t.Fatalf("expected status to be %s, got %s", transactions.StatusSealed, details.Status)
+		}
+
+		if details.BlockHeight == 0 {
+			t.Fatal("expected block height to be set")
+		}
+
+		if !details.EventsStored {
+			t.Fatal("expected events to be stored")
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		_, _, err := txSvc.Create(ctx, true, cfg.AdminAddress, "transaction() { prepare(signer: AuthAccount){} execute { panic(\"failed on purpose\") }}", nil, transactions.General)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+
+		tt, err := txSvc.List(transactions.StatusFailed, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tt) != 1 {
+			t.Fatalf("expected 1 failed transaction, got %d", len(tt))
+		}
+
+		if !strings.Contains(tt[0].ErrorMessage, "failed on purpose") {
+			t.Fatalf("expected error message to contain the panic message, got %q", tt[0].ErrorMessage)
+		}
+	})
+}
+
+func Test_TransactionGasLimitAndPayer(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+	txSvc := svcs.GetTransactions()
+
+	_, acc, err := svcs.GetAccounts().Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := "transaction() { prepare(signer: AuthAccount){} execute {}}"
+
+	t.Run("gas limit", func(t *testing.T) {
+		tx, err := txSvc.Sign(ctx, acc.Address, code, nil, transactions.WithGasLimit(100))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tx.GasLimit != 100 {
+			t.Fatalf("expected gas limit to be 100, got %d", tx.GasLimit)
+		}
+	})
+
+	t.Run("gas limit over maximum", func(t *testing.T) {
+		_, err := txSvc.Sign(ctx, acc.Address, code, nil, transactions.WithGasLimit(cfg.TransactionMaxGasLimit+1))
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("custodial payer", func(t *testing.T) {
+		tx, err := txSvc.Sign(ctx, acc.Address, code, nil, transactions.WithPayer(acc.Address))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if flow.HexToAddress(acc.Address) != tx.Payer {
+			t.Fatalf("expected payer to be %s, got %s", acc.Address, tx.Payer)
+		}
+
+		if !addressExists(acc.Address, tx.EnvelopeSignatures) {
+			t.Fatal("couldn't find payer from envelope signatures")
+		}
+
+		if addressExists(cfg.AdminAddress, tx.EnvelopeSignatures) || addressExists(cfg.AdminAddress, tx.PayloadSignatures) {
+			t.Fatal("expected admin not to sign the transaction")
+		}
+
+		if _, _, err := txSvc.Create(ctx, true, acc.Address, code, nil, transactions.General, transactions.WithPayer(acc.Address)); err != nil {
+			t.Fatalf("expected err == nil, got %#v", err)
+		}
+	})
+
+	t.Run("non-custodial payer", func(t *testing.T) {
+		_, err := txSvc.Sign(ctx, acc.Address, code, nil, transactions.WithPayer("0x01cf0e2f2f715450"))
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func Test_TransactionSubmitExternallySigned(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+	txSvc := svcs.GetTransactions()
+
+	_, acc, err := svcs.GetAccounts().Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The custodial account stands in for an external signer (e.g. a browser wallet)
+	external, err := svcs.GetKeyManager().UserAuthorizer(ctx, flow.HexToAddress(acc.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	build := func(t *testing.T) *flow.Transaction {
+		latestBlockID, err := flow_helpers.LatestBlockId(ctx, svcs.GetFlowClient())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		account, err := svcs.GetFlowClient().GetAccount(ctx, external.Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		flowTx := flow.NewTransaction().
+			SetScript([]byte("transaction() { prepare(signer: AuthAccount){} execute {}}")).
+			SetReferenceBlockID(*latestBlockID).
+			SetGasLimit(9999).
+			SetProposalKey(external.Address, external.Key.Index, account.Keys[external.Key.Index].SequenceNumber).
+			SetPayer(flow.HexToAddress(cfg.AdminAddress)).
+			AddAuthorizer(external.Address)
+
+		if err := flowTx.SignPayload(external.Address, external.Key.Index, external.Signer); err != nil {
+			t.Fatal(err)
+		}
+
+		return flowTx
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		_, tx, err := txSvc.Submit(ctx, true, acc.Address, build(t))
+		if err != nil {
+			t.Fatalf("expected err == nil, got %#v", err)
+		}
+
+		if !tx.ExternallySigned {
+			t.Fatal("expected transaction to be marked as externally signed")
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		flowTx := build(t)
+		flowTx.PayloadSignatures[0].Signature[0] ^= 0xff
+
+		if _, _, err := txSvc.Submit(ctx, true, acc.Address, flowTx); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		flowTx := build(t)
+		flowTx.PayloadSignatures = nil
+
+		if _, _, err := txSvc.Submit(ctx, true, acc.Address, flowTx); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("proposer mismatch", func(t *testing.T) {
+		if _, _, err := txSvc.Submit(ctx, true, cfg.AdminAddress, build(t)); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("payer as authorizer", func(t *testing.T) {
+		flowTx := build(t)
+		flowTx.AddAuthorizer(flow.HexToAddress(cfg.AdminAddress))
+		flowTx.PayloadSignatures = nil
+
+		if err := flowTx.SignPayload(external.Address, external.Key.Index, external.Signer); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := txSvc.Submit(ctx, true, acc.Address, flowTx); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("other custodial payer", func(t *testing.T) {
+		_, other, err := svcs.GetAccounts().Create(ctx, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		flowTx := build(t)
+		flowTx.SetPayer(flow.HexToAddress(other.Address))
+		flowTx.PayloadSignatures = nil
+
+		if err := flowTx.SignPayload(external.Address, external.Key.Index, external.Signer); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := txSvc.Submit(ctx, true, acc.Address, flowTx); err == nil || !strings.Contains(err.Error(), "must be the admin account") {
+			t.Fatalf("expected a payer error, got %v", err)
+		}
+	})
+}
+
+func Test_TransactionCreateBatch(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+	txSvc := svcs.GetTransactions()
+
+	_, acc, err := svcs.GetAccounts().Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := "transaction(greeting: String) { prepare(signer: AuthAccount){} execute { log(greeting) }}"
+
+	results, err := txSvc.CreateBatch(ctx, []transactions.BatchItem{
+		{Reference: "admin", Address: cfg.AdminAddress, Code: code, Arguments: []transactions.Argument{"hello"}},
+		{Reference: "account", Address: acc.Address, Code: code, Arguments: []transactions.Argument{"hello"}},
+		// Valid but not a custodial account, fails on its own
+		{Reference: "unknown", Address: "0x01cf0e2f2f715450", Code: code, Arguments: []transactions.Argument{"hello"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	for _, r := range results {
+		if r.Err != nil || r.Job == nil {
+			t.Fatalf("expected a job for %s, got: %v", r.Reference, r.Err)
+		}
+	}
+
+	// Retrying the batch returns the existing jobs
+	retried, err := txSvc.CreateBatch(ctx, []transactions.BatchItem{
+		{Reference: "admin", Address: cfg.AdminAddress, Code: code, Arguments: []transactions.Argument{"hello"}},
+		{Reference: "account", Address: acc.Address, Code: code, Arguments: []transactions.Argument{"hello"}},
+		{Reference: "unknown", Address: "0x01cf0e2f2f715450", Code: code, Arguments: []transactions.Argument{"hello"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, r := range retried {
+		if r.Err != nil || r.Job == nil || r.Job.ID != results[i].Job.ID {
+			t.Fatalf("expected the existing job for %s", r.Reference)
+		}
+	}
+
+	jobSvc := svcs.GetJobs()
+	states := make([]jobs.State, len(results))
+
+	for i, r := range results {
+		for {
+			job, err := jobSvc.Details(r.Job.ID.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.State == jobs.Complete || job.State == jobs.Failed || job.State == jobs.Error {
+				states[i] = job.State
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	for i, r := range results[:2] {
+		if states[i] != jobs.Complete {
+			t.Fatalf("expected the job of %s to complete, got %s", r.Reference, states[i])
+		}
+	}
+
+	// Valid but not a custodial account, fails on its own
+	if states[2] == jobs.Complete {
+		t.Fatal("expected the job of the item with an unknown proposer to fail")
+	}
+
+	before, err := txSvc.List("", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid items reject the whole batch
+	_, err = txSvc.CreateBatch(ctx, []transactions.BatchItem{
+		{Reference: "valid", Address: cfg.AdminAddress, Code: code, Arguments: []transactions.Argument{"hello"}},
+		{Reference: "invalid", Address: cfg.AdminAddress, Code: code},
+	})
+	if err == nil {
+		t.Fatal("expected batch validation to fail")
+	}
+
+	after, err := txSvc.List("", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(after) != len(before) {
+		t.Fatalf("expected no transactions to be created, got %d new", len(after)-len(before))
+	}
+}
+
+func Test_TransactionBatchReferences(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	store := transactions.NewGormStore(db)
+	jobStore := jobs.NewGormStore(db)
+
+	first, second := &jobs.Job{Type: transactions.BatchTransactionJobType}, &jobs.Job{Type: transactions.BatchTransactionJobType}
+	for _, j := range []*jobs.Job{first, second} {
+		if err := jobStore.InsertJob(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.InsertBatchReferences([]transactions.BatchReference{{Reference: "a", JobID: first.ID}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Already stored references are ignored
+	if err := store.InsertBatchReferences([]transactions.BatchReference{{Reference: "a", JobID: second.ID}, {Reference: "b", JobID: second.ID}}); err != nil {
+		t.Fatal(err)
+	}
+
+	rr, err := store.BatchReferences([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jobIDs := make(map[string]string)
+	for _, r := range rr {
+		if r.Job == nil || r.Job.ID != r.JobID {
+			t.Fatalf("expected the job of %s to be loaded", r.Reference)
+		}
+		jobIDs[r.Reference] = r.JobID.String()
+	}
+
+	expected := map[string]string{"a": first.ID.String(), "b": second.ID.String()}
+	if diff := cmp.Diff(expected, jobIDs); diff != "" {
+		t.Fatalf("\n\n%s\n", diff)
+	}
+
+	r, err := store.BatchReference("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.InsertBatchTransaction(&r, &transactions.Transaction{TransactionId: "tx1", TransactionType: transactions.General}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-signing the transaction updates the reference
+	if err := store.ReplaceTransaction("tx1", &transactions.Transaction{TransactionId: "tx2", PreviousTransactionId: "tx1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = store.BatchReference("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.TransactionId != "tx2" {
+		t.Fatalf("expected transaction id tx2, got %q", r.TransactionId)
+	}
+}
+
+func Test_TransactionFeeSummaries(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	store := transactions.NewGormStore(test.GetDatabase(t, cfg))
+
+	day := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tt := []transactions.Transaction{
+		{TransactionId: "a", ProposerAddress: "0x01", PayerAddress: "0xf8", TransactionType: transactions.General, FeeAmount: 100, ExecutionEffort: 10, CreatedAt: day},
+		{TransactionId: "b", ProposerAddress: "0x01", PayerAddress: "0xf8", TransactionType: transactions.FtTransfer, FeeAmount: 200, ExecutionEffort: 20, CreatedAt: day},
+		{TransactionId: "c", ProposerAddress: "0x02", PayerAddress: "0xf8", TransactionType: transactions.FtTransfer, FeeAmount: 300, ExecutionEffort: 30, CreatedAt: day.AddDate(0, 0, 1)},
+		// No recorded fees, not included
+		{TransactionId: "d", ProposerAddress: "0x03", PayerAddress: "0xf8", TransactionType: transactions.General, CreatedAt: day},
+	}
+
+	for i := range tt {
+		if err := store.InsertTransaction(&tt[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ss, err := store.FeeSummaries(transactions.FeesByProposer, transactions.FeeFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []transactions.FeeSummary{
+		{Group: "0x01", TransactionCount: 2, FeeAmount: 300, ExecutionEffort: 30},
+		{Group: "0x02", TransactionCount: 1, FeeAmount: 300, ExecutionEffort: 30},
+	}
+	if diff := cmp.Diff(expected, ss); diff != "" {
+		t.Fatalf("\n\n%s\n", diff)
+	}
+
+	ss, err = store.FeeSummaries(transactions.FeesByPayer, transactions.FeeFilter{From: day, To: day.AddDate(0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []transactions.FeeSummary{
+		{Group: "0xf8", TransactionCount: 2, FeeAmount: 300, ExecutionEffort: 30},
+	}
+	if diff := cmp.Diff(expected, ss); diff != "" {
+		t.Fatalf("\n\n%s\n", diff)
+	}
+
+	ss, err = store.FeeSummaries(transactions.FeesByDate, transactions.FeeFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ss) != 2 || !strings.HasPrefix(ss[0].Group, "2022-03-01") || ss[0].FeeAmount != 300 {
+		t.Fatalf("expected fees to be grouped by date, got %+v", ss)
+	}
+}
@@ -14,13 +14,18 @@ type AccountAddedHandler struct {
 
 func (h *AccountAddedHandler) Handle(payload accounts.AccountAddedPayload) {
 	address := flow_helpers.FormatAddress(payload.Address)
-	h.addFlowToken(address)
+	h.addToken("FlowToken", address)
+
+	// Tokens set up when the account was created
+	for _, tokenName := range payload.Tokens {
+		h.addToken(tokenName, address)
+	}
 }
 
-func (h *AccountAddedHandler) addFlowToken(address string) {
-	if err := h.TokenService.AddAccountToken("FlowToken", address); err != nil {
+func (h *AccountAddedHandler) addToken(tokenName, address string) {
+	if err := h.TokenService.AddAccountToken(tokenName, address); err != nil {
 		log.
-			WithFields(log.Fields{"error": err}).
-			Warn("Error while adding FlowToken to new account")
+			WithFields(log.Fields{"error": err, "tokenName": tokenName}).
+			Warn("Error while adding token to new account")
 	}
 }
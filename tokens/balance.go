@@ -3,6 +3,7 @@ package tokens
 import (
 	"encoding/json"
 
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
 	"github.com/onflow/cadence"
 )
 
@@ -17,12 +18,6 @@ func (b *Balance) MarshalJSON() ([]byte, error) {
 		return json.Marshal(nil)
 	}
 
-	// Only handle fixed point numbers differently, rest can use the default
-	_, isUfix64 := b.CadenceValue.Type().(cadence.UFix64Type)
-	_, isFix64 := b.CadenceValue.Type().(cadence.Fix64Type)
-	if isUfix64 || isFix64 {
-		return json.Marshal(b.CadenceValue.String())
-	}
-
-	return json.Marshal(b.CadenceValue.ToGoValue())
+	// Fixed point numbers are handled as strings, rest can use the default
+	return json.Marshal(flow_helpers.CadenceValueToJSON(b.CadenceValue))
 }
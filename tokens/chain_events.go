@@ -8,15 +8,17 @@ import (
 	"github.com/flow-hydraulics/flow-wallet-api/chain_events"
 	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
 	"github.com/flow-hydraulics/flow-wallet-api/templates"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
 	"github.com/onflow/flow-go-sdk"
 	log "github.com/sirupsen/logrus"
 )
 
 type ChainEventHandler struct {
-	AccountService  accounts.Service
-	ChainListener   chain_events.Listener
-	TemplateService templates.Service
-	TokenService    Service
+	AccountService     accounts.Service
+	ChainListener      chain_events.Listener
+	TemplateService    templates.Service
+	TokenService       Service
+	TransactionService transactions.Service
 }
 
 func (h *ChainEventHandler) Handle(ctx context.Context, event flow.Event) {
@@ -49,4 +51,14 @@ func (h *ChainEventHandler) handleDeposit(ctx context.Context, event flow.Event)
 			Warn("Error while registering a deposit")
 		return
 	}
+
+	// Keep the deposit event around in case the transaction result is no
+	// longer available from chain when its details are requested
+	if h.TransactionService != nil {
+		if err := h.TransactionService.StoreEvents(event); err != nil {
+			log.
+				WithFields(log.Fields{"error": err}).
+				Warn("Error while storing a deposit event")
+		}
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/flow-hydraulics/flow-wallet-api/accounts"
 	"github.com/flow-hydraulics/flow-wallet-api/jobs"
 )
 
@@ -36,3 +37,32 @@ func (s *ServiceImpl) executeCreateWithdrawalJob(ctx context.Context, j *jobs.Jo
 
 	return nil
 }
+
+// executeInitialFundingJob transfers the initial funding to a new account,
+// recording it as a withdrawal from the funding account.
+func (s *ServiceImpl) executeInitialFundingJob(ctx context.Context, j *jobs.Job) error {
+	if j.Type != accounts.InitialFundingJobType {
+		return jobs.ErrInvalidJobType
+	}
+
+	j.ShouldSendNotification = true
+
+	attrs := accounts.InitialFundingJobAttributes{}
+	if err := json.Unmarshal(j.Attributes, &attrs); err != nil {
+		return err
+	}
+
+	transaction, err := s.createWithdrawal(ctx, attrs.FundedBy, WithdrawalRequest{
+		TokenName: attrs.Funding.TokenName,
+		Recipient: attrs.Address,
+		FtAmount:  attrs.Funding.Amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	j.TransactionID = transaction.TransactionId
+	j.Result = transaction.TransactionId
+
+	return nil
+}
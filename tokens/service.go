@@ -28,7 +28,7 @@ type Service interface {
 	Setup(ctx context.Context, sync bool, tokenName, address string) (*jobs.Job, *transactions.Transaction, error)
 	AddAccountToken(tokenName, address string) error
 	AccountTokens(address string, tType templates.TokenType) ([]AccountToken, error)
-	Details(ctx context.Context, tokenName, address string) (*Details, error)
+	Details(ctx context.Context, tokenName, address string, opts ...transactions.ScriptOption) (*Details, error)
 	CreateWithdrawal(ctx context.Context, sync bool, sender string, request WithdrawalRequest) (*jobs.Job, *transactions.Transaction, error)
 	ListWithdrawals(address, tokenName string) ([]*TokenWithdrawal, error)
 	ListDeposits(address, tokenName string) ([]*TokenDeposit, error)
@@ -71,6 +71,7 @@ func NewService(
 
 	// Register asynchronous job executor.
 	wp.RegisterExecutor(WithdrawalCreateJobType, svc.executeCreateWithdrawalJob)
+	wp.RegisterExecutor(accounts.InitialFundingJobType, svc.executeInitialFundingJob)
 
 	return svc
 }
@@ -156,7 +157,9 @@ func (s *ServiceImpl) AccountTokens(address string, tType templates.TokenType) (
 }
 
 // Details is used to get the accounts balance (or similar for NFTs) for a token.
-func (s *ServiceImpl) Details(ctx context.Context, tokenName, address string) (*Details, error) {
+// The balance is read at the latest sealed block unless a block height or ID
+// is given.
+func (s *ServiceImpl) Details(ctx context.Context, tokenName, address string, opts ...transactions.ScriptOption) (*Details, error) {
 	// Check if the input is a valid address
 	address, err := flow_helpers.ValidateAddress(address, s.cfg.ChainID)
 	if err != nil {
@@ -178,7 +181,7 @@ func (s *ServiceImpl) Details(ctx context.Context, tokenName, address string) (*
 		return nil, fmt.Errorf("unsupported token type: %s", token.Type)
 	}
 
-	res, err := s.transactions.ExecuteScript(ctx, token.Balance, []transactions.Argument{cadence.NewAddress(flow.HexToAddress(address))})
+	res, err := s.transactions.ExecuteScript(ctx, token.Balance, []transactions.Argument{cadence.NewAddress(flow.HexToAddress(address))}, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -189,6 +192,14 @@ func (s *ServiceImpl) Details(ctx context.Context, tokenName, address string) (*
 func (s *ServiceImpl) CreateWithdrawal(ctx context.Context, sync bool, sender string, request WithdrawalRequest) (*jobs.Job, *transactions.Transaction, error) {
 	log.WithFields(log.Fields{"sync": sync}).Trace("Create withdrawal")
 
+	// Reject frozen senders before scheduling anything, other errors are
+	// handled when creating the withdrawal
+	if account, err := s.accounts.Details(sender); err == nil {
+		if err := account.CheckActive(); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	if !sync {
 		// Async
 		attrs := withdrawalCreateJobAttributes{sender, request}
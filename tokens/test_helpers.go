@@ -34,7 +34,7 @@ func (s *ServiceImpl) DeployTokenContractForAccount(ctx context.Context, runSync
 
 	c := flow_templates.Contract{Name: n, Source: src}
 
-	err = accounts.AddContract(ctx, s.fc, s.km, address, c, s.cfg.TransactionTimeout)
+	err = accounts.AddContract(ctx, s.fc, s.km, address, c, s.cfg.TransactionMaxGasLimit, s.cfg.TransactionTimeout)
 	if err != nil {
 		return err
 	}
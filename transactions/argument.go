@@ -1,10 +1,22 @@
 package transactions
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/onflow/cadence"
 	c_json "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/parser2"
+	"github.com/onflow/flow-go-sdk"
 )
 
 type Argument interface{}
@@ -30,16 +42,369 @@ func ArgAsCadence(a Argument) (cadence.Value, error) {
 	return c, nil
 }
 
-func MustDecodeArgs(aa []Argument) []cadence.Value {
-	var cc []cadence.Value
+// DecodeArguments converts arguments to Cadence values using the parameter
+// types declared by the transaction, or the `main` function of a script, in
+// code. Arguments can be given as Cadence values, JSON-Cadence values or plain
+// JSON values (strings, numbers, booleans, arrays and objects). If the code
+// can not be parsed only Cadence and JSON-Cadence arguments are accepted.
+//
+// Arity and type mismatches are returned as a 400 RequestError.
+func DecodeArguments(code string, args []Argument) ([]cadence.Value, error) {
+	params, ok := parameters(code)
+	if !ok {
+		// Unable to resolve parameter types, let the chain validate the arguments
+		values := make([]cadence.Value, len(args))
+		for i, a := range args {
+			v, err := ArgAsCadence(a)
+			if err != nil {
+				return nil, argumentError(fmt.Errorf("argument %d: %w", i, err))
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+
+	if len(args) != len(params) {
+		return nil, argumentError(fmt.Errorf("expected %d arguments, got %d", len(params), len(args)))
+	}
+
+	values := make([]cadence.Value, len(args))
+	for i, a := range args {
+		p := params[i]
+
+		v, err := decodeArgument(p.TypeAnnotation.Type, a)
+		if err != nil {
+			return nil, argumentError(fmt.Errorf("argument %d (%s: %s): %w", i, p.Identifier.Identifier, p.TypeAnnotation.Type, err))
+		}
+
+		values[i] = v
+	}
+
+	return values, nil
+}
+
+func argumentError(err error) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf("invalid arguments: %w", err),
+	}
+}
+
+// parameters returns the parameters of the transaction or the `main` function
+// of a script declared in code.
+func parameters(code string) ([]*ast.Parameter, bool) {
+	program, err := parser2.ParseProgram(code, nil)
+	if err != nil {
+		return nil, false
+	}
+
+	if txs := program.TransactionDeclarations(); len(txs) > 0 {
+		if txs[0].ParameterList == nil {
+			return nil, true
+		}
+		return txs[0].ParameterList.Parameters, true
+	}
+
+	for _, f := range program.FunctionDeclarations() {
+		if f.Identifier.Identifier == "main" {
+			if f.ParameterList == nil {
+				return nil, true
+			}
+			return f.ParameterList.Parameters, true
+		}
+	}
+
+	return nil, false
+}
+
+func decodeArgument(t ast.Type, a Argument) (cadence.Value, error) {
+	if v, ok := a.(cadence.Value); ok {
+		return v, checkType(t, v)
+	}
+
+	// Normalize to plain JSON values, keeping numbers as is
+	b, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var plain interface{}
+	if err := d.Decode(&plain); err != nil {
+		return nil, err
+	}
+
+	if isJSONCadence(plain) {
+		if v, err := c_json.Decode(nil, b); err == nil {
+			return v, checkType(t, v)
+		}
+	}
+
+	return fromPlain(t, plain)
+}
+
+// isJSONCadence checks if a plain JSON value looks like a JSON-Cadence value.
+func isJSONCadence(plain interface{}) bool {
+	m, ok := plain.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	if _, ok := m["type"].(string); !ok {
+		return false
+	}
+
+	_, hasValue := m["value"]
+
+	return hasValue || m["type"] == "Void"
+}
+
+// checkType checks that a Cadence value matches a builtin type, other types
+// are left for the chain to validate.
+func checkType(t ast.Type, v cadence.Value) error {
+	switch t := t.(type) {
+	case *ast.OptionalType:
+		if o, ok := v.(cadence.Optional); ok {
+			if o.Value == nil {
+				return nil
+			}
+			return checkType(t.Type, o.Value)
+		}
+		return checkType(t.Type, v)
+	case *ast.NominalType:
+		if len(t.NestedIdentifiers) > 0 || !isBuiltin(t.Identifier.Identifier) {
+			return nil
+		}
+		if v.Type() == nil || v.Type().ID() != t.Identifier.Identifier {
+			return fmt.Errorf("expected %s", t.Identifier.Identifier)
+		}
+	}
+
+	return nil
+}
+
+func isBuiltin(name string) bool {
+	switch name {
+	case "String", "Character", "Bool", "Address",
+		"Int", "Int8", "Int16", "Int32", "Int64", "Int128", "Int256",
+		"UInt", "UInt8", "UInt16", "UInt32", "UInt64", "UInt128", "UInt256",
+		"Word8", "Word16", "Word32", "Word64",
+		"Fix64", "UFix64":
+		return true
+	}
+	return false
+}
 
-	for _, a := range aa {
-		c, err := ArgAsCadence(a)
+// fromPlain converts a plain JSON value to a Cadence value of the given type.
+func fromPlain(t ast.Type, plain interface{}) (cadence.Value, error) {
+	switch t := t.(type) {
+	case *ast.OptionalType:
+		if plain == nil {
+			return cadence.NewOptional(nil), nil
+		}
+		v, err := fromPlain(t.Type, plain)
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewOptional(v), nil
+
+	case *ast.VariableSizedType:
+		return arrayFromPlain(t.Type, plain, -1)
+
+	case *ast.ConstantSizedType:
+		size := -1
+		if t.Size != nil && t.Size.Value != nil {
+			size = int(t.Size.Value.Int64())
+		}
+		return arrayFromPlain(t.Type, plain, size)
+
+	case *ast.DictionaryType:
+		m, ok := plain.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object")
+		}
+
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]cadence.KeyValuePair, len(keys))
+		for i, k := range keys {
+			key, err := fromPlain(t.KeyType, k)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k, err)
+			}
+			value, err := fromPlain(t.ValueType, m[k])
+			if err != nil {
+				return nil, fmt.Errorf("value of %q: %w", k, err)
+			}
+			pairs[i] = cadence.KeyValuePair{Key: key, Value: value}
+		}
+		return cadence.NewDictionary(pairs), nil
+
+	case *ast.NominalType:
+		if len(t.NestedIdentifiers) == 0 && isBuiltin(t.Identifier.Identifier) {
+			return primitiveFromPlain(t.Identifier.Identifier, plain)
+		}
+	}
+
+	return nil, fmt.Errorf("type %s can only be given as JSON-Cadence", t)
+}
+
+func arrayFromPlain(elementType ast.Type, plain interface{}, size int) (cadence.Value, error) {
+	a, ok := plain.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array")
+	}
+
+	if size >= 0 && len(a) != size {
+		return nil, fmt.Errorf("expected an array of %d elements, got %d", size, len(a))
+	}
+
+	values := make([]cadence.Value, len(a))
+	for i, e := range a {
+		v, err := fromPlain(elementType, e)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		values[i] = v
+	}
+
+	return cadence.NewArray(values), nil
+}
+
+func primitiveFromPlain(name string, plain interface{}) (cadence.Value, error) {
+	switch name {
+	case "String":
+		s, ok := plain.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string")
+		}
+		return cadence.NewString(s)
+
+	case "Character":
+		s, ok := plain.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string")
+		}
+		return cadence.NewCharacter(s)
+
+	case "Bool":
+		b, ok := plain.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a boolean")
+		}
+		return cadence.NewBool(b), nil
+
+	case "Address":
+		s, ok := plain.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected an address string")
+		}
+		h := strings.TrimPrefix(s, "0x")
+		if b, err := hex.DecodeString(h); err != nil || len(b) == 0 || len(b) > flow.AddressLength {
+			return nil, fmt.Errorf("not a valid address: %q", s)
+		}
+		return cadence.NewAddress(flow.HexToAddress(h)), nil
+	}
+
+	s, err := numberString(plain)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "Fix64", "UFix64":
+		if !strings.Contains(s, ".") {
+			s = s + ".0"
+		}
+		if name == "Fix64" {
+			return cadence.NewFix64(s)
+		}
+		return cadence.NewUFix64(s)
+
+	case "Int8", "Int16", "Int32", "Int64":
+		bits, _ := strconv.Atoi(strings.TrimPrefix(name, "Int"))
+		i, err := strconv.ParseInt(s, 10, bits)
 		if err != nil {
-			panic("unable to decode arguments")
+			return nil, fmt.Errorf("expected %s: %w", name, err)
+		}
+		switch bits {
+		case 8:
+			return cadence.NewInt8(int8(i)), nil
+		case 16:
+			return cadence.NewInt16(int16(i)), nil
+		case 32:
+			return cadence.NewInt32(int32(i)), nil
+		default:
+			return cadence.NewInt64(i), nil
+		}
+
+	case "UInt8", "UInt16", "UInt32", "UInt64", "Word8", "Word16", "Word32", "Word64":
+		bits, _ := strconv.Atoi(strings.TrimLeft(name, "UIntWord"))
+		u, err := strconv.ParseUint(s, 10, bits)
+		if err != nil {
+			return nil, fmt.Errorf("expected %s: %w", name, err)
+		}
+		word := strings.HasPrefix(name, "Word")
+		switch {
+		case bits == 8 && word:
+			return cadence.NewWord8(uint8(u)), nil
+		case bits == 8:
+			return cadence.NewUInt8(uint8(u)), nil
+		case bits == 16 && word:
+			return cadence.NewWord16(uint16(u)), nil
+		case bits == 16:
+			return cadence.NewUInt16(uint16(u)), nil
+		case bits == 32 && word:
+			return cadence.NewWord32(uint32(u)), nil
+		case bits == 32:
+			return cadence.NewUInt32(uint32(u)), nil
+		case word:
+			return cadence.NewWord64(u), nil
+		default:
+			return cadence.NewUInt64(u), nil
 		}
-		cc = append(cc, c)
 	}
 
-	return cc
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("expected %s", name)
+	}
+
+	switch name {
+	case "Int":
+		return cadence.NewIntFromBig(i), nil
+	case "Int128":
+		return cadence.NewInt128FromBig(i)
+	case "Int256":
+		return cadence.NewInt256FromBig(i)
+	case "UInt":
+		return cadence.NewUIntFromBig(i)
+	case "UInt128":
+		return cadence.NewUInt128FromBig(i)
+	case "UInt256":
+		return cadence.NewUInt256FromBig(i)
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", name)
+}
+
+// numberString returns the string representation of a plain JSON number,
+// numbers can also be given as strings.
+func numberString(plain interface{}) (string, error) {
+	switch n := plain.(type) {
+	case json.Number:
+		return n.String(), nil
+	case string:
+		return n, nil
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("expected a number")
+	}
 }
@@ -2,11 +2,18 @@ package transactions
 
 import (
 	"encoding/json"
+	stdErrors "errors"
 	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/google/go-cmp/cmp"
 	"github.com/onflow/cadence"
+	c_json "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/flow-go-sdk"
 )
 
 func Test_AsCadence(t *testing.T) {
@@ -72,3 +79,153 @@ func Test_AsCadence(t *testing.T) {
 		})
 	}
 }
+
+func Test_DecodeArguments(t *testing.T) {
+	const txCode = `
+transaction(
+	recipient: Address,
+	amount: UFix64,
+	id: UInt64,
+	big: UInt256,
+	delta: Int8,
+	name: String?,
+	ids: [UInt64],
+	pair: [Bool; 2],
+	scores: {String: Int}
+) {
+	prepare(signer: AuthAccount) {}
+}`
+
+	address := cadence.NewAddress(flow.HexToAddress("0xf8d6e0586b0a20c7"))
+	amount, _ := cadence.NewUFix64("1.0")
+	big, _ := cadence.NewUInt256FromBig(new(big.Int).Lsh(big.NewInt(1), 100))
+	expected := []cadence.Value{
+		address,
+		amount,
+		cadence.NewUInt64(18446744073709551615),
+		big,
+		cadence.NewInt8(-5),
+		cadence.NewOptional(nil),
+		cadence.NewArray([]cadence.Value{cadence.NewUInt64(1), cadence.NewUInt64(2)}),
+		cadence.NewArray([]cadence.Value{cadence.NewBool(true), cadence.NewBool(false)}),
+		cadence.NewDictionary([]cadence.KeyValuePair{
+			{Key: cadence.String("a"), Value: cadence.NewInt(1)},
+			{Key: cadence.String("b"), Value: cadence.NewInt(-2)},
+		}),
+	}
+
+	testCases := []struct {
+		name      string
+		code      string
+		inputJson string
+		expected  []cadence.Value
+		errorText string
+	}{
+		{
+			name:      "plain values",
+			code:      txCode,
+			inputJson: `["0xf8d6e0586b0a20c7", 1, 18446744073709551615, "1267650600228229401496703205376", -5, null, [1, 2], [true, false], {"b": -2, "a": 1}]`,
+			expected:  expected,
+		},
+		{
+			name:      "mixed JSON-Cadence and plain values",
+			code:      txCode,
+			inputJson: `[{"type":"Address","value":"0xf8d6e0586b0a20c7"}, {"type":"UFix64","value":"1.0"}, "18446744073709551615", 1267650600228229401496703205376, "-5", null, [1, 2], [true, false], {"a": 1, "b": -2}]`,
+			expected:  expected,
+		},
+		{
+			name:      "script main parameters",
+			code:      `pub fun main(a: Int, b: String): Int { return a }`,
+			inputJson: `[42, "b"]`,
+			expected:  []cadence.Value{cadence.NewInt(42), cadence.String("b")},
+		},
+		{
+			name:      "unparseable code falls back to JSON-Cadence",
+			code:      ``,
+			inputJson: `[{"type":"String","value":"Hello"}]`,
+			expected:  []cadence.Value{cadence.String("Hello")},
+		},
+		{
+			name:      "too few arguments",
+			code:      `pub fun main(a: Int, b: String): Int { return a }`,
+			inputJson: `[42]`,
+			errorText: "expected 2 arguments, got 1",
+		},
+		{
+			name:      "JSON-Cadence type mismatch",
+			code:      `pub fun main(a: Int): Int { return a }`,
+			inputJson: `[{"type":"String","value":"Hello"}]`,
+			errorText: "expected Int",
+		},
+		{
+			name:      "integer out of range",
+			code:      `pub fun main(a: UInt8): UInt8 { return a }`,
+			inputJson: `[256]`,
+			errorText: "expected UInt8",
+		},
+		{
+			name:      "invalid address",
+			code:      `pub fun main(a: Address): Address { return a }`,
+			inputJson: `["not an address"]`,
+			errorText: "not a valid address",
+		},
+		{
+			name:      "constant sized array length mismatch",
+			code:      `pub fun main(a: [Bool; 2]): Bool { return a[0] }`,
+			inputJson: `[[true]]`,
+			errorText: "expected an array of 2 elements, got 1",
+		},
+		{
+			name:      "composite types require JSON-Cadence",
+			code:      `pub fun main(a: Foo.Bar): Int { return 1 }`,
+			inputJson: `[{"x": 1}]`,
+			errorText: "can only be given as JSON-Cadence",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d: %s", i, tc.name), func(t *testing.T) {
+			var arguments []Argument
+
+			d := json.NewDecoder(strings.NewReader(tc.inputJson))
+			d.UseNumber()
+			if err := d.Decode(&arguments); err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := DecodeArguments(tc.code, arguments)
+
+			if tc.errorText != "" {
+				var reqErr *errors.RequestError
+				if !stdErrors.As(err, &reqErr) || reqErr.StatusCode != http.StatusBadRequest {
+					t.Fatalf("expected a bad request error, got: %#v", err)
+				}
+				if !strings.Contains(err.Error(), tc.errorText) {
+					t.Fatalf("expected error to contain %q, got %q", tc.errorText, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Compare encoded values as big integers can not be compared directly
+			if !cmp.Equal(encodeValues(t, decoded), encodeValues(t, tc.expected)) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(encodeValues(t, tc.expected), encodeValues(t, decoded)))
+			}
+		})
+	}
+}
+
+func encodeValues(t *testing.T, values []cadence.Value) []string {
+	encoded := make([]string, len(values))
+	for i, v := range values {
+		b, err := c_json.Encode(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded[i] = string(b)
+	}
+	return encoded
+}
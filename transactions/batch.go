@@ -0,0 +1,318 @@
+package transactions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxBatchErrors is the maximum number of item errors reported when a batch
+// fails validation.
+const maxBatchErrors = 10
+
+// BatchItem is a single transaction in a batch request.
+type BatchItem struct {
+	Reference   string     `json:"reference"`
+	Address     string     `json:"address"`
+	Code        string     `json:"code"`
+	Arguments   []Argument `json:"arguments"`
+	Authorizers []string   `json:"authorizers,omitempty"`
+	GasLimit    uint64     `json:"gasLimit,omitempty"`
+	Payer       string     `json:"payer,omitempty"`
+}
+
+// BatchResult is the result of creating the job of a single batch item.
+type BatchResult struct {
+	Reference string
+	Job       *jobs.Job
+	Err       error
+}
+
+// BatchReference database model, links the reference of a batch item to the
+// job creating and sending its transaction. References are unique, so a
+// retried batch returns the jobs of the already created items.
+type BatchReference struct {
+	Reference     string    `gorm:"column:reference;primaryKey"`
+	JobID         uuid.UUID `gorm:"column:job_id;type:uuid"`
+	Job           *jobs.Job `gorm:"foreignKey:JobID"`
+	TransactionId string    `gorm:"column:transaction_id;index"`
+	CreatedAt     time.Time `gorm:"column:created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at"`
+}
+
+func (BatchReference) TableName() string {
+	return "transaction_batch_references"
+}
+
+// Batch JSON HTTP request
+type BatchJSONRequest struct {
+	Items []BatchItem `json:"items"`
+}
+
+// Batch item result JSON HTTP response
+type BatchResultJSONResponse struct {
+	Reference     string             `json:"reference"`
+	TransactionId string             `json:"transactionId,omitempty"`
+	Job           *jobs.JSONResponse `json:"job,omitempty"`
+	Error         string             `json:"error,omitempty"`
+}
+
+func (r BatchResult) ToJSONResponse() BatchResultJSONResponse {
+	res := BatchResultJSONResponse{Reference: r.Reference}
+
+	if r.Job != nil {
+		job := r.Job.ToJSONResponse()
+		res.Job = &job
+		res.TransactionId = r.Job.TransactionID
+	}
+
+	if r.Err != nil {
+		res.Error = r.Err.Error()
+	}
+
+	return res
+}
+
+// CreateBatch creates and schedules a job for each item, the job builds,
+// signs and sends the transaction of the item. The whole batch is validated
+// before any job is created, after that items succeed or fail independently.
+// Items whose reference is already stored are not created again, their
+// existing job is returned instead.
+func (s *ServiceImpl) CreateBatch(ctx context.Context, items []BatchItem) ([]BatchResult, error) {
+	if err := s.validateBatch(items); err != nil {
+		return nil, err
+	}
+
+	references := make([]string, len(items))
+	for i, item := range items {
+		references[i] = item.Reference
+	}
+
+	existing, err := s.batchJobs(references)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(items))
+
+	var created []BatchReference
+	createdIndexes := make(map[string]int)
+
+	for i, item := range items {
+		results[i].Reference = item.Reference
+
+		if job, ok := existing[item.Reference]; ok {
+			results[i].Job = job
+			continue
+		}
+
+		attrBytes, err := json.Marshal(item)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		job, err := s.wp.CreateJob(BatchTransactionJobType, "", jobs.WithAttributes(attrBytes))
+		if err != nil {
+			results[i].Err = fmt.Errorf("error while creating job: %w", err)
+			continue
+		}
+
+		results[i].Job = job
+		created = append(created, BatchReference{Reference: item.Reference, JobID: job.ID})
+		createdIndexes[item.Reference] = i
+	}
+
+	if err := s.store.InsertBatchReferences(created); err != nil {
+		return nil, err
+	}
+
+	createdReferences := make([]string, len(created))
+	for i, r := range created {
+		createdReferences[i] = r.Reference
+	}
+
+	// A concurrent request may have stored some of the references first, its
+	// jobs are returned and the jobs created here fail without a transaction
+	stored, err := s.batchJobs(createdReferences)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range created {
+		i := createdIndexes[r.Reference]
+
+		if job, ok := stored[r.Reference]; ok && job.ID != r.JobID {
+			results[i].Job = job
+			continue
+		}
+
+		if err := s.wp.Schedule(results[i].Job); err != nil {
+			results[i].Err = fmt.Errorf("error while scheduling job: %w", err)
+		}
+	}
+
+	return results, nil
+}
+
+// batchJobs returns the jobs of the stored references by reference.
+func (s *ServiceImpl) batchJobs(references []string) (map[string]*jobs.Job, error) {
+	rr, err := s.store.BatchReferences(references)
+	if err != nil {
+		return nil, err
+	}
+
+	jj := make(map[string]*jobs.Job, len(rr))
+	for _, r := range rr {
+		if r.Job != nil {
+			jj[r.Reference] = r.Job
+		}
+	}
+
+	return jj, nil
+}
+
+func (s *ServiceImpl) executeBatchTransactionJob(ctx context.Context, j *jobs.Job) error {
+	if j.Type != BatchTransactionJobType {
+		return jobs.ErrInvalidJobType
+	}
+
+	j.ShouldSendNotification = true
+
+	// Keep numbers as is so large integer arguments do not lose precision
+	var item BatchItem
+	decoder := json.NewDecoder(bytes.NewReader(j.Attributes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&item); err != nil {
+		return err
+	}
+
+	r, err := s.store.BatchReference(item.Reference)
+	if err != nil && !stdErrors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err != nil || r.JobID != j.ID {
+		return jobs.PermanentFailure(fmt.Errorf("reference %q belongs to another job", item.Reference))
+	}
+
+	var tx *Transaction
+
+	if r.TransactionId == "" {
+		tx, err = s.newTransaction(ctx, item.Address, item.Code, item.Arguments, General, parseTransactionOptions([]TransactionOption{
+			WithAuthorizers(item.Authorizers...),
+			WithGasLimit(item.GasLimit),
+			WithPayer(item.Payer),
+		}))
+		if err != nil {
+			return fmt.Errorf("error while getting new transaction: %w", err)
+		}
+
+		if err := s.store.InsertBatchTransaction(&r, tx); err != nil {
+			return fmt.Errorf("error while inserting transaction in db: %w", err)
+		}
+	} else {
+		// Created by a previous run of the job
+		stored, err := s.store.Transaction(r.TransactionId)
+		if err != nil {
+			return err
+		}
+		tx = &stored
+	}
+
+	j.TransactionID = tx.TransactionId
+
+	// Re-sign the transaction if it has gone stale since a previous run
+	if err := s.refreshTransaction(ctx, tx); err != nil {
+		return err
+	}
+
+	j.TransactionID = tx.TransactionId
+
+	return s.sendTransaction(ctx, tx)
+}
+
+func (s *ServiceImpl) validateBatch(items []BatchItem) error {
+	if len(items) == 0 {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("empty batch"),
+		}
+	}
+
+	if len(items) > s.cfg.TransactionMaxBatchSize {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("batch of %d items exceeds the maximum of %d", len(items), s.cfg.TransactionMaxBatchSize),
+		}
+	}
+
+	var itemErrors []string
+	references := make(map[string]bool, len(items))
+
+	for i, item := range items {
+		if err := s.validateBatchItem(item, references); err != nil {
+			itemErrors = append(itemErrors, fmt.Sprintf("item %d (%q): %s", i, item.Reference, err))
+		}
+	}
+
+	if len(itemErrors) > 0 {
+		if len(itemErrors) > maxBatchErrors {
+			itemErrors = append(itemErrors[:maxBatchErrors], fmt.Sprintf("and %d more", len(itemErrors)-maxBatchErrors))
+		}
+
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid batch: %s", strings.Join(itemErrors, "; ")),
+		}
+	}
+
+	return nil
+}
+
+func (s *ServiceImpl) validateBatchItem(item BatchItem, references map[string]bool) error {
+	if item.Reference == "" {
+		return fmt.Errorf("missing reference")
+	}
+
+	if references[item.Reference] {
+		return fmt.Errorf("duplicate reference")
+	}
+	references[item.Reference] = true
+
+	if item.Code == "" {
+		return fmt.Errorf("missing code")
+	}
+
+	addresses := append([]string{item.Address}, item.Authorizers...)
+	if item.Payer != "" {
+		addresses = append(addresses, item.Payer)
+	}
+
+	for _, a := range addresses {
+		if _, err := flow_helpers.ValidateAddress(a, s.cfg.ChainID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.gasLimit(item.GasLimit); err != nil {
+		return err
+	}
+
+	if _, err := DecodeArguments(item.Code, item.Arguments); err != nil {
+		return err
+	}
+
+	return nil
+}
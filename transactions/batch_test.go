@@ -0,0 +1,95 @@
+package transactions
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestValidateBatch(t *testing.T) {
+	svc := &ServiceImpl{cfg: &configs.Config{
+		ChainID:                 flow.Emulator,
+		TransactionMaxGasLimit:  9999,
+		TransactionMaxBatchSize: 3,
+	}}
+
+	const code = "transaction(amount: UFix64) { prepare(signer: AuthAccount){} execute {}}"
+	valid := BatchItem{Reference: "a", Address: "0xf8d6e0586b0a20c7", Code: code, Arguments: []Argument{"1.0"}}
+
+	item := func(modify func(*BatchItem)) BatchItem {
+		i := valid
+		modify(&i)
+		return i
+	}
+
+	testCases := []struct {
+		name      string
+		items     []BatchItem
+		errorText string
+	}{
+		{
+			name:  "valid",
+			items: []BatchItem{valid, item(func(i *BatchItem) { i.Reference = "b" })},
+		},
+		{
+			name:      "empty",
+			items:     nil,
+			errorText: "empty batch",
+		},
+		{
+			name: "too large",
+			items: []BatchItem{
+				item(func(i *BatchItem) { i.Reference = "a" }),
+				item(func(i *BatchItem) { i.Reference = "b" }),
+				item(func(i *BatchItem) { i.Reference = "c" }),
+				item(func(i *BatchItem) { i.Reference = "d" }),
+			},
+			errorText: "exceeds the maximum of 3",
+		},
+		{
+			name:      "duplicate reference",
+			items:     []BatchItem{valid, valid},
+			errorText: `item 1 ("a"): duplicate reference`,
+		},
+		{
+			name:      "missing reference",
+			items:     []BatchItem{item(func(i *BatchItem) { i.Reference = "" })},
+			errorText: "missing reference",
+		},
+		{
+			name:      "invalid address",
+			items:     []BatchItem{item(func(i *BatchItem) { i.Address = "0x1" })},
+			errorText: "not a valid address",
+		},
+		{
+			name:      "invalid arguments",
+			items:     []BatchItem{item(func(i *BatchItem) { i.Arguments = nil })},
+			errorText: "expected 1 arguments, got 0",
+		},
+		{
+			name:      "gas limit exceeded",
+			items:     []BatchItem{item(func(i *BatchItem) { i.GasLimit = 10000 })},
+			errorText: "gas limit",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d: %s", i, tc.name), func(t *testing.T) {
+			err := svc.validateBatch(tc.items)
+
+			if tc.errorText == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tc.errorText) {
+				t.Fatalf("expected error to contain %q, got %v", tc.errorText, err)
+			}
+		})
+	}
+}
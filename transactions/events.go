@@ -0,0 +1,81 @@
+package transactions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/onflow/cadence"
+	c_json "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Event is the database model for events emitted by transactions.
+type Event struct {
+	TransactionId    string    `gorm:"column:transaction_id;primaryKey"`
+	EventIndex       int       `gorm:"column:event_index;primaryKey;autoIncrement:false"`
+	TransactionIndex int       `gorm:"column:transaction_index"`
+	Type             string    `gorm:"column:type;index"`
+	Payload          []byte    `gorm:"column:payload;type:bytes"`
+	CreatedAt        time.Time `gorm:"column:created_at"`
+}
+
+func (Event) TableName() string {
+	return "transaction_events"
+}
+
+func eventFromFlow(e flow.Event) Event {
+	return Event{
+		TransactionId:    e.TransactionID.Hex(),
+		EventIndex:       e.EventIndex,
+		TransactionIndex: e.TransactionIndex,
+		Type:             e.Type,
+		Payload:          e.Payload,
+	}
+}
+
+// ToFlow converts a stored event back to a flow.Event, decoding its payload.
+func (e Event) ToFlow() (flow.Event, error) {
+	value, err := c_json.Decode(nil, e.Payload)
+	if err != nil {
+		return flow.Event{}, err
+	}
+
+	eventValue, ok := value.(cadence.Event)
+	if !ok {
+		return flow.Event{}, fmt.Errorf("expected an event value, got %s", value.Type().ID())
+	}
+
+	return flow.Event{
+		Type:             e.Type,
+		TransactionID:    flow.HexToID(e.TransactionId),
+		TransactionIndex: e.TransactionIndex,
+		EventIndex:       e.EventIndex,
+		Value:            eventValue,
+		Payload:          e.Payload,
+	}, nil
+}
+
+// DecodedEvent is an event with its payload decoded to plain JSON values keyed
+// by field name.
+type DecodedEvent struct {
+	Type             string                 `json:"type"`
+	TransactionIndex int                    `json:"transactionIndex"`
+	EventIndex       int                    `json:"eventIndex"`
+	Fields           map[string]interface{} `json:"fields"`
+}
+
+// DecodeEvent converts the fields of a flow.Event to plain JSON values.
+func DecodeEvent(e flow.Event) DecodedEvent {
+	var fieldTypes []cadence.Field
+	if e.Value.EventType != nil {
+		fieldTypes = e.Value.EventType.Fields
+	}
+
+	return DecodedEvent{
+		Type:             e.Type,
+		TransactionIndex: e.TransactionIndex,
+		EventIndex:       e.EventIndex,
+		Fields:           flow_helpers.CompositeFieldsToJSON(fieldTypes, e.Value.Fields),
+	}
+}
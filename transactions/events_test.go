@@ -0,0 +1,94 @@
+package transactions
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/onflow/cadence"
+	c_json "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestEventRoundTrip(t *testing.T) {
+	value := cadence.NewEvent([]cadence.Value{
+		cadence.UFix64(100000000),
+		cadence.NewAddress(flow.HexToAddress("0xf8d6e0586b0a20c7")),
+	}).WithType(&cadence.EventType{
+		Location:            common.AddressLocation{Address: common.Address(flow.HexToAddress("0x0ae53cb6e3f42a79")), Name: "FlowToken"},
+		QualifiedIdentifier: "FlowToken.TokensDeposited",
+		Fields: []cadence.Field{
+			{Identifier: "amount", Type: cadence.UFix64Type{}},
+			{Identifier: "to", Type: cadence.OptionalType{Type: cadence.AddressType{}}},
+		},
+	})
+
+	payload, err := c_json.Encode(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := flow.Event{
+		Type:             "A.0ae53cb6e3f42a79.FlowToken.TokensDeposited",
+		TransactionID:    flow.HexToID("0e4f500d6965c7fc0ff1239525e09eb9dd27c00a511976e353d9f6a44ca22921"),
+		TransactionIndex: 1,
+		EventIndex:       2,
+		Value:            value,
+		Payload:          payload,
+	}
+
+	stored := eventFromFlow(original)
+
+	if stored.TransactionId != original.TransactionID.Hex() {
+		t.Fatalf("expected transaction id %s, got %s", original.TransactionID.Hex(), stored.TransactionId)
+	}
+
+	restored, err := stored.ToFlow()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.ID() != original.ID() {
+		t.Fatalf("expected restored event to match the original, got %s", restored)
+	}
+
+	if restored.Value.String() != original.Value.String() {
+		t.Fatalf("expected value %s, got %s", original.Value, restored.Value)
+	}
+}
+
+func TestEventToFlowInvalidPayload(t *testing.T) {
+	if _, err := (Event{Payload: []byte(`{"type":"String","value":"not an event"}`)}).ToFlow(); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	value := cadence.NewEvent([]cadence.Value{
+		cadence.UFix64(150000000),
+		cadence.NewOptional(cadence.NewAddress(flow.HexToAddress("0xf8d6e0586b0a20c7"))),
+	}).WithType(&cadence.EventType{
+		QualifiedIdentifier: "FlowToken.TokensDeposited",
+		Fields: []cadence.Field{
+			{Identifier: "amount", Type: cadence.UFix64Type{}},
+			{Identifier: "to", Type: cadence.OptionalType{Type: cadence.AddressType{}}},
+		},
+	})
+
+	decoded := DecodeEvent(flow.Event{
+		Type:             "A.0ae53cb6e3f42a79.FlowToken.TokensDeposited",
+		TransactionIndex: 1,
+		EventIndex:       2,
+		Value:            value,
+	})
+
+	b, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"type":"A.0ae53cb6e3f42a79.FlowToken.TokensDeposited","transactionIndex":1,"eventIndex":2,"fields":{"amount":"1.50000000","to":"0xf8d6e0586b0a20c7"}}`
+	if string(b) != expected {
+		t.Fatalf("expected %s, got %s", expected, b)
+	}
+}
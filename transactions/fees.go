@@ -0,0 +1,164 @@
+package transactions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// feesDeductedEventSuffix is the suffix of the event emitted for the fees
+// paid by a transaction. FlowFees.FeesWithdrawn is emitted when the collected
+// fees are withdrawn from the fee vault, it is not a transaction fee.
+const feesDeductedEventSuffix = ".FlowFees.FeesDeducted"
+
+// FeeGrouping defines how transaction fees are aggregated.
+type FeeGrouping string
+
+const (
+	FeesByProposer FeeGrouping = "proposer"
+	FeesByPayer    FeeGrouping = "payer"
+	FeesByType     FeeGrouping = "type"
+	FeesByDate     FeeGrouping = "date"
+)
+
+func ParseFeeGrouping(text string) (FeeGrouping, error) {
+	switch g := FeeGrouping(strings.ToLower(text)); g {
+	case FeesByProposer, FeesByPayer, FeesByType, FeesByDate:
+		return g, nil
+	}
+	return "", fmt.Errorf("invalid fee grouping: %q", text)
+}
+
+// column returns the column or expression transactions are grouped by.
+func (g FeeGrouping) column() string {
+	switch g {
+	case FeesByPayer:
+		return "payer_address"
+	case FeesByType:
+		return "transaction_type"
+	case FeesByDate:
+		return "DATE(created_at)"
+	default:
+		return "proposer_address"
+	}
+}
+
+// transactionFees holds the fees paid for a transaction in the smallest unit
+// of UFix64, as reported by the FlowFees contract.
+type transactionFees struct {
+	Amount          uint64
+	InclusionEffort uint64
+	ExecutionEffort uint64
+}
+
+// feesFromEvents parses the fees of a transaction from its
+// FlowFees.FeesDeducted event.
+func feesFromEvents(events []flow.Event) (transactionFees, bool) {
+	for _, e := range events {
+		if !strings.HasSuffix(e.Type, feesDeductedEventSuffix) {
+			continue
+		}
+
+		if e.Value.EventType == nil {
+			continue
+		}
+
+		var f transactionFees
+		for i, field := range e.Value.EventType.Fields {
+			if i >= len(e.Value.Fields) {
+				break
+			}
+
+			v, ok := e.Value.Fields[i].(cadence.UFix64)
+			if !ok {
+				continue
+			}
+
+			switch field.Identifier {
+			case "amount":
+				f.Amount = uint64(v)
+			case "inclusionEffort":
+				f.InclusionEffort = uint64(v)
+			case "executionEffort":
+				f.ExecutionEffort = uint64(v)
+			}
+		}
+
+		return f, true
+	}
+
+	return transactionFees{}, false
+}
+
+// formatUFix64 formats a value in the smallest unit of UFix64 as a decimal
+// string, zero values are formatted as an empty string.
+func formatUFix64(v uint64) string {
+	if v == 0 {
+		return ""
+	}
+	return cadence.UFix64(v).String()
+}
+
+// FeeSummary is the sum of fees paid for a group of transactions.
+type FeeSummary struct {
+	Group            string `gorm:"column:grp"`
+	TransactionCount int64  `gorm:"column:transaction_count"`
+	FeeAmount        uint64 `gorm:"column:fee_amount"`
+	InclusionEffort  uint64 `gorm:"column:inclusion_effort"`
+	ExecutionEffort  uint64 `gorm:"column:execution_effort"`
+}
+
+// Fee summary JSON HTTP response
+type FeeSummaryJSONResponse struct {
+	Group            string `json:"group"`
+	TransactionCount int64  `json:"transactionCount"`
+	Fees             string `json:"fees"`
+	InclusionEffort  string `json:"inclusionEffort"`
+	ExecutionEffort  string `json:"executionEffort"`
+}
+
+func (f FeeSummary) ToJSONResponse() FeeSummaryJSONResponse {
+	return FeeSummaryJSONResponse{
+		Group:            f.Group,
+		TransactionCount: f.TransactionCount,
+		Fees:             cadence.UFix64(f.FeeAmount).String(),
+		InclusionEffort:  cadence.UFix64(f.InclusionEffort).String(),
+		ExecutionEffort:  cadence.UFix64(f.ExecutionEffort).String(),
+	}
+}
+
+// FeeFilter restricts the transactions included in fee summaries. Zero values
+// are ignored. From is inclusive and To exclusive.
+type FeeFilter struct {
+	From time.Time
+	To   time.Time
+}
+
+// FeeSummaries returns the sum of fees paid for transactions, grouped by
+// proposer, payer, transaction type or date.
+func (s *ServiceImpl) FeeSummaries(groupBy FeeGrouping, filter FeeFilter) ([]FeeSummary, error) {
+	ss, err := s.store.FeeSummaries(groupBy, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ss {
+		switch groupBy {
+		case FeesByType:
+			if t, err := strconv.Atoi(ss[i].Group); err == nil {
+				ss[i].Group = Type(t).String()
+			}
+		case FeesByDate:
+			// Some databases return dates as timestamps
+			if len(ss[i].Group) > len("2006-01-02") {
+				ss[i].Group = ss[i].Group[:len("2006-01-02")]
+			}
+		}
+	}
+
+	return ss, nil
+}
@@ -0,0 +1,71 @@
+package transactions
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestFeesFromEvents(t *testing.T) {
+	deducted := cadence.NewEvent([]cadence.Value{
+		cadence.UFix64(1000),
+		cadence.UFix64(100000000),
+		cadence.UFix64(2500000),
+	}).WithType(&cadence.EventType{
+		QualifiedIdentifier: "FlowFees.FeesDeducted",
+		Fields: []cadence.Field{
+			{Identifier: "amount", Type: cadence.UFix64Type{}},
+			{Identifier: "inclusionEffort", Type: cadence.UFix64Type{}},
+			{Identifier: "executionEffort", Type: cadence.UFix64Type{}},
+		},
+	})
+
+	events := []flow.Event{
+		{Type: "A.0ae53cb6e3f42a79.FlowToken.TokensWithdrawn"},
+		{Type: "A.e5a8b7f23e8b548f.FlowFees.FeesDeducted", Value: deducted},
+	}
+
+	f, ok := feesFromEvents(events)
+	if !ok {
+		t.Fatal("expected fees to be found")
+	}
+
+	expected := transactionFees{Amount: 1000, InclusionEffort: 100000000, ExecutionEffort: 2500000}
+	if f != expected {
+		t.Fatalf("expected %+v, got %+v", expected, f)
+	}
+
+	if _, ok := feesFromEvents(events[:1]); ok {
+		t.Fatal("expected no fees without a FlowFees event")
+	}
+
+	withdrawn := cadence.NewEvent([]cadence.Value{
+		cadence.UFix64(5000),
+		cadence.NewAddress(flow.HexToAddress("0xe5a8b7f23e8b548f")),
+	}).WithType(&cadence.EventType{
+		QualifiedIdentifier: "FlowFees.FeesWithdrawn",
+		Fields: []cadence.Field{
+			{Identifier: "amount", Type: cadence.UFix64Type{}},
+			{Identifier: "to", Type: cadence.AddressType{}},
+		},
+	})
+
+	if _, ok := feesFromEvents([]flow.Event{{Type: "A.e5a8b7f23e8b548f.FlowFees.FeesWithdrawn", Value: withdrawn}}); ok {
+		t.Fatal("expected no fees from a FeesWithdrawn event")
+	}
+
+	if s := formatUFix64(f.Amount); s != "0.00001000" {
+		t.Fatalf("expected formatted amount 0.00001000, got %s", s)
+	}
+}
+
+func TestParseFeeGrouping(t *testing.T) {
+	if g, err := ParseFeeGrouping("Type"); err != nil || g != FeesByType {
+		t.Fatalf("expected %s, got %s (err: %v)", FeesByType, g, err)
+	}
+
+	if _, err := ParseFeeGrouping("tenant"); err == nil {
+		t.Fatal("expected an error for an unknown grouping")
+	}
+}
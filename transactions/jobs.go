@@ -3,11 +3,25 @@ package transactions
 import (
 	"context"
 
+	"github.com/flow-hydraulics/flow-wallet-api/datastore"
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
 	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/access/grpc"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
 )
 
 const TransactionJobType = "transaction"
 
+const BatchTransactionJobType = "batch_transaction"
+
+const TransactionStatusReconcilerName = "transaction_status"
+
+// reconcileBatchSize is the maximum number of transactions to check per
+// reconciler run.
+const reconcileBatchSize = 100
+
 func (s *ServiceImpl) executeTransactionJob(ctx context.Context, j *jobs.Job) error {
 	if j.Type != TransactionJobType {
 		return jobs.ErrInvalidJobType
@@ -20,6 +34,14 @@ func (s *ServiceImpl) executeTransactionJob(ctx context.Context, j *jobs.Job) er
 		return err
 	}
 
+	// Re-sign the transaction if it has gone stale while waiting in the queue
+	err = s.refreshTransaction(ctx, &tx)
+	if err != nil {
+		return err
+	}
+
+	j.TransactionID = tx.TransactionId
+
 	err = s.sendTransaction(ctx, &tx)
 	if err != nil {
 		return err
@@ -27,3 +49,91 @@ func (s *ServiceImpl) executeTransactionJob(ctx context.Context, j *jobs.Job) er
 
 	return nil
 }
+
+// reconcileTransactionStatuses polls the chain for the results of transactions
+// that have not reached a final status yet and updates them. Transactions the
+// access node does not know are marked expired once their reference block has
+// expired. Errors are logged per transaction so one does not block the rest.
+func (s *ServiceImpl) reconcileTransactionStatuses(ctx context.Context) error {
+	entry := log.WithFields(log.Fields{"function": "ServiceImpl.reconcileTransactionStatuses"})
+
+	tt, err := s.store.UnresolvedTransactions(datastore.ParseListOptions(reconcileBatchSize, 0))
+	if err != nil {
+		return err
+	}
+
+	if len(tt) == 0 {
+		return nil
+	}
+
+	latestBlock, err := s.fc.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	for i := range tt {
+		tx := &tt[i]
+		previous := tx.Status
+
+		if err := s.reconcileTransactionStatus(ctx, tx, latestBlock.Height); err != nil {
+			entry.
+				WithFields(log.Fields{"transactionId": tx.TransactionId, "err": err}).
+				Warn("Failed to reconcile transaction status")
+			continue
+		}
+
+		if tx.Status != previous {
+			entry.
+				WithFields(log.Fields{"transactionId": tx.TransactionId, "status": tx.Status}).
+				Debug("Transaction status updated")
+		}
+	}
+
+	return nil
+}
+
+func (s *ServiceImpl) reconcileTransactionStatus(ctx context.Context, tx *Transaction, latestHeight uint64) error {
+	result, err := s.fc.GetTransactionResult(ctx, flow.HexToID(tx.TransactionId))
+	if err != nil {
+		if rpcErr, ok := err.(grpc.RPCError); !ok || rpcErr.GRPCStatus().Code() != codes.NotFound {
+			return err
+		}
+
+		// Not sent yet (or unknown to the access node), check again later
+		// unless it can no longer be included
+		expired, err := s.isExpired(ctx, tx, latestHeight)
+		if err != nil {
+			return err
+		}
+
+		if expired {
+			tx.Status = StatusExpired
+			tx.ErrorMessage = "transaction expired"
+		}
+
+		return s.store.UpdateTransactionStatus(tx)
+	}
+
+	return s.updateStatus(ctx, tx, result)
+}
+
+// isExpired checks whether the reference block of the transaction is more
+// than flow_helpers.TransactionExpiry blocks behind the latest block.
+func (s *ServiceImpl) isExpired(ctx context.Context, tx *Transaction, latestHeight uint64) (bool, error) {
+	flowTx, err := flow.DecodeTransaction(tx.FlowTransaction)
+	if err != nil {
+		return false, err
+	}
+
+	referenceBlock, err := s.fc.GetBlockHeaderByID(ctx, flowTx.ReferenceBlockID)
+	if err != nil {
+		if rpcErr, ok := err.(grpc.RPCError); ok && rpcErr.GRPCStatus().Code() == codes.NotFound {
+			// Reference block is not known by the access node (e.g. previous spork)
+			return true, nil
+		}
+		return false, err
+	}
+
+	return latestHeight > referenceBlock.Height &&
+		latestHeight-referenceBlock.Height > flow_helpers.TransactionExpiry, nil
+}
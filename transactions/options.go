@@ -3,9 +3,93 @@ package transactions
 import "go.uber.org/ratelimit"
 
 type ServiceOption func(*ServiceImpl)
+type TransactionOption func(*transactionOptions)
+type ScriptOption func(*scriptOptions)
+
+// SignerCheck is called before a custodial account signs a transaction, an
+// error rejects the transaction.
+type SignerCheck func(address string) error
+
+// transactionOptions holds the optional parameters of a single transaction.
+type transactionOptions struct {
+	authorizers []string
+	gasLimit    uint64
+	payer       string
+}
+
+// scriptOptions holds the optional parameters of a single script execution.
+type scriptOptions struct {
+	blockHeight *uint64
+	blockID     string
+}
 
 func WithTxRatelimiter(limiter ratelimit.Limiter) ServiceOption {
 	return func(svc *ServiceImpl) {
 		svc.txRateLimiter = limiter
 	}
 }
+
+// WithSignerCheck sets a check for custodial accounts signing transactions,
+// e.g. to reject frozen accounts.
+func WithSignerCheck(check SignerCheck) ServiceOption {
+	return func(svc *ServiceImpl) {
+		svc.signerCheck = check
+	}
+}
+
+// WithAuthorizers sets the ordered list of authorizer addresses for a
+// transaction. If no authorizers are given the proposer is used as the sole
+// authorizer.
+func WithAuthorizers(addresses ...string) TransactionOption {
+	return func(o *transactionOptions) {
+		o.authorizers = addresses
+	}
+}
+
+// WithGasLimit sets the gas limit of a transaction. The limit may not exceed
+// the configured maximum. If no limit is given the default limit is used.
+func WithGasLimit(limit uint64) TransactionOption {
+	return func(o *transactionOptions) {
+		o.gasLimit = limit
+	}
+}
+
+// WithPayer sets the payer of a transaction. The payer must be either the admin
+// account or a custodial account. If no payer is given the admin account pays.
+func WithPayer(address string) TransactionOption {
+	return func(o *transactionOptions) {
+		o.payer = address
+	}
+}
+
+func parseTransactionOptions(opts []TransactionOption) transactionOptions {
+	o := transactionOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithBlockHeight executes a script at the given block height instead of the
+// latest sealed block.
+func WithBlockHeight(height uint64) ScriptOption {
+	return func(o *scriptOptions) {
+		o.blockHeight = &height
+	}
+}
+
+// WithBlockID executes a script at the block with the given ID instead of the
+// latest sealed block.
+func WithBlockID(id string) ScriptOption {
+	return func(o *scriptOptions) {
+		o.blockID = id
+	}
+}
+
+func parseScriptOptions(opts []ScriptOption) scriptOptions {
+	o := scriptOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -14,20 +14,27 @@ import (
 	"github.com/onflow/cadence"
 	"github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go-sdk/access/grpc"
+	"github.com/onflow/flow-go-sdk/crypto"
+	log "github.com/sirupsen/logrus"
 	"go.uber.org/ratelimit"
 	"google.golang.org/grpc/codes"
 )
 
 type Service interface {
-	Create(ctx context.Context, sync bool, proposerAddress string, code string, args []Argument, tType Type) (*jobs.Job, *Transaction, error)
-	Sign(ctx context.Context, proposerAddress string, code string, args []Argument) (*SignedTransaction, error)
-	List(limit, offset int) ([]Transaction, error)
-	ListForAccount(tType Type, address string, limit, offset int) ([]Transaction, error)
+	Create(ctx context.Context, sync bool, proposerAddress string, code string, args []Argument, tType Type, opts ...TransactionOption) (*jobs.Job, *Transaction, error)
+	Sign(ctx context.Context, proposerAddress string, code string, args []Argument, opts ...TransactionOption) (*SignedTransaction, error)
+	Submit(ctx context.Context, sync bool, proposerAddress string, flowTx *flow.Transaction) (*jobs.Job, *Transaction, error)
+	CreateBatch(ctx context.Context, items []BatchItem) ([]BatchResult, error)
+	FeeSummaries(groupBy FeeGrouping, filter FeeFilter) ([]FeeSummary, error)
+	List(status Status, limit, offset int) ([]Transaction, error)
+	ListForAccount(tType Type, address string, status Status, limit, offset int) ([]Transaction, error)
 	Details(ctx context.Context, transactionId string) (*Transaction, error)
 	DetailsForAccount(ctx context.Context, tType Type, address, transactionId string) (*Transaction, error)
-	ExecuteScript(ctx context.Context, code string, args []Argument) (cadence.Value, error)
+	ExecuteScript(ctx context.Context, code string, args []Argument, opts ...ScriptOption) (cadence.Value, error)
 	UpdateTransaction(t *Transaction) error
+	RecordResult(ctx context.Context, flowTx *flow.Transaction, tType Type, result *flow.TransactionResult) error
 	GetOrCreateTransaction(transactionId string) *Transaction
+	StoreEvents(events ...flow.Event) error
 }
 
 // ServiceImpl defines the API for transaction HTTP handlers.
@@ -38,6 +45,7 @@ type ServiceImpl struct {
 	wp            jobs.WorkerPool
 	cfg           *configs.Config
 	txRateLimiter ratelimit.Limiter
+	signerCheck   SignerCheck
 }
 
 // NewService initiates a new transaction service.
@@ -52,7 +60,7 @@ func NewService(
 	var defaultTxRatelimiter = ratelimit.NewUnlimited()
 
 	// TODO(latenssi): safeguard against nil config?
-	svc := &ServiceImpl{store, km, fc, wp, cfg, defaultTxRatelimiter}
+	svc := &ServiceImpl{store, km, fc, wp, cfg, defaultTxRatelimiter, nil}
 
 	for _, opt := range opts {
 		opt(svc)
@@ -64,16 +72,26 @@ func NewService(
 
 	// Register asynchronous job executor.
 	wp.RegisterExecutor(TransactionJobType, svc.executeTransactionJob)
+	wp.RegisterExecutor(BatchTransactionJobType, svc.executeBatchTransactionJob)
+
+	// Register transaction status reconciler.
+	wp.RegisterReconciler(TransactionStatusReconcilerName, svc.reconcileTransactionStatuses)
 
 	return svc
 }
 
-func (s *ServiceImpl) Create(ctx context.Context, sync bool, proposerAddress string, code string, args []Argument, tType Type) (*jobs.Job, *Transaction, error) {
-	transaction, err := s.newTransaction(ctx, proposerAddress, code, args, tType)
+func (s *ServiceImpl) Create(ctx context.Context, sync bool, proposerAddress string, code string, args []Argument, tType Type, opts ...TransactionOption) (*jobs.Job, *Transaction, error) {
+	transaction, err := s.newTransaction(ctx, proposerAddress, code, args, tType, parseTransactionOptions(opts))
 	if err != nil {
 		return nil, nil, fmt.Errorf("error while getting new transaction: %w", err)
 	}
 
+	return s.insertAndSend(ctx, sync, transaction)
+}
+
+// insertAndSend stores a new transaction and either sends it right away (sync)
+// or schedules a job for sending it (async).
+func (s *ServiceImpl) insertAndSend(ctx context.Context, sync bool, transaction *Transaction) (*jobs.Job, *Transaction, error) {
 	if err := s.store.InsertTransaction(transaction); err != nil {
 		return nil, nil, fmt.Errorf("error while inserting transaction in db: %w", err)
 	}
@@ -101,8 +119,8 @@ func (s *ServiceImpl) Create(ctx context.Context, sync bool, proposerAddress str
 	}
 }
 
-func (s *ServiceImpl) Sign(ctx context.Context, proposerAddress string, code string, args []Argument) (*SignedTransaction, error) {
-	flowTx, err := s.buildFlowTransaction(ctx, proposerAddress, code, args)
+func (s *ServiceImpl) Sign(ctx context.Context, proposerAddress string, code string, args []Argument, opts ...TransactionOption) (*SignedTransaction, error) {
+	flowTx, err := s.buildFlowTransaction(ctx, proposerAddress, code, args, parseTransactionOptions(opts))
 	if err != nil {
 		return nil, err
 	}
@@ -110,14 +128,163 @@ func (s *ServiceImpl) Sign(ctx context.Context, proposerAddress string, code str
 	return &SignedTransaction{Transaction: *flowTx}, nil
 }
 
-// List returns all transactions in the datastore.
-func (s *ServiceImpl) List(limit, offset int) ([]Transaction, error) {
+// Submit verifies the payload signatures of an externally signed transaction,
+// adds the envelope signature of the payer and sends the transaction. The payer
+// must be either the admin account or the proposer and it may not be an
+// authorizer, as its envelope signature would authorize the transaction without
+// the account holder. Any existing envelope signatures are replaced.
+func (s *ServiceImpl) Submit(ctx context.Context, sync bool, proposerAddress string, flowTx *flow.Transaction) (*jobs.Job, *Transaction, error) {
+	proposerAddress, err := flow_helpers.ValidateAddress(proposerAddress, s.cfg.ChainID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if flow.HexToAddress(proposerAddress) != flowTx.ProposalKey.Address {
+		return nil, nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("transaction proposer %s does not match %s", flowTx.ProposalKey.Address.Hex(), proposerAddress),
+		}
+	}
+
+	if flowTx.GasLimit > s.cfg.TransactionMaxGasLimit {
+		return nil, nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("gas limit %d exceeds the maximum of %d", flowTx.GasLimit, s.cfg.TransactionMaxGasLimit),
+		}
+	}
+
+	// Other custodial accounts can not be made to pay for the transaction
+	payerAddress := flow_helpers.FormatAddress(flowTx.Payer)
+	if payerAddress != s.cfg.AdminAddress && payerAddress != proposerAddress {
+		return nil, nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("payer %s must be the admin account or %s", payerAddress, proposerAddress),
+		}
+	}
+
+	payer, err := s.getPayerAuthorizer(ctx, payerAddress, keys.Authorizer{})
+	if err != nil {
+		return nil, nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("error while getting payer: %w", err),
+		}
+	}
+
+	for _, a := range flowTx.Authorizers {
+		if a == payer.Address {
+			return nil, nil, &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("payer %s can not be an authorizer", flowTx.Payer.Hex()),
+			}
+		}
+	}
+
+	if err := s.verifyPayloadSignatures(ctx, flowTx, payer); err != nil {
+		return nil, nil, err
+	}
+
+	flowTx.EnvelopeSignatures = nil
+
+	if err := payer.SignEnvelope(flowTx); err != nil {
+		return nil, nil, err
+	}
+
+	transaction := &Transaction{
+		TransactionId:    flowTx.ID().Hex(),
+		ProposerAddress:  proposerAddress,
+		PayerAddress:     payerAddress,
+		TransactionType:  General,
+		FlowTransaction:  flowTx.Encode(),
+		ExternallySigned: true,
+	}
+
+	return s.insertAndSend(ctx, sync, transaction)
+}
+
+// verifyPayloadSignatures checks that the payload signatures of a transaction
+// are valid signatures by non-revoked on-chain keys and that the proposer,
+// unless it's the payer, and each authorizer have signed with enough weight.
+func (s *ServiceImpl) verifyPayloadSignatures(ctx context.Context, flowTx *flow.Transaction, payer keys.Authorizer) error {
+	invalidErr := func(format string, a ...interface{}) error {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf(format, a...),
+		}
+	}
+
+	message := append(flow.TransactionDomainTag[:], flowTx.PayloadMessage()...)
+
+	accounts := make(map[flow.Address]*flow.Account)
+	weights := make(map[flow.Address]int)
+	signedKeys := make(map[flow.Address]map[int]bool)
+
+	for _, sig := range flowTx.PayloadSignatures {
+		account, ok := accounts[sig.Address]
+		if !ok {
+			var err error
+			account, err = s.fc.GetAccount(ctx, sig.Address)
+			if err != nil {
+				return err
+			}
+			accounts[sig.Address] = account
+		}
+
+		if sig.KeyIndex < 0 || sig.KeyIndex >= len(account.Keys) {
+			return invalidErr("key %d not found for %s", sig.KeyIndex, sig.Address)
+		}
+
+		key := account.Keys[sig.KeyIndex]
+		if key.Revoked {
+			return invalidErr("key %d of %s is revoked", sig.KeyIndex, sig.Address)
+		}
+
+		hasher, err := crypto.NewHasher(key.HashAlgo)
+		if err != nil {
+			return err
+		}
+
+		valid, err := key.PublicKey.Verify(sig.Signature, message, hasher)
+		if err != nil || !valid {
+			return invalidErr("invalid payload signature by key %d of %s", sig.KeyIndex, sig.Address)
+		}
+
+		if signedKeys[sig.Address] == nil {
+			signedKeys[sig.Address] = make(map[int]bool)
+		}
+
+		if !signedKeys[sig.Address][sig.KeyIndex] {
+			signedKeys[sig.Address][sig.KeyIndex] = true
+			weights[sig.Address] += key.Weight
+		}
+	}
+
+	// The payer covers itself with the envelope signature
+	if flowTx.ProposalKey.Address == payer.Address {
+		if flowTx.ProposalKey.KeyIndex != payer.Key.Index {
+			return invalidErr("proposal key of the payer account must be key %d", payer.Key.Index)
+		}
+	} else if !signedKeys[flowTx.ProposalKey.Address][flowTx.ProposalKey.KeyIndex] {
+		return invalidErr("missing payload signature by the proposal key")
+	}
+
+	for _, a := range flowTx.Authorizers {
+		if weights[a] < flow.AccountKeyWeightThreshold {
+			return invalidErr("insufficient payload signature weight for authorizer %s", a)
+		}
+	}
+
+	return nil
+}
+
+// List returns all transactions in the datastore, optionally filtered by status.
+func (s *ServiceImpl) List(status Status, limit, offset int) ([]Transaction, error) {
 	o := datastore.ParseListOptions(limit, offset)
-	return s.store.Transactions(o)
+	return s.store.Transactions(status, o)
 }
 
-// ListForAccount returns all transactions in the datastore for a given account.
-func (s *ServiceImpl) ListForAccount(tType Type, address string, limit, offset int) ([]Transaction, error) {
+// ListForAccount returns all transactions in the datastore for a given account,
+// optionally filtered by status.
+func (s *ServiceImpl) ListForAccount(tType Type, address string, status Status, limit, offset int) ([]Transaction, error) {
 	// Check if the input is a valid address
 	address, err := flow_helpers.ValidateAddress(address, s.cfg.ChainID)
 	if err != nil {
@@ -126,7 +293,7 @@ func (s *ServiceImpl) ListForAccount(tType Type, address string, limit, offset i
 
 	o := datastore.ParseListOptions(limit, offset)
 
-	return s.store.TransactionsForAccount(tType, address, o)
+	return s.store.TransactionsForAccount(tType, address, status, o)
 }
 
 // Details returns a specific transaction.
@@ -147,13 +314,10 @@ func (s *ServiceImpl) Details(ctx context.Context, transactionId string) (*Trans
 		return nil, err
 	}
 
-	result, err := s.fc.GetTransactionResult(ctx, flow.HexToID(transactionId))
-	if err != nil {
+	if err := s.loadEvents(ctx, &transaction); err != nil {
 		return nil, err
 	}
 
-	transaction.Events = result.Events
-
 	return &transaction, nil
 }
 
@@ -181,22 +345,44 @@ func (s *ServiceImpl) DetailsForAccount(ctx context.Context, tType Type, address
 		return nil, err
 	}
 
-	result, err := s.fc.GetTransactionResult(ctx, flow.HexToID(transactionId))
-	if err != nil {
+	if err := s.loadEvents(ctx, &transaction); err != nil {
 		return nil, err
 	}
 
-	transaction.Events = result.Events
-
 	return &transaction, nil
 }
 
-// Execute a script
-func (s *ServiceImpl) ExecuteScript(ctx context.Context, code string, args []Argument) (cadence.Value, error) {
+// Execute a script, at the latest sealed block unless a block height or ID is
+// given.
+func (s *ServiceImpl) ExecuteScript(ctx context.Context, code string, args []Argument, opts ...ScriptOption) (cadence.Value, error) {
+	o := parseScriptOptions(opts)
+
+	if o.blockHeight != nil && o.blockID != "" {
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("only one of block height and block id can be given"),
+		}
+	}
+
+	cadenceArgs, err := DecodeArguments(code, args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case o.blockHeight != nil:
+		return s.fc.ExecuteScriptAtBlockHeight(ctx, *o.blockHeight, []byte(code), cadenceArgs)
+	case o.blockID != "":
+		if err := flow_helpers.ValidateBlockId(o.blockID); err != nil {
+			return nil, err
+		}
+		return s.fc.ExecuteScriptAtBlockID(ctx, flow.HexToID(o.blockID), []byte(code), cadenceArgs)
+	}
+
 	return s.fc.ExecuteScriptAtLatestBlock(
 		ctx,
 		[]byte(code),
-		MustDecodeArgs(args),
+		cadenceArgs,
 	)
 }
 
@@ -208,74 +394,214 @@ func (s *ServiceImpl) GetOrCreateTransaction(transactionId string) *Transaction
 	return s.store.GetOrCreateTransaction(transactionId)
 }
 
-func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress, code string, arguments []Argument) (*flow.Transaction, error) {
-	latestBlockID, err := flow_helpers.LatestBlockId(ctx, s.fc)
-	if err != nil {
-		return nil, err
+// RecordResult stores a transaction sent outside of this service along with
+// the status, fees and events of its result, so that its fees are included in
+// fee summaries.
+func (s *ServiceImpl) RecordResult(ctx context.Context, flowTx *flow.Transaction, tType Type, result *flow.TransactionResult) error {
+	tx := &Transaction{
+		TransactionId:   flowTx.ID().Hex(),
+		ProposerAddress: flow_helpers.FormatAddress(flowTx.ProposalKey.Address),
+		PayerAddress:    flow_helpers.FormatAddress(flowTx.Payer),
+		TransactionType: tType,
+		FlowTransaction: flowTx.Encode(),
+	}
+
+	if err := s.store.InsertTransaction(tx); err != nil {
+		return err
+	}
+
+	return s.updateStatus(ctx, tx, result)
+}
+
+// StoreEvents stores individual events seen outside of a transaction result
+// (e.g. by the chain events listener).
+func (s *ServiceImpl) StoreEvents(events ...flow.Event) error {
+	ee := make([]Event, len(events))
+	for i, e := range events {
+		ee[i] = eventFromFlow(e)
 	}
+	return s.store.InsertEvents(ee)
+}
 
-	// Admin should always be the payer of the transaction fees.
-	payer, err := s.km.AdminAuthorizer(ctx)
+// loadEvents sets the events of a transaction. Events are served from the
+// database once the complete set has been stored. Otherwise they are fetched
+// from chain and stored if the transaction has reached a final status. If the
+// chain no longer has the data (e.g. after a spork) whatever events have been
+// stored are used.
+func (s *ServiceImpl) loadEvents(ctx context.Context, tx *Transaction) error {
+	if !tx.EventsStored {
+		result, err := s.fc.GetTransactionResult(ctx, flow.HexToID(tx.TransactionId))
+		if err == nil {
+			if status := statusFromResult(result); status == StatusSealed || status == StatusFailed {
+				if err := s.storeResultEvents(tx, result.Events); err != nil {
+					return err
+				}
+			}
+			tx.Events = result.Events
+			return nil
+		}
+
+		log.
+			WithFields(log.Fields{"transactionId": tx.TransactionId, "error": err}).
+			Warn("Could not fetch transaction result from chain, using stored events")
+	}
+
+	ee, err := s.store.Events(tx.TransactionId)
 	if err != nil {
-		return nil, fmt.Errorf("error while getting admin authorizer for payer: %w", err)
+		return err
 	}
 
-	proposer, err := s.getProposalAuthorizer(ctx, proposerAddress)
+	tx.Events = make([]flow.Event, len(ee))
+	for i, e := range ee {
+		if tx.Events[i], err = e.ToFlow(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storeResultEvents stores the complete set of events of a transaction.
+func (s *ServiceImpl) storeResultEvents(tx *Transaction, events []flow.Event) error {
+	ee := make([]Event, len(events))
+	for i, e := range events {
+		ee[i] = eventFromFlow(e)
+	}
+
+	if err := s.store.InsertTransactionEvents(tx.TransactionId, ee); err != nil {
+		return err
+	}
+
+	tx.EventsStored = true
+
+	return nil
+}
+
+func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress, code string, arguments []Argument, o transactionOptions) (*flow.Transaction, error) {
+	gasLimit, err := s.gasLimit(o.gasLimit)
 	if err != nil {
 		return nil, err
 	}
 
 	flowTx := flow.NewTransaction()
 	flowTx.
-		SetReferenceBlockID(*latestBlockID).
-		SetProposalKey(proposer.Address, proposer.Key.Index, proposer.Key.SequenceNumber).
-		SetPayer(payer.Address).
-		SetGasLimit(maxGasLimit).
+		SetGasLimit(gasLimit).
 		SetScript([]byte(code))
 
-	for _, arg := range arguments {
-		cv, err := ArgAsCadence(arg)
+	cadenceArgs, err := DecodeArguments(code, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cv := range cadenceArgs {
+		err = flowTx.AddArgument(cv)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		err = flowTx.AddArgument(cv)
+	// Add authorizers, proposer is the sole authorizer unless specified otherwise
+	authorizerAddresses := o.authorizers
+	if len(authorizerAddresses) == 0 {
+		authorizerAddresses = []string{proposerAddress}
+	}
+
+	for _, a := range authorizerAddresses {
+		address, err := flow_helpers.ValidateAddress(a, s.cfg.ChainID)
 		if err != nil {
 			return nil, err
 		}
+		flowTx.AddAuthorizer(flow.HexToAddress(address))
+	}
+
+	if err := s.signFlowTransaction(ctx, flowTx, proposerAddress, o.payer); err != nil {
+		return nil, err
+	}
+
+	return flowTx, nil
+}
+
+// gasLimit returns the gas limit to use for a transaction requesting the given
+// limit, enforcing the configured maximum.
+func (s *ServiceImpl) gasLimit(requested uint64) (uint64, error) {
+	if requested == 0 {
+		return DefaultGasLimit(s.cfg.TransactionMaxGasLimit), nil
+	}
+
+	if requested > s.cfg.TransactionMaxGasLimit {
+		return 0, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("gas limit %d exceeds the maximum of %d", requested, s.cfg.TransactionMaxGasLimit),
+		}
 	}
 
-	// Add authorizers. We assume proposer is always the sole authorizer
-	// https://github.com/flow-hydraulics/flow-wallet-api/issues/79
-	flowTx.AddAuthorizer(proposer.Address)
+	return requested, nil
+}
+
+// signFlowTransaction sets a fresh reference block, proposal key and payer for
+// the given transaction and signs it on behalf of the proposer, authorizers
+// and payer. If no payer address is given the admin account pays.
+func (s *ServiceImpl) signFlowTransaction(ctx context.Context, flowTx *flow.Transaction, proposerAddress, payerAddress string) error {
+	latestBlockID, err := flow_helpers.LatestBlockId(ctx, s.fc)
+	if err != nil {
+		return err
+	}
+
+	proposer, err := s.getProposalAuthorizer(ctx, proposerAddress)
+	if err != nil {
+		return err
+	}
+
+	payer, err := s.getPayerAuthorizer(ctx, payerAddress, proposer)
+	if err != nil {
+		return err
+	}
+
+	flowTx.
+		SetReferenceBlockID(*latestBlockID).
+		SetProposalKey(proposer.Address, proposer.Key.Index, proposer.Key.SequenceNumber).
+		SetPayer(payer.Address)
+
+	authorizers, err := s.getAuthorizers(ctx, flowTx.Authorizers, proposer, payer)
+	if err != nil {
+		return err
+	}
 
 	// Proposer signs the payload (unless proposer == payer).
 	if !proposer.Equals(payer) {
-		if err := flowTx.SignPayload(proposer.Address, proposer.Key.Index, proposer.Signer); err != nil {
-			return nil, err
+		if err := proposer.SignPayload(flowTx); err != nil {
+			return err
+		}
+	}
+
+	// Authorizers sign the payload, payer is covered by the envelope signature
+	for _, a := range authorizers {
+		if err := a.SignPayload(flowTx); err != nil {
+			return err
 		}
 	}
 
 	// Payer signs the envelope
-	if err := flowTx.SignEnvelope(payer.Address, payer.Key.Index, payer.Signer); err != nil {
-		return nil, err
+	if err := payer.SignEnvelope(flowTx); err != nil {
+		return err
 	}
 
-	return flowTx, nil
+	return nil
 }
 
-func (s *ServiceImpl) newTransaction(ctx context.Context, proposerAddress string, code string, args []Argument, tType Type) (*Transaction, error) {
+func (s *ServiceImpl) newTransaction(ctx context.Context, proposerAddress string, code string, args []Argument, tType Type, o transactionOptions) (*Transaction, error) {
 	tx := &Transaction{
 		ProposerAddress: proposerAddress,
 		TransactionType: tType,
 	}
 
-	flowTx, err := s.buildFlowTransaction(ctx, proposerAddress, code, args)
+	flowTx, err := s.buildFlowTransaction(ctx, proposerAddress, code, args, o)
 	if err != nil {
 		return nil, fmt.Errorf("error while building transaction: %w", err)
 	}
 
 	tx.TransactionId = flowTx.ID().Hex()
+	tx.PayerAddress = flow_helpers.FormatAddress(flowTx.Payer)
 	tx.FlowTransaction = flowTx.Encode()
 
 	return tx, nil
@@ -295,7 +621,7 @@ func (s *ServiceImpl) getProposalAuthorizer(ctx context.Context, proposerAddress
 			return keys.Authorizer{}, fmt.Errorf("error while getting admin authorizer: %w", err)
 		}
 	} else {
-		proposer, err = s.km.UserAuthorizer(ctx, flow.HexToAddress(proposerAddress))
+		proposer, err = s.userAuthorizer(ctx, flow.HexToAddress(proposerAddress))
 		if err != nil {
 			return keys.Authorizer{}, fmt.Errorf("error while getting user authorizer: %w", err)
 		}
@@ -304,36 +630,248 @@ func (s *ServiceImpl) getProposalAuthorizer(ctx context.Context, proposerAddress
 	return proposer, nil
 }
 
-func (s *ServiceImpl) sendTransaction(ctx context.Context, tx *Transaction) error {
-	// TODO: we should "recreate" the transaction as proposal key sequence numbering
-	// might have gotten out of sync by now (in async situations)
+// userAuthorizer returns the authorizer of a custodial account, if the
+// account is allowed to sign.
+func (s *ServiceImpl) userAuthorizer(ctx context.Context, address flow.Address) (keys.Authorizer, error) {
+	if s.signerCheck != nil {
+		if err := s.signerCheck(flow_helpers.FormatAddress(address)); err != nil {
+			return keys.Authorizer{}, err
+		}
+	}
+
+	return s.km.UserAuthorizer(ctx, address)
+}
+
+// getPayerAuthorizer returns the authorizer for the payer of a transaction.
+// The admin account pays unless a custodial account is given. A custodial
+// proposer paying for its own transaction uses its proposal key as the payer
+// key.
+func (s *ServiceImpl) getPayerAuthorizer(ctx context.Context, payerAddress string, proposer keys.Authorizer) (keys.Authorizer, error) {
+	if payerAddress == "" {
+		payerAddress = s.cfg.AdminAddress
+	}
+
+	payerAddress, err := flow_helpers.ValidateAddress(payerAddress, s.cfg.ChainID)
+	if err != nil {
+		return keys.Authorizer{}, err
+	}
+
+	if payerAddress == s.cfg.AdminAddress {
+		payer, err := s.km.AdminAuthorizer(ctx)
+		if err != nil {
+			return keys.Authorizer{}, fmt.Errorf("error while getting admin authorizer for payer: %w", err)
+		}
+		return payer, nil
+	}
+
+	if flow.HexToAddress(payerAddress) == proposer.Address && proposer.Weight() >= flow.AccountKeyWeightThreshold {
+		return proposer, nil
+	}
+
+	payer, err := s.userAuthorizer(ctx, flow.HexToAddress(payerAddress))
+	if err != nil {
+		return keys.Authorizer{}, fmt.Errorf("error while getting user authorizer for payer: %w", err)
+	}
+
+	return payer, nil
+}
+
+// getAuthorizers returns the authorizers that need to sign the transaction
+// payload. Accounts covered by the payer envelope signature or by a full weight
+// proposer payload signature are skipped, as are duplicates.
+func (s *ServiceImpl) getAuthorizers(ctx context.Context, addresses []flow.Address, proposer, payer keys.Authorizer) ([]keys.Authorizer, error) {
+	var authorizers []keys.Authorizer
+
+	seen := make(map[flow.Address]bool, len(addresses))
+
+	for _, address := range addresses {
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+
+		if address == payer.Address {
+			continue
+		}
+
+		if address == proposer.Address && proposer.Weight() >= flow.AccountKeyWeightThreshold {
+			continue
+		}
+
+		var (
+			authorizer keys.Authorizer
+			err        error
+		)
+
+		if flow_helpers.FormatAddress(address) == s.cfg.AdminAddress {
+			authorizer, err = s.km.AdminAuthorizer(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("error while getting admin authorizer: %w", err)
+			}
+		} else {
+			authorizer, err = s.userAuthorizer(ctx, address)
+			if err != nil {
+				return nil, fmt.Errorf("error while getting user authorizer for %s: %w", address, err)
+			}
+		}
+
+		authorizers = append(authorizers, authorizer)
+	}
+
+	return authorizers, nil
+}
+
+// refreshTransaction re-signs a transaction that has not been sent yet with a
+// fresh reference block and proposal key if its reference block has expired or
+// its proposal key sequence number has already been used. The stored
+// transaction is updated to point to the new Flow transaction. Externally
+// signed transactions are left as is.
+func (s *ServiceImpl) refreshTransaction(ctx context.Context, tx *Transaction) error {
+	entry := log.WithFields(log.Fields{"transactionId": tx.TransactionId, "function": "ServiceImpl.refreshTransaction"})
+
+	if tx.ExternallySigned {
+		// Can not be re-signed without the external signers
+		return nil
+	}
 
 	flowTx, err := flow.DecodeTransaction(tx.FlowTransaction)
 	if err != nil {
 		return err
 	}
 
-	// Check if transaction has been sent already.
-	_, err = s.fc.GetTransaction(ctx, flowTx.ID())
+	if sent, err := s.isSent(ctx, flowTx.ID()); err != nil {
+		return err
+	} else if sent {
+		// Already on its way, nothing to refresh
+		return nil
+	}
+
+	stale, err := s.isStale(ctx, flowTx)
+	if err != nil {
+		return err
+	}
+
+	if !stale {
+		return nil
+	}
+
+	entry.Debug("Transaction is stale, re-signing")
+
+	newFlowTx := flow.NewTransaction().
+		SetScript(flowTx.Script).
+		SetGasLimit(flowTx.GasLimit)
+
+	for _, arg := range flowTx.Arguments {
+		newFlowTx.AddRawArgument(arg)
+	}
+
+	for _, a := range flowTx.Authorizers {
+		newFlowTx.AddAuthorizer(a)
+	}
+
+	if err := s.signFlowTransaction(ctx, newFlowTx, tx.ProposerAddress, flow_helpers.FormatAddress(flowTx.Payer)); err != nil {
+		return fmt.Errorf("error while re-signing transaction: %w", err)
+	}
+
+	oldId := tx.TransactionId
+
+	tx.PreviousTransactionId = oldId
+	tx.TransactionId = newFlowTx.ID().Hex()
+	tx.FlowTransaction = newFlowTx.Encode()
+
+	if err := s.store.ReplaceTransaction(oldId, tx); err != nil {
+		return fmt.Errorf("error while updating re-signed transaction in db: %w", err)
+	}
+
+	entry.WithFields(log.Fields{"newTransactionId": tx.TransactionId}).Info("Re-signed stale transaction")
+
+	return nil
+}
+
+// isSent checks whether the access node already knows the given transaction.
+func (s *ServiceImpl) isSent(ctx context.Context, id flow.Identifier) (bool, error) {
+	_, err := s.fc.GetTransaction(ctx, id)
 	if err != nil {
 		rpcErr, ok := err.(grpc.RPCError)
 		if !ok {
 			// The error wasn't from gRPC.
-			return err
+			return false, err
 		}
 
 		if rpcErr.GRPCStatus().Code() != codes.NotFound {
 			// Something unexpected went wrong in the gRPC call or in the Access API.
-			return err
+			return false, err
+		}
+
+		// The Flow transaction was not found.
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// isStale checks whether the reference block of the given transaction has
+// expired (or is about to) or its proposal key sequence number has been used.
+// A sequence number ahead of the on-chain one is not stale, the transactions
+// before it are still pending.
+func (s *ServiceImpl) isStale(ctx context.Context, flowTx *flow.Transaction) (bool, error) {
+	latestBlock, err := s.fc.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return false, err
+	}
+
+	referenceBlock, err := s.fc.GetBlockHeaderByID(ctx, flowTx.ReferenceBlockID)
+	if err != nil {
+		if rpcErr, ok := err.(grpc.RPCError); ok && rpcErr.GRPCStatus().Code() == codes.NotFound {
+			// Reference block is not known by the access node (e.g. previous spork)
+			return true, nil
+		}
+		return false, err
+	}
+
+	if latestBlock.Height > referenceBlock.Height &&
+		latestBlock.Height-referenceBlock.Height >= flow_helpers.TransactionExpiry-referenceBlockExpiryMargin {
+		return true, nil
+	}
+
+	proposer, err := s.fc.GetAccount(ctx, flowTx.ProposalKey.Address)
+	if err != nil {
+		return false, err
+	}
+
+	for _, k := range proposer.Keys {
+		if k.Index == flowTx.ProposalKey.KeyIndex {
+			return k.SequenceNumber > flowTx.ProposalKey.SequenceNumber, nil
 		}
+	}
+
+	return false, fmt.Errorf("proposal key %d not found for %s", flowTx.ProposalKey.KeyIndex, flowTx.ProposalKey.Address)
+}
+
+func (s *ServiceImpl) sendTransaction(ctx context.Context, tx *Transaction) error {
+	flowTx, err := flow.DecodeTransaction(tx.FlowTransaction)
+	if err != nil {
+		return err
+	}
 
-		// The Flow transaction was not found. All good. Continue.
+	// Check if transaction has been sent already.
+	if _, err := s.isSent(ctx, flowTx.ID()); err != nil {
+		return err
 	}
 
 	// Ratelimit
 	s.txRateLimiter.Take()
 
 	resp, err := flow_helpers.SendAndWait(ctx, s.fc, *flowTx, s.cfg.TransactionTimeout)
+
+	// Store the status whether the transaction succeeded or not, the reconciler
+	// will take care of transactions that did not reach a final status here.
+	if resp != nil {
+		if err := s.updateStatus(ctx, tx, resp); err != nil {
+			return err
+		}
+	}
+
 	if err != nil {
 		return err
 	}
@@ -342,3 +880,41 @@ func (s *ServiceImpl) sendTransaction(ctx context.Context, tx *Transaction) erro
 
 	return nil
 }
+
+// updateStatus sets the status related fields of tx from the given result and
+// stores them along with the events of a transaction with a final status.
+func (s *ServiceImpl) updateStatus(ctx context.Context, tx *Transaction, result *flow.TransactionResult) error {
+	tx.Status = statusFromResult(result)
+
+	if result.Error != nil {
+		tx.ErrorMessage = result.Error.Error()
+	} else if tx.Status == StatusExpired {
+		tx.ErrorMessage = "transaction expired"
+	}
+
+	if f, ok := feesFromEvents(result.Events); ok {
+		tx.FeeAmount = f.Amount
+		tx.InclusionEffort = f.InclusionEffort
+		tx.ExecutionEffort = f.ExecutionEffort
+	}
+
+	if tx.BlockHeight == 0 && result.BlockID != flow.EmptyID {
+		header, err := s.fc.GetBlockHeaderByID(ctx, result.BlockID)
+		if err != nil {
+			return err
+		}
+		if header != nil {
+			tx.BlockHeight = header.Height
+		}
+	}
+
+	if err := s.store.UpdateTransactionStatus(tx); err != nil {
+		return err
+	}
+
+	if (tx.Status == StatusSealed || tx.Status == StatusFailed) && !tx.EventsStored {
+		return s.storeResultEvents(tx, result.Events)
+	}
+
+	return nil
+}
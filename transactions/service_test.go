@@ -0,0 +1,29 @@
+package transactions
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+)
+
+func TestGasLimit(t *testing.T) {
+	svc := &ServiceImpl{cfg: &configs.Config{TransactionMaxGasLimit: 20000}}
+
+	if l, err := svc.gasLimit(0); err != nil || l != defaultGasLimit {
+		t.Fatalf("expected default gas limit %d, got %d (err: %v)", defaultGasLimit, l, err)
+	}
+
+	if l, err := svc.gasLimit(15000); err != nil || l != 15000 {
+		t.Fatalf("expected requested gas limit 15000, got %d (err: %v)", l, err)
+	}
+
+	if _, err := svc.gasLimit(20001); err == nil {
+		t.Fatal("expected an error when exceeding the maximum gas limit")
+	}
+
+	svc.cfg.TransactionMaxGasLimit = 1000
+
+	if l, err := svc.gasLimit(0); err != nil || l != 1000 {
+		t.Fatalf("expected default gas limit to be capped to 1000, got %d (err: %v)", l, err)
+	}
+}
@@ -6,11 +6,39 @@ import (
 
 // Store manages data regarding transactions.
 type Store interface {
-	Transactions(opt datastore.ListOptions) ([]Transaction, error)
+	Transactions(status Status, opt datastore.ListOptions) ([]Transaction, error)
 	Transaction(txId string) (Transaction, error)
-	TransactionsForAccount(tType Type, address string, opt datastore.ListOptions) ([]Transaction, error)
+	TransactionsForAccount(tType Type, address string, status Status, opt datastore.ListOptions) ([]Transaction, error)
 	TransactionForAccount(tType Type, address, txId string) (Transaction, error)
 	GetOrCreateTransaction(txId string) *Transaction
 	InsertTransaction(*Transaction) error
 	UpdateTransaction(*Transaction) error
+	// ReplaceTransaction updates the transaction stored with oldId to match t,
+	// including its transaction id. Batch references to oldId are updated too.
+	ReplaceTransaction(oldId string, t *Transaction) error
+	// UnresolvedTransactions returns transactions whose status may still
+	// change, least recently updated first.
+	UnresolvedTransactions(opt datastore.ListOptions) ([]Transaction, error)
+	// UpdateTransactionStatus updates only the on-chain status related fields.
+	UpdateTransactionStatus(*Transaction) error
+	// Events returns the stored events of a transaction ordered by event index.
+	Events(txId string) ([]Event, error)
+	// InsertEvents stores events, ignoring already stored ones.
+	InsertEvents([]Event) error
+	// InsertTransactionEvents stores the complete set of events of a
+	// transaction and marks the transaction as having its events stored.
+	InsertTransactionEvents(txId string, events []Event) error
+	// FeeSummaries returns the sum of fees of transactions with recorded fees,
+	// grouped by groupBy.
+	FeeSummaries(groupBy FeeGrouping, filter FeeFilter) ([]FeeSummary, error)
+	// BatchReferences returns the stored batch references of the given
+	// references with their jobs.
+	BatchReferences(references []string) ([]BatchReference, error)
+	BatchReference(reference string) (BatchReference, error)
+	// InsertBatchReferences stores batch references, ignoring already stored
+	// references.
+	InsertBatchReferences([]BatchReference) error
+	// InsertBatchTransaction stores a transaction and links it to the batch
+	// reference r.
+	InsertBatchTransaction(r *BatchReference, t *Transaction) error
 }
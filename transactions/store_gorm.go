@@ -1,8 +1,11 @@
 package transactions
 
 import (
+	"time"
+
 	"github.com/flow-hydraulics/flow-wallet-api/datastore"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormStore struct {
@@ -15,8 +18,8 @@ func NewGormStore(db *gorm.DB) Store {
 
 // -- All transactions
 
-func (s *GormStore) Transactions(o datastore.ListOptions) (tt []Transaction, err error) {
-	q := &Transaction{}
+func (s *GormStore) Transactions(status Status, o datastore.ListOptions) (tt []Transaction, err error) {
+	q := &Transaction{Status: status}
 	err = s.db.
 		Where(q).
 		Order("created_at desc").
@@ -34,8 +37,8 @@ func (s *GormStore) Transaction(txId string) (t Transaction, err error) {
 
 // -- Transactions for an account
 
-func (s *GormStore) TransactionsForAccount(tType Type, address string, o datastore.ListOptions) (tt []Transaction, err error) {
-	q := &Transaction{ProposerAddress: address, TransactionType: tType}
+func (s *GormStore) TransactionsForAccount(tType Type, address string, status Status, o datastore.ListOptions) (tt []Transaction, err error) {
+	q := &Transaction{ProposerAddress: address, TransactionType: tType, Status: status}
 	err = s.db.
 		Where(q).
 		Order("created_at desc").
@@ -68,3 +71,175 @@ func (s *GormStore) InsertTransaction(t *Transaction) error {
 func (s *GormStore) UpdateTransaction(t *Transaction) error {
 	return s.db.Save(t).Error
 }
+
+func (s *GormStore) ReplaceTransaction(oldId string, t *Transaction) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Model(&Transaction{}).
+			Where(&Transaction{TransactionId: oldId}).
+			Updates(map[string]interface{}{
+				"transaction_id":          t.TransactionId,
+				"previous_transaction_id": t.PreviousTransactionId,
+				"flow_transaction":        t.FlowTransaction,
+				// A re-signed transaction has not been sent yet
+				"status": StatusPending,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.
+			Model(&BatchReference{}).
+			Where(&BatchReference{TransactionId: oldId}).
+			Update("transaction_id", t.TransactionId).Error
+	})
+}
+
+func (s *GormStore) UnresolvedTransactions(o datastore.ListOptions) (tt []Transaction, err error) {
+	err = s.db.
+		Where("status IN ?", unresolvedStatuses).
+		Order("updated_at asc").
+		Limit(o.Limit).
+		Offset(o.Offset).
+		Find(&tt).Error
+	return
+}
+
+func (s *GormStore) UpdateTransactionStatus(t *Transaction) error {
+	return s.db.
+		Model(&Transaction{}).
+		Where(&Transaction{TransactionId: t.TransactionId}).
+		Updates(map[string]interface{}{
+			"status":           t.Status,
+			"error_message":    t.ErrorMessage,
+			"block_height":     t.BlockHeight,
+			"fee_amount":       t.FeeAmount,
+			"inclusion_effort": t.InclusionEffort,
+			"execution_effort": t.ExecutionEffort,
+			"updated_at":       time.Now(),
+		}).Error
+}
+
+// -- Events
+
+func (s *GormStore) Events(txId string) (ee []Event, err error) {
+	err = s.db.
+		Where(&Event{TransactionId: txId}).
+		Order("event_index asc").
+		Find(&ee).Error
+	return
+}
+
+func (s *GormStore) InsertEvents(ee []Event) error {
+	if len(ee) == 0 {
+		return nil
+	}
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&ee).Error
+}
+
+func (s *GormStore) InsertTransactionEvents(txId string, ee []Event) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if len(ee) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ee).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.
+			Model(&Transaction{}).
+			Where(&Transaction{TransactionId: txId}).
+			Update("events_stored", true).Error
+	})
+}
+
+// -- Fees
+
+// feeSummaryColumns are the aggregated columns of a fee summary.
+const feeSummaryColumns = "COUNT(*) AS transaction_count, " +
+	"COALESCE(SUM(fee_amount), 0) AS fee_amount, " +
+	"COALESCE(SUM(inclusion_effort), 0) AS inclusion_effort, " +
+	"COALESCE(SUM(execution_effort), 0) AS execution_effort"
+
+func (s *GormStore) FeeSummaries(groupBy FeeGrouping, f FeeFilter) (ss []FeeSummary, err error) {
+	column := groupBy.column()
+
+	q := s.db.
+		Model(&Transaction{}).
+		Select(column + " AS grp, " + feeSummaryColumns).
+		Where("fee_amount > 0")
+
+	if !f.From.IsZero() {
+		q = q.Where("created_at >= ?", f.From)
+	}
+
+	if !f.To.IsZero() {
+		q = q.Where("created_at < ?", f.To)
+	}
+
+	err = q.
+		Group(column).
+		Order(column).
+		Scan(&ss).Error
+	return
+}
+
+// -- Batch references
+
+// batchReferenceQuerySize is the maximum number of references per query,
+// keeping queries below the parameter limits of the databases.
+const batchReferenceQuerySize = 500
+
+func (s *GormStore) BatchReferences(references []string) ([]BatchReference, error) {
+	rr := make([]BatchReference, 0, len(references))
+
+	for start := 0; start < len(references); start += batchReferenceQuerySize {
+		end := start + batchReferenceQuerySize
+		if end > len(references) {
+			end = len(references)
+		}
+
+		var chunk []BatchReference
+		err := s.db.
+			Preload("Job").
+			Where("reference IN ?", references[start:end]).
+			Find(&chunk).Error
+		if err != nil {
+			return nil, err
+		}
+
+		rr = append(rr, chunk...)
+	}
+
+	return rr, nil
+}
+
+func (s *GormStore) BatchReference(reference string) (r BatchReference, err error) {
+	err = s.db.Where(&BatchReference{Reference: reference}).First(&r).Error
+	return
+}
+
+func (s *GormStore) InsertBatchReferences(rr []BatchReference) error {
+	if len(rr) == 0 {
+		return nil
+	}
+
+	return s.db.
+		Omit("Job").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&rr, batchReferenceQuerySize).Error
+}
+
+func (s *GormStore) InsertBatchTransaction(r *BatchReference, t *Transaction) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(t).Error; err != nil {
+			return err
+		}
+
+		r.TransactionId = t.TransactionId
+
+		return tx.
+			Model(&BatchReference{}).
+			Where(&BatchReference{Reference: r.Reference}).
+			Update("transaction_id", r.TransactionId).Error
+	})
+}
@@ -1,14 +1,31 @@
 package transactions
 
 import (
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/onflow/flow-go-sdk"
 	"gorm.io/gorm"
 )
 
-const maxGasLimit = 9999
+// defaultGasLimit is used for transactions that do not specify a gas limit,
+// capped by the configured maximum.
+const defaultGasLimit = 9999
+
+// DefaultGasLimit returns the gas limit of transactions that do not specify
+// one, the default capped by the configured maximum.
+func DefaultGasLimit(maxGasLimit uint64) uint64 {
+	if defaultGasLimit > maxGasLimit {
+		return maxGasLimit
+	}
+	return defaultGasLimit
+}
+
+// referenceBlockExpiryMargin is the number of blocks before the actual expiry
+// at which a transaction's reference block is considered stale.
+const referenceBlockExpiryMargin = 50
 
 type SignedTransaction struct {
 	flow.Transaction
@@ -80,16 +97,78 @@ func (st *SignedTransaction) ToJSONResponse() (SignedTransactionJSONResponse, er
 	return res, nil
 }
 
+// Externally signed transaction JSON HTTP request. Either a hex encoded RLP
+// transaction or the fields of a SignedTransactionJSONResponse can be given.
+type SignedTransactionJSONRequest struct {
+	SignedTransactionJSONResponse
+	RLP string `json:"rlp,omitempty"`
+}
+
+// ToFlowTransaction converts the request to a Flow transaction. Envelope
+// signatures are dropped as the envelope is signed by this service.
+func (r SignedTransactionJSONRequest) ToFlowTransaction() (*flow.Transaction, error) {
+	if r.RLP != "" {
+		b, err := hex.DecodeString(strings.TrimPrefix(r.RLP, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rlp: %w", err)
+		}
+
+		tx, err := flow.DecodeTransaction(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rlp: %w", err)
+		}
+
+		tx.EnvelopeSignatures = nil
+
+		return tx, nil
+	}
+
+	tx := flow.NewTransaction().
+		SetScript([]byte(r.Code)).
+		SetReferenceBlockID(flow.HexToID(r.ReferenceBlockID)).
+		SetGasLimit(r.GasLimit).
+		SetProposalKey(flow.HexToAddress(r.ProposalKey.Address), r.ProposalKey.KeyIndex, r.ProposalKey.SequenceNumber).
+		SetPayer(flow.HexToAddress(r.Payer))
+
+	for _, a := range r.Arguments {
+		tx.AddRawArgument(a)
+	}
+
+	for _, a := range r.Authorizers {
+		tx.AddAuthorizer(flow.HexToAddress(a))
+	}
+
+	for _, s := range r.PayloadSignatures {
+		sig, err := hex.DecodeString(strings.TrimPrefix(s.Signature, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature: %w", err)
+		}
+		tx.AddPayloadSignature(flow.HexToAddress(s.Address), s.KeyIndex, sig)
+	}
+
+	return tx, nil
+}
+
 // Transaction is the database model for all transactions.
 type Transaction struct {
-	TransactionId   string         `gorm:"column:transaction_id;primaryKey"`
-	TransactionType Type           `gorm:"column:transaction_type;index"`
-	ProposerAddress string         `gorm:"column:proposer_address;index"`
-	FlowTransaction []byte         `gorm:"column:flow_transaction;type:bytes"`
-	CreatedAt       time.Time      `gorm:"column:created_at"`
-	UpdatedAt       time.Time      `gorm:"column:updated_at"`
-	DeletedAt       gorm.DeletedAt `gorm:"column:deleted_at;index"`
-	Events          []flow.Event   `gorm:"-"`
+	TransactionId         string         `gorm:"column:transaction_id;primaryKey"`
+	PreviousTransactionId string         `gorm:"column:previous_transaction_id;index"`
+	TransactionType       Type           `gorm:"column:transaction_type;index"`
+	ProposerAddress       string         `gorm:"column:proposer_address;index"`
+	PayerAddress          string         `gorm:"column:payer_address;index"`
+	FlowTransaction       []byte         `gorm:"column:flow_transaction;type:bytes"`
+	Status                Status         `gorm:"column:status;default:PENDING;index"`
+	ErrorMessage          string         `gorm:"column:error_message"`
+	BlockHeight           uint64         `gorm:"column:block_height"`
+	EventsStored          bool           `gorm:"column:events_stored"`
+	ExternallySigned      bool           `gorm:"column:externally_signed"`
+	FeeAmount             uint64         `gorm:"column:fee_amount"`
+	InclusionEffort       uint64         `gorm:"column:inclusion_effort"`
+	ExecutionEffort       uint64         `gorm:"column:execution_effort"`
+	CreatedAt             time.Time      `gorm:"column:created_at"`
+	UpdatedAt             time.Time      `gorm:"column:updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"column:deleted_at;index"`
+	Events                []flow.Event   `gorm:"-"`
 }
 
 func (Transaction) TableName() string {
@@ -98,25 +177,82 @@ func (Transaction) TableName() string {
 
 // Transaction JSON HTTP request
 type JSONRequest struct {
-	Code      string     `json:"code"`
-	Arguments []Argument `json:"arguments"`
+	Code        string     `json:"code"`
+	Arguments   []Argument `json:"arguments"`
+	Authorizers []string   `json:"authorizers,omitempty"`
+	GasLimit    uint64     `json:"gasLimit,omitempty"`
+	Payer       string     `json:"payer,omitempty"`
+}
+
+// Script JSON HTTP request
+type ScriptJSONRequest struct {
+	Code        string     `json:"code"`
+	Arguments   []Argument `json:"arguments"`
+	BlockHeight *uint64    `json:"blockHeight,omitempty"`
+	BlockID     string     `json:"blockId,omitempty"`
+}
+
+// Options returns the script options given in the request.
+func (r ScriptJSONRequest) Options() []ScriptOption {
+	var opts []ScriptOption
+	if r.BlockHeight != nil {
+		opts = append(opts, WithBlockHeight(*r.BlockHeight))
+	}
+	if r.BlockID != "" {
+		opts = append(opts, WithBlockID(r.BlockID))
+	}
+	return opts
 }
 
 // Transaction JSON HTTP response
 type JSONResponse struct {
-	TransactionId   string       `json:"transactionId"`
-	TransactionType Type         `json:"transactionType"`
-	Events          []flow.Event `json:"events,omitempty"`
-	CreatedAt       time.Time    `json:"createdAt"`
-	UpdatedAt       time.Time    `json:"updatedAt"`
+	TransactionId         string       `json:"transactionId"`
+	PreviousTransactionId string       `json:"previousTransactionId,omitempty"`
+	TransactionType       Type         `json:"transactionType"`
+	Status                Status       `json:"status"`
+	ErrorMessage          string       `json:"errorMessage,omitempty"`
+	BlockHeight           uint64       `json:"blockHeight,omitempty"`
+	PayerAddress          string       `json:"payerAddress,omitempty"`
+	Fees                  string       `json:"fees,omitempty"`
+	InclusionEffort       string       `json:"inclusionEffort,omitempty"`
+	ExecutionEffort       string       `json:"executionEffort,omitempty"`
+	Events                []flow.Event `json:"events,omitempty"`
+	CreatedAt             time.Time    `json:"createdAt"`
+	UpdatedAt             time.Time    `json:"updatedAt"`
 }
 
 func (t Transaction) ToJSONResponse() JSONResponse {
 	return JSONResponse{
-		TransactionId:   t.TransactionId,
-		TransactionType: t.TransactionType,
-		Events:          t.Events,
-		CreatedAt:       t.CreatedAt,
-		UpdatedAt:       t.UpdatedAt,
+		TransactionId:         t.TransactionId,
+		PreviousTransactionId: t.PreviousTransactionId,
+		TransactionType:       t.TransactionType,
+		Status:                t.Status,
+		ErrorMessage:          t.ErrorMessage,
+		BlockHeight:           t.BlockHeight,
+		PayerAddress:          t.PayerAddress,
+		Fees:                  formatUFix64(t.FeeAmount),
+		InclusionEffort:       formatUFix64(t.InclusionEffort),
+		ExecutionEffort:       formatUFix64(t.ExecutionEffort),
+		Events:                t.Events,
+		CreatedAt:             t.CreatedAt,
+		UpdatedAt:             t.UpdatedAt,
+	}
+}
+
+// Transaction JSON HTTP response with decoded event payloads
+type DecodedJSONResponse struct {
+	JSONResponse
+	Events []DecodedEvent `json:"events,omitempty"`
+}
+
+func (t Transaction) ToDecodedJSONResponse() DecodedJSONResponse {
+	events := make([]DecodedEvent, len(t.Events))
+	for i, e := range t.Events {
+		events[i] = DecodeEvent(e)
+	}
+
+	return DecodedJSONResponse{
+		JSONResponse: t.ToJSONResponse(),
+		Events:       events,
 	}
 }
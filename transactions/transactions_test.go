@@ -0,0 +1,71 @@
+package transactions
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestSignedTransactionJSONRequest(t *testing.T) {
+	proposer := flow.HexToAddress("0x01cf0e2f2f715450")
+	payer := flow.HexToAddress("0xf8d6e0586b0a20c7")
+
+	original := flow.NewTransaction().
+		SetScript([]byte("transaction(greeting: String) { prepare(signer: AuthAccount){} execute {}}")).
+		SetReferenceBlockID(flow.HexToID("0e4f500d6965c7fc0ff1239525e09eb9dd27c00a511976e353d9f6a44ca22921")).
+		SetGasLimit(100).
+		SetProposalKey(proposer, 1, 42).
+		SetPayer(payer).
+		AddAuthorizer(proposer).
+		AddRawArgument([]byte(`{"type":"String","value":"Hello"}`))
+
+	// Signatures are not verified here, any bytes will do
+	original.AddPayloadSignature(proposer, 1, []byte("payload signature"))
+	original.AddEnvelopeSignature(payer, 0, []byte("envelope signature"))
+
+	t.Run("json", func(t *testing.T) {
+		res, err := (&SignedTransaction{Transaction: *original}).ToJSONResponse()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tx, err := SignedTransactionJSONRequest{SignedTransactionJSONResponse: res}.ToFlowTransaction()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(tx.PayloadMessage()) != string(original.PayloadMessage()) {
+			t.Fatal("expected payload to match the original")
+		}
+
+		if len(tx.PayloadSignatures) != 1 || len(tx.EnvelopeSignatures) != 0 {
+			t.Fatalf("expected 1 payload and 0 envelope signatures, got %d and %d", len(tx.PayloadSignatures), len(tx.EnvelopeSignatures))
+		}
+
+		if string(tx.PayloadSignatures[0].Signature) != "payload signature" {
+			t.Fatal("expected payload signature to match the original")
+		}
+	})
+
+	t.Run("rlp", func(t *testing.T) {
+		tx, err := SignedTransactionJSONRequest{RLP: hex.EncodeToString(original.Encode())}.ToFlowTransaction()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(tx.PayloadMessage()) != string(original.PayloadMessage()) {
+			t.Fatal("expected payload to match the original")
+		}
+
+		if len(tx.EnvelopeSignatures) != 0 {
+			t.Fatalf("expected envelope signatures to be dropped, got %d", len(tx.EnvelopeSignatures))
+		}
+	})
+
+	t.Run("invalid rlp", func(t *testing.T) {
+		if _, err := (SignedTransactionJSONRequest{RLP: "not hex"}).ToFlowTransaction(); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
@@ -1,6 +1,11 @@
 package transactions
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onflow/flow-go-sdk"
+)
 
 //go:generate stringer -type=Type
 type Type int
@@ -39,3 +44,52 @@ func StatusFromText(text string) Type {
 		return NftTransfer
 	}
 }
+
+// Status is the on-chain status of a transaction.
+type Status string
+
+const (
+	StatusPending   Status = "PENDING"
+	StatusFinalized Status = "FINALIZED"
+	StatusExecuted  Status = "EXECUTED"
+	StatusSealed    Status = "SEALED"
+	StatusExpired   Status = "EXPIRED"
+	StatusFailed    Status = "FAILED"
+	// Transactions finished before statuses were tracked
+	StatusUnknown Status = "UNKNOWN"
+)
+
+// unresolvedStatuses are the statuses of transactions that may still change.
+var unresolvedStatuses = []Status{StatusPending, StatusFinalized, StatusExecuted}
+
+// ParseStatus parses a transaction status case-insensitively.
+func ParseStatus(text string) (Status, error) {
+	s := Status(strings.ToUpper(text))
+	switch s {
+	case StatusPending, StatusFinalized, StatusExecuted, StatusSealed, StatusExpired, StatusFailed, StatusUnknown:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid transaction status %q", text)
+	}
+}
+
+// statusFromResult maps a Flow transaction result to a Status. Transactions
+// with an execution error are considered failed.
+func statusFromResult(result *flow.TransactionResult) Status {
+	if result.Error != nil {
+		return StatusFailed
+	}
+
+	switch result.Status {
+	case flow.TransactionStatusFinalized:
+		return StatusFinalized
+	case flow.TransactionStatusExecuted:
+		return StatusExecuted
+	case flow.TransactionStatusSealed:
+		return StatusSealed
+	case flow.TransactionStatusExpired:
+		return StatusExpired
+	default:
+		return StatusPending
+	}
+}
@@ -0,0 +1,41 @@
+package transactions
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestParseStatus(t *testing.T) {
+	for _, text := range []string{"pending", "FINALIZED", "Executed", "sealed", "expired", "failed", "unknown"} {
+		if _, err := ParseStatus(text); err != nil {
+			t.Errorf("expected %q to be a valid status, got error: %s", text, err)
+		}
+	}
+
+	if _, err := ParseStatus("invalid"); err == nil {
+		t.Error("expected an error for an invalid status")
+	}
+}
+
+func TestStatusFromResult(t *testing.T) {
+	tests := []struct {
+		result   flow.TransactionResult
+		expected Status
+	}{
+		{flow.TransactionResult{Status: flow.TransactionStatusUnknown}, StatusPending},
+		{flow.TransactionResult{Status: flow.TransactionStatusPending}, StatusPending},
+		{flow.TransactionResult{Status: flow.TransactionStatusFinalized}, StatusFinalized},
+		{flow.TransactionResult{Status: flow.TransactionStatusExecuted}, StatusExecuted},
+		{flow.TransactionResult{Status: flow.TransactionStatusSealed}, StatusSealed},
+		{flow.TransactionResult{Status: flow.TransactionStatusExpired}, StatusExpired},
+		{flow.TransactionResult{Status: flow.TransactionStatusSealed, Error: fmt.Errorf("panic")}, StatusFailed},
+	}
+
+	for _, tt := range tests {
+		if got := statusFromResult(&tt.result); got != tt.expected {
+			t.Errorf("expected %s for %s, got %s", tt.expected, tt.result.Status, got)
+		}
+	}
+}